@@ -10,7 +10,6 @@ import (
 	"log"
 	"net/url"
 	"os"
-	"sort"
 	"sync"
 
 	"github.com/spf13/pflag"
@@ -109,9 +108,8 @@ func process(arg string) ([]match, error) {
 	}
 
 	var matches []match
-	for k, v := range ls {
-		matches = append(matches, match{k, v})
+	for _, m := range licensedb.Sorted(ls) {
+		matches = append(matches, match{m.License, m.Confidence})
 	}
-	sort.Slice(matches, func(i, j int) bool { return matches[i].Confidence > matches[j].Confidence })
 	return matches, nil
 }
@@ -0,0 +1,82 @@
+package licensedb
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+// archiveFilers map the extensions of the archive files to the corresponding filers.
+var archiveFilers = []struct {
+	extension string
+	open      func(path string) (filer.Filer, error)
+}{
+	{".zip", filer.FromZIP},
+	{".tar", filer.FromTarball},
+	{".tar.gz", filer.FromTarball},
+	{".tgz", filer.FromTarball},
+	{".siva", filer.FromSiva},
+}
+
+// DetectArchives detects the licenses of the archives in the local directory, e.g. the release
+// tarballs in the downloads folder. Each .zip, .tar, .tar.gz, .tgz or .siva file is opened with
+// the corresponding filer and detected like Detect. If the archive contains the single top
+// level directory, e.g. "foo-1.0/", the detection starts there. The result maps the names of
+// the archive files to the licenses; the archives which have no license are not included.
+// The second result maps the names of the archive files which cannot be opened to the errors.
+// The files inside the archives which cannot be read, e.g. too big to be loaded from
+// the compressed tarball, are skipped like Detect does. The subdirectories are not scanned.
+func DetectArchives(dir string) (map[string]map[string]float32, map[string]error, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "cannot read directory %s", dir)
+	}
+	result := map[string]map[string]float32{}
+	failures := map[string]error{}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		open := openArchive(file.Name())
+		if open == nil {
+			continue
+		}
+		fs, err := open(filepath.Join(dir, file.Name()))
+		if err != nil {
+			failures[file.Name()] = err
+			continue
+		}
+		licenses, err := Detect(unwrapTopDirectory(fs))
+		fs.Close()
+		if err == nil {
+			result[file.Name()] = licenses
+		} else if err != ErrNoLicenseFound {
+			failures[file.Name()] = err
+		}
+	}
+	return result, failures, nil
+}
+
+// openArchive returns the filer constructor for the archive file name, see archiveFilers, or
+// nil if it is not an archive.
+func openArchive(name string) func(path string) (filer.Filer, error) {
+	name = strings.ToLower(name)
+	for _, archive := range archiveFilers {
+		if strings.HasSuffix(name, archive.extension) {
+			return archive.open
+		}
+	}
+	return nil
+}
+
+// unwrapTopDirectory nests the filer into the only entry of the root if it is a directory.
+func unwrapTopDirectory(fs filer.Filer) filer.Filer {
+	files, err := fs.ReadDir("")
+	if err != nil || len(files) != 1 || !files[0].IsDir {
+		return fs
+	}
+	return filer.NestFiler(fs, files[0].Name)
+}
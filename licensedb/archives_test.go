@@ -0,0 +1,61 @@
+package licensedb
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectArchives(t *testing.T) {
+	root, err := ioutil.TempDir("", "licensedb-archives-")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+	zipBuffer := &bytes.Buffer{}
+	zipWriter := zip.NewWriter(zipBuffer)
+	entry, err := zipWriter.Create("LICENSE")
+	assert.Nil(t, err)
+	_, err = entry.Write([]byte(referenceText(t, "MIT")))
+	assert.Nil(t, err)
+	assert.Nil(t, zipWriter.Close())
+	assert.Nil(t, ioutil.WriteFile(path.Join(root, "foo-1.0.zip"), zipBuffer.Bytes(), 0644))
+
+	tarBuffer := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(tarBuffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+	// the release tarballs have the single top level directory
+	text := []byte(referenceText(t, "Apache-2.0"))
+	assert.Nil(t, tarWriter.WriteHeader(&tar.Header{
+		Name: "bar-2.0/LICENSE", Mode: 0644, Size: int64(len(text)), Typeflag: tar.TypeReg}))
+	_, err = tarWriter.Write(text)
+	assert.Nil(t, err)
+	// too big to be loaded from the compressed archive, so it is skipped
+	huge := bytes.Repeat([]byte("All rights reserved.\n"), 100000)
+	assert.Nil(t, tarWriter.WriteHeader(&tar.Header{
+		Name: "bar-2.0/COPYING", Mode: 0644, Size: int64(len(huge)), Typeflag: tar.TypeReg}))
+	_, err = tarWriter.Write(huge)
+	assert.Nil(t, err)
+	assert.Nil(t, tarWriter.Close())
+	assert.Nil(t, gzipWriter.Close())
+	assert.Nil(t, ioutil.WriteFile(path.Join(root, "bar-2.0.tar.gz"), tarBuffer.Bytes(), 0644))
+
+	assert.Nil(t, ioutil.WriteFile(path.Join(root, "broken.tgz"), []byte("not an archive"), 0644))
+	assert.Nil(t, ioutil.WriteFile(path.Join(root, "README"), []byte(referenceText(t, "MIT")), 0644))
+
+	results, failures, err := DetectArchives(root)
+	assert.Nil(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "MIT", bestLicense(results["foo-1.0.zip"]))
+	assert.Equal(t, "Apache-2.0", bestLicense(results["bar-2.0.tar.gz"]))
+	assert.Len(t, failures, 1)
+	assert.NotNil(t, failures["broken.tgz"])
+
+	_, _, err = DetectArchives(path.Join(root, "missing"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,115 @@
+package licensedb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal"
+)
+
+// AuditRecord is the reproducible trail of a single detection run, e.g. for the compliance
+// reviews. It is serialized to JSON as is. Hash covers everything except Timestamp, so the same
+// files scanned with the same license database always produce the same Hash.
+type AuditRecord struct {
+	// Timestamp is when the detection finished.
+	Timestamp time.Time `json:"timestamp"`
+	// Database identifies the version of the license database, see internal.DatabaseDigest.
+	Database string `json:"database"`
+	// Inputs are the hex SHA-256 digests of the files which were read, mapped from their paths.
+	// The files of which only the leading bytes were read, e.g. the source files scanned for
+	// the license headers, have the digests of those bytes rather than of the whole content.
+	Inputs map[string]string `json:"inputs"`
+	// Result is the outcome of the detection, nil if no license was found.
+	Result *Result `json:"result"`
+	// Error is the reason why the detection did not succeed, e.g. ErrNoLicenseFound.
+	Error string `json:"error,omitempty"`
+	// Hash is the hex SHA-256 of the JSON of the other fields except Timestamp.
+	Hash string `json:"hash"`
+}
+
+// DetectAudited is the same as DetectDetailedWithOptions but returns the audit record
+// of the run. Not finding any license is recorded in the Error field rather than returned.
+func DetectAudited(fs filer.Filer, options Options) (*AuditRecord, error) {
+	digests := &digestingFiler{Filer: fs, digests: map[string]string{}}
+	var resolved filer.Filer = digests
+	if resolver, ok := fs.(filer.LFSResolver); ok {
+		resolved = &digestingLFSFiler{digestingFiler: digests, resolver: resolver}
+	}
+	record := &AuditRecord{Database: internal.DatabaseDigest(), Inputs: digests.digests}
+	result, err := detect(resolved, options, nil)
+	if err == ErrNoLicenseFound {
+		record.Error = err.Error()
+	} else if err != nil {
+		return nil, err
+	}
+	record.Result = result
+	record.Timestamp = time.Now().UTC()
+	if record.Hash, err = record.ComputeHash(); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ComputeHash returns the hex SHA-256 of the record without Timestamp and Hash. It fails if
+// the record cannot be serialized to JSON.
+func (record *AuditRecord) ComputeHash() (string, error) {
+	content := *record
+	content.Timestamp = time.Time{}
+	content.Hash = ""
+	// encoding/json sorts the map keys, so the serialization is stable
+	data, err := json.Marshal(&content)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot serialize the audit record")
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Verify indicates whether the record was not modified since it was created. The records which
+// cannot be serialized are not verified.
+func (record *AuditRecord) Verify() bool {
+	hash, err := record.ComputeHash()
+	return err == nil && record.Hash == hash
+}
+
+// digestingFiler records the SHA-256 of each file which was successfully read. The prefix
+// reads record the digest of the prefix unless the whole file was already read.
+type digestingFiler struct {
+	filer.Filer
+	digests map[string]string
+}
+
+func (fs *digestingFiler) ReadFile(path string) ([]byte, error) {
+	content, err := fs.Filer.ReadFile(path)
+	if err == nil {
+		fs.digest(path, content)
+	}
+	return content, err
+}
+
+func (fs *digestingFiler) ReadFilePrefix(path string, size int) ([]byte, error) {
+	content, err := filer.ReadFilePrefix(fs.Filer, path, size)
+	if _, exists := fs.digests[path]; err == nil && !exists {
+		fs.digest(path, content)
+	}
+	return content, err
+}
+
+func (fs *digestingFiler) digest(path string, content []byte) {
+	hash := sha256.Sum256(content)
+	fs.digests[path] = hex.EncodeToString(hash[:])
+}
+
+// digestingLFSFiler keeps the original Filer resolving the git-lfs pointers.
+type digestingLFSFiler struct {
+	*digestingFiler
+	resolver filer.LFSResolver
+}
+
+func (fs *digestingLFSFiler) ReadLFSObject(oid string) ([]byte, error) {
+	return fs.resolver.ReadLFSObject(oid)
+}
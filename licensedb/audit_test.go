@@ -0,0 +1,68 @@
+package licensedb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectAudited(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE":   referenceText(t, "MIT"),
+		"README.md": "# Foo\n",
+	}
+	record, err := DetectAudited(fs, Options{})
+	assert.Nil(t, err)
+	assert.Len(t, record.Database, 64)
+	assert.Len(t, record.Inputs, 1)
+	assert.Contains(t, record.Inputs, "LICENSE")
+	assert.Equal(t, "MIT", record.Result.Matches[0].License)
+	assert.Empty(t, record.Error)
+	assert.True(t, record.Verify())
+	time.Sleep(time.Millisecond)
+	again, err := DetectAudited(fs, Options{})
+	assert.Nil(t, err)
+	assert.NotEqual(t, record.Timestamp, again.Timestamp)
+	assert.Equal(t, record.Hash, again.Hash)
+
+	data, err := json.Marshal(record)
+	assert.Nil(t, err)
+	decoded := &AuditRecord{}
+	assert.Nil(t, json.Unmarshal(data, decoded))
+	assert.True(t, decoded.Verify())
+	decoded.Result.Matches[0].Confidence = 0.5
+	assert.False(t, decoded.Verify())
+	hash, err := record.ComputeHash()
+	assert.Nil(t, err)
+	assert.Equal(t, record.Hash, hash)
+	// NaN cannot be serialized to JSON
+	decoded.Result.Matches[0].Confidence = float32(math.NaN())
+	_, err = decoded.ComputeHash()
+	assert.NotNil(t, err)
+	assert.False(t, decoded.Verify())
+
+	fs["LICENSE"] += "\n"
+	changed, err := DetectAudited(fs, Options{})
+	assert.Nil(t, err)
+	assert.NotEqual(t, record.Hash, changed.Hash)
+	assert.NotEqual(t, record.Inputs["LICENSE"], changed.Inputs["LICENSE"])
+
+	record, err = DetectAudited(memoryFiler{"README.md": "# Foo\n"}, Options{})
+	assert.Nil(t, err)
+	assert.Nil(t, record.Result)
+	assert.Equal(t, ErrNoLicenseFound.Error(), record.Error)
+	assert.True(t, record.Verify())
+
+	// only the header window of the source file is read and digested
+	source := "// Licensed under the MIT license.\npackage foo\n" + strings.Repeat("// padding\n", 1000)
+	record, err = DetectAudited(memoryFiler{"foo.go": source}, Options{ScanHeaders: true})
+	assert.Nil(t, err)
+	prefix := sha256.Sum256([]byte(source[:4096]))
+	assert.Equal(t, hex.EncodeToString(prefix[:]), record.Inputs["foo.go"])
+}
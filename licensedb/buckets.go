@@ -0,0 +1,42 @@
+package licensedb
+
+import "sort"
+
+// Bucket is a named confidence range which starts at Min, inclusive, and ends at
+// the Min of the next higher bucket, exclusive.
+type Bucket struct {
+	Name string
+	Min  float32
+}
+
+// DefaultBuckets split the matches into "high" (≥0.95), "medium" (0.7 to 0.95)
+// and "low" (<0.7) confidence.
+var DefaultBuckets = []Bucket{
+	{Name: "high", Min: 0.95},
+	{Name: "medium", Min: 0.7},
+	{Name: "low", Min: 0},
+}
+
+// GroupByConfidence distributes the licenses returned by Detect among the buckets.
+// The matches in each bucket are sorted by confidence in descending order. The matches
+// below the lowest bucket are dropped and the empty buckets are not included.
+func GroupByConfidence(licenses map[string]float32, buckets []Bucket) map[string][]Match {
+	sorted := make([]Bucket, len(buckets))
+	copy(sorted, buckets)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Min > sorted[j].Min })
+	result := &Result{}
+	for name, confidence := range licenses {
+		result.Matches = append(result.Matches, Match{License: name, Confidence: confidence})
+	}
+	result.sort()
+	groups := map[string][]Match{}
+	for _, match := range result.Matches {
+		for _, bucket := range sorted {
+			if match.Confidence >= bucket.Min {
+				groups[bucket.Name] = append(groups[bucket.Name], match)
+				break
+			}
+		}
+	}
+	return groups
+}
@@ -0,0 +1,25 @@
+package licensedb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupByConfidence(t *testing.T) {
+	licenses := map[string]float32{
+		"MIT": 1, "Apache-2.0": 0.95, "ECL-2.0": 0.9, "BSD-2-Clause": 0.7, "ISC": 0.69, "X11": 0.1}
+	groups := GroupByConfidence(licenses, DefaultBuckets)
+	assert.Equal(t, map[string][]Match{
+		"high": {{License: "MIT", Confidence: 1}, {License: "Apache-2.0", Confidence: 0.95}},
+		"medium": {{License: "ECL-2.0", Confidence: 0.9},
+			{License: "BSD-2-Clause", Confidence: 0.7}},
+		"low": {{License: "ISC", Confidence: 0.69}, {License: "X11", Confidence: 0.1}},
+	}, groups)
+	groups = GroupByConfidence(licenses, []Bucket{{"sure", 0.99}, {"maybe", 0.5}})
+	assert.Equal(t, map[string][]Match{
+		"sure": {{License: "MIT", Confidence: 1}},
+		"maybe": {{License: "Apache-2.0", Confidence: 0.95}, {License: "ECL-2.0", Confidence: 0.9},
+			{License: "BSD-2-Clause", Confidence: 0.7}, {License: "ISC", Confidence: 0.69}},
+	}, groups)
+}
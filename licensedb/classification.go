@@ -0,0 +1,16 @@
+package licensedb
+
+var (
+	// licenses which publish the source code but are not approved by OSI because they restrict
+	// the commercial use or impose the copyleft beyond the derivative works
+	sourceAvailableLicenses = map[string]bool{
+		"Parity-7.0.0":     true,
+		"Prosperity-3.0.0": true,
+	}
+)
+
+// IsSourceAvailable indicates whether the license is source-available rather than open source,
+// that is, it is not approved by OSI. E.g. the License Zero family: Parity and Prosperity.
+func IsSourceAvailable(license string) bool {
+	return sourceAvailableLicenses[license]
+}
@@ -0,0 +1,158 @@
+package licensedb
+
+import (
+	"sort"
+	"strings"
+)
+
+var (
+	// license -> the licenses which cannot be combined with it in the same work, according to
+	// the FSF list of the GPL-incompatible licenses
+	incompatibleLicenses = map[string][]string{
+		"GPL-2.0-only": {
+			"Apache-2.0", "Apache-1.1", "GPL-3.0-only", "GPL-3.0-or-later", "LGPL-3.0-only",
+			"LGPL-3.0-or-later", "AGPL-3.0-only", "AGPL-3.0-or-later", "MPL-1.1", "EPL-1.0",
+			"EPL-2.0", "CPL-1.0", "IPL-1.0", "CDDL-1.0", "CDDL-1.1", "OpenSSL", "BSD-4-Clause",
+			"PHP-3.01", "MS-PL",
+		},
+		"GPL-2.0-or-later": {
+			"MPL-1.1", "EPL-1.0", "CPL-1.0", "IPL-1.0", "CDDL-1.0", "CDDL-1.1", "OpenSSL",
+			"BSD-4-Clause", "PHP-3.01", "MS-PL",
+		},
+		"GPL-3.0-only": {
+			"GPL-2.0-only", "MPL-1.1", "EPL-1.0", "CPL-1.0", "IPL-1.0", "CDDL-1.0", "CDDL-1.1",
+			"OpenSSL", "BSD-4-Clause", "PHP-3.01", "MS-PL",
+		},
+		"GPL-3.0-or-later": {
+			"GPL-2.0-only", "MPL-1.1", "EPL-1.0", "CPL-1.0", "IPL-1.0", "CDDL-1.0", "CDDL-1.1",
+			"OpenSSL", "BSD-4-Clause", "PHP-3.01", "MS-PL",
+		},
+		"AGPL-3.0-only": {
+			"GPL-2.0-only", "MPL-1.1", "EPL-1.0", "CPL-1.0", "IPL-1.0", "CDDL-1.0", "CDDL-1.1",
+			"OpenSSL", "BSD-4-Clause", "PHP-3.01", "MS-PL",
+		},
+		"AGPL-3.0-or-later": {
+			"GPL-2.0-only", "MPL-1.1", "EPL-1.0", "CPL-1.0", "IPL-1.0", "CDDL-1.0", "CDDL-1.1",
+			"OpenSSL", "BSD-4-Clause", "PHP-3.01", "MS-PL",
+		},
+	}
+
+	// deprecated SPDX identifier -> the current one
+	deprecatedLicenseIDs = map[string]string{
+		"GPL-2.0":   "GPL-2.0-only",
+		"GPL-2.0+":  "GPL-2.0-or-later",
+		"GPL-3.0":   "GPL-3.0-only",
+		"GPL-3.0+":  "GPL-3.0-or-later",
+		"LGPL-3.0":  "LGPL-3.0-only",
+		"LGPL-3.0+": "LGPL-3.0-or-later",
+		"AGPL-3.0":  "AGPL-3.0-only",
+	}
+)
+
+// Conflict is the pair of the incompatible licenses detected in the same tree.
+type Conflict struct {
+	// License and Other are the SPDX identifiers of the incompatible licenses.
+	License, Other string
+	// LicensePath and OtherPath are the directories where the licenses were detected,
+	// an empty string means the root.
+	LicensePath, OtherPath string
+}
+
+// CheckCompatibility returns the pairs of the incompatible licenses in the detection results,
+// which map the directory paths to the licenses detected there, see Subtrees.Licenses.
+// Only the most confident licenses in each directory are considered. The conflicts are sorted
+// by the paths and the licenses.
+func CheckCompatibility(results map[string]map[string]float32) []Conflict {
+	paths := make([]string, 0, len(results))
+	for path := range results {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	top := map[string][]string{}
+	for _, path := range paths {
+		top[path] = topLicenses(results[path])
+	}
+	var conflicts []Conflict
+	add := func(license, other, path, otherPath string) {
+		if areIncompatible(license, other) {
+			conflicts = append(conflicts, Conflict{
+				License: license, Other: other, LicensePath: path, OtherPath: otherPath})
+		}
+	}
+	for i, path := range paths {
+		// each pair is checked once, including the pairs in the same directory
+		for j, license := range top[path] {
+			for _, other := range top[path][j+1:] {
+				add(license, other, path, path)
+			}
+		}
+		for _, otherPath := range paths[i+1:] {
+			for _, license := range top[path] {
+				for _, other := range top[otherPath] {
+					add(license, other, path, otherPath)
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// Licenses returns the licenses of the root and of the conflicting subdirectories mapped from
+// the paths, the root path is an empty string. This is the argument of CheckCompatibility.
+func (subtrees *Subtrees) Licenses() map[string]map[string]float32 {
+	results := map[string]map[string]float32{}
+	if subtrees.Root != nil {
+		results[""] = subtrees.Root
+	}
+	for path, licenses := range subtrees.Conflicts {
+		results[path] = licenses
+	}
+	return results
+}
+
+// topLicenses returns the sorted current SPDX identifiers of the licenses with the maximum
+// confidence.
+func topLicenses(licenses map[string]float32) []string {
+	var max float32
+	for _, confidence := range licenses {
+		if confidence > max {
+			max = confidence
+		}
+	}
+	unique := map[string]bool{}
+	for name, confidence := range licenses {
+		if confidence == max {
+			unique[currentLicenseID(name)] = true
+		}
+	}
+	result := make([]string, 0, len(unique))
+	for name := range unique {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// currentLicenseID replaces the deprecated SPDX identifier with the current one.
+func currentLicenseID(name string) string {
+	name = strings.TrimPrefix(name, "deprecated_")
+	if current, exists := deprecatedLicenseIDs[name]; exists {
+		return current
+	}
+	return name
+}
+
+// areIncompatible checks the compatibility matrix in both directions.
+func areIncompatible(license, other string) bool {
+	for _, name := range incompatibleLicenses[license] {
+		if name == other {
+			return true
+		}
+	}
+	for _, name := range incompatibleLicenses[other] {
+		if name == license {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package licensedb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE":             referenceText(t, "Apache-2.0"),
+		"main.go":             "package main",
+		"gpl-module/COPYING":  referenceText(t, "GPL-2.0-only"),
+		"mit-module/LICENSE":  referenceText(t, "MIT"),
+		"gpl-module/module.c": "int main() {}",
+	}
+	subtrees, err := DetectWithSubtrees(fs)
+	assert.Nil(t, err)
+	conflicts := CheckCompatibility(subtrees.Licenses())
+	assert.Equal(t, []Conflict{{
+		License: "Apache-2.0", Other: "GPL-2.0-only", LicensePath: "", OtherPath: "gpl-module",
+	}}, conflicts)
+	assert.Len(t, CheckCompatibility(map[string]map[string]float32{
+		"":    {"Apache-2.0": 1},
+		"lib": {"GPL-2.0-or-later": 1, "deprecated_GPL-2.0+": 1},
+		"doc": {"MIT": 1},
+	}), 0)
+	// the dual licensed root conflicts with itself once and with GPL-3.0-only of the subdirectory
+	conflicts = CheckCompatibility(map[string]map[string]float32{
+		"":    {"Apache-2.0": 1, "GPL-2.0-only": 1},
+		"lib": {"GPL-3.0-only": 1},
+	})
+	assert.Equal(t, []Conflict{
+		{License: "Apache-2.0", Other: "GPL-2.0-only", LicensePath: "", OtherPath: ""},
+		{License: "GPL-2.0-only", Other: "GPL-3.0-only", LicensePath: "", OtherPath: "lib"},
+	}, conflicts)
+}
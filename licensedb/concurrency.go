@@ -0,0 +1,91 @@
+package licensedb
+
+import (
+	"sync"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+// limiter is the semaphore which bounds the number of the candidates investigated at the same
+// time. The same limiter is shared by all the plans of a single detection, see
+// Options.MaxConcurrency. The nil limiter investigates sequentially.
+type limiter chan struct{}
+
+func newLimiter(size int) limiter {
+	if size <= 1 {
+		return nil
+	}
+	return make(limiter, size)
+}
+
+// run executes the tasks and waits until all of them finish.
+func (l limiter) run(tasks []func()) {
+	if l == nil {
+		for _, task := range tasks {
+			task()
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	wg.Add(len(tasks))
+	for _, task := range tasks {
+		l <- struct{}{}
+		go func(task func()) {
+			defer func() {
+				<-l
+				wg.Done()
+			}()
+			task()
+		}(task)
+	}
+	wg.Wait()
+}
+
+// investigate calls add for each of the n candidates with the separate Result and appends
+// the matches to `result` in the order of the candidates, so that it does not depend on
+// the scheduling.
+func (l limiter) investigate(result *Result, n int, add func(i int, part *Result)) {
+	parts := make([]Result, n)
+	tasks := make([]func(), n)
+	for i := range tasks {
+		i := i
+		tasks[i] = func() { add(i, &parts[i]) }
+	}
+	l.run(tasks)
+	for _, part := range parts {
+		result.Matches = append(result.Matches, part.Matches...)
+	}
+}
+
+// wrap serializes the reads if the candidates are investigated concurrently, since neither
+// the Filer implementations nor the wrappers in detect are safe for the concurrent use.
+func (l limiter) wrap(fs filer.Filer) filer.Filer {
+	if l == nil {
+		return fs
+	}
+	return &lockedFiler{Filer: fs}
+}
+
+// lockedFiler allows only one read at a time.
+type lockedFiler struct {
+	filer.Filer
+	lock sync.Mutex
+}
+
+func (fs *lockedFiler) ReadFile(path string) ([]byte, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	return fs.Filer.ReadFile(path)
+}
+
+func (fs *lockedFiler) ReadFilePrefix(path string, size int) ([]byte, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	return filer.ReadFilePrefix(fs.Filer, path, size)
+}
+
+func (fs *lockedFiler) ReadDir(path string) ([]filer.File, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	return fs.Filer.ReadDir(path)
+}
@@ -0,0 +1,105 @@
+package licensedb
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+// shuffledFiler lists the files in a random order.
+type shuffledFiler struct {
+	memoryFiler
+}
+
+func (fs shuffledFiler) ReadDir(dir string) ([]filer.File, error) {
+	files, err := fs.memoryFiler.ReadDir(dir)
+	rand.Shuffle(len(files), func(i, j int) { files[i], files[j] = files[j], files[i] })
+	return files, err
+}
+
+func TestDetectDeterministicOrder(t *testing.T) {
+	const oid = "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+	lfsPointer := "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 1077\n"
+	fs := shuffledFiler{memoryFiler{
+		"LICENSE-MIT":      referenceText(t, "MIT"),
+		"LICENSE-APACHE":   referenceText(t, "Apache-2.0"),
+		"LICENSES/MIT.txt": referenceText(t, "MIT"),
+		"LICENSE.lfs":      lfsPointer,
+		"COPYING.lfs":      lfsPointer,
+		"README.md":        "# Foo\n\n## License\n\nFoo is dual licensed under MIT or Apache-2.0.\n",
+		"main.go":          "// SPDX-License-Identifier: MIT\npackage main\n",
+	}}
+	var expected []byte
+	for i := 0; i < 10; i++ {
+		result, err := DetectDetailedWithOptions(fs, Options{RunAllPlans: true, MaxConcurrency: 4})
+		assert.Nil(t, err)
+		actual, err := json.Marshal(result)
+		assert.Nil(t, err)
+		if expected == nil {
+			expected = actual
+		}
+		assert.Equal(t, string(expected), string(actual))
+	}
+	licenses := mustDetect(t, fs)
+	assert.Equal(t, []Match{{License: "Apache-2.0", Confidence: 1}, {License: "MIT", Confidence: 1}},
+		Sorted(map[string]float32{"MIT": licenses["MIT"], "Apache-2.0": licenses["Apache-2.0"]}))
+}
+
+func TestLimiter(t *testing.T) {
+	var running, peak, done int32
+	tasks := make([]func(), 20)
+	for i := range tasks {
+		tasks[i] = func() {
+			now := atomic.AddInt32(&running, 1)
+			for {
+				prev := atomic.LoadInt32(&peak)
+				if now <= prev || atomic.CompareAndSwapInt32(&peak, prev, now) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			atomic.AddInt32(&done, 1)
+		}
+	}
+	newLimiter(3).run(tasks)
+	assert.Equal(t, int32(20), done)
+	assert.True(t, peak <= 3, "%d tasks ran at the same time", peak)
+	assert.True(t, peak > 1)
+
+	peak, done = 0, 0
+	newLimiter(0).run(tasks)
+	assert.Equal(t, int32(20), done)
+	assert.Equal(t, int32(1), peak)
+}
+
+func TestDetectMaxConcurrency(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE":     referenceText(t, "Apache-2.0"),
+		"LICENSE-MIT": referenceText(t, "MIT"),
+		"COPYING":     referenceText(t, "GPL-3.0-only"),
+	}
+	expected, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	for _, concurrency := range []int{1, 2, 8} {
+		result, err := DetectDetailedWithOptions(fs, Options{MaxConcurrency: concurrency})
+		assert.Nil(t, err)
+		assert.Equal(t, expected, result)
+	}
+	fs = memoryFiler{
+		"a.go": fmt.Sprintf(apacheHeader, 2018) + "package a",
+		"b.go": beerwareNotice + "\npackage b",
+		"c.go": "// Licensed under the MIT license, see LICENSE.txt.\npackage c",
+	}
+	expected, err = DetectDetailedWithOptions(fs, Options{ScanHeaders: true})
+	assert.Nil(t, err)
+	result, err := DetectDetailedWithOptions(fs, Options{ScanHeaders: true, MaxConcurrency: 3})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
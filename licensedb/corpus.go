@@ -0,0 +1,40 @@
+package licensedb
+
+import (
+	"errors"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal"
+)
+
+// ErrCorpusVersionUnavailable is returned by the detection if Options.CorpusVersion is not
+// among EmbeddedCorpusVersions, e.g. after the library upgrade which changed the corpus.
+var ErrCorpusVersionUnavailable = errors.New("the pinned license corpus version is not embedded")
+
+// CorpusVersion returns the version of the default corpus of the reference licenses which
+// the detection matches against, e.g. "3.0-5f1c2a9e": the version of the SPDX license list
+// data and the digest of the embedded texts. It identifies only the reference texts: the matching
+// algorithms and the thresholds may change between the library releases while the version stays
+// the same, so the same version does not guarantee the same results of the same tree.
+func CorpusVersion() string {
+	return internal.CorpusVersion()
+}
+
+// EmbeddedCorpusVersions returns the versions of the corpora which are embedded in the library
+// and can be pinned with Options.CorpusVersion. Currently, there is only the default corpus.
+func EmbeddedCorpusVersions() []string {
+	return []string{internal.CorpusVersion()}
+}
+
+// checkCorpusVersion returns ErrCorpusVersionUnavailable if Options.CorpusVersion is set and
+// not embedded.
+func (options Options) checkCorpusVersion() error {
+	if options.CorpusVersion == "" {
+		return nil
+	}
+	for _, version := range EmbeddedCorpusVersions() {
+		if version == options.CorpusVersion {
+			return nil
+		}
+	}
+	return ErrCorpusVersionUnavailable
+}
@@ -0,0 +1,26 @@
+package licensedb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorpusVersion(t *testing.T) {
+	version := CorpusVersion()
+	assert.True(t, strings.HasPrefix(version, "3.0-"), version)
+	assert.Equal(t, version, CorpusVersion())
+	assert.Equal(t, []string{version}, EmbeddedCorpusVersions())
+
+	fs := memoryFiler{"LICENSE": referenceText(t, "MIT"), "README.md": "# Foo\n"}
+	expected, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	result, err := DetectDetailedWithOptions(fs, Options{CorpusVersion: version})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+
+	// the corpus which is not embedded, e.g. pinned before the upgrade
+	_, err = DetectWithOptions(fs, Options{CorpusVersion: "2.6-0123abcd"})
+	assert.Equal(t, ErrCorpusVersionUnavailable, err)
+}
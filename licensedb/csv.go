@@ -0,0 +1,49 @@
+package licensedb
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// csvHeader are the columns written by WriteCSV.
+var csvHeader = []string{"file", "license", "spdx_id", "confidence", "source"}
+
+// WriteCSV writes the matches of the detailed results as CSV, one row per match in the order
+// of Result.Matches after the header row: the file, the full name of the license, its SPDX
+// identifier, the confidence and the detection plan. The full name is taken from
+// Match.Metadata if it is attached and from the default database otherwise, so WriteCSV
+// fails with ErrDefaultDatabaseDisabled like SPDXMetadata while the default database is
+// disabled unless the metadata is attached, see Options.AttachMetadata. The name is empty if
+// the license is not in the database, e.g. "public-domain".
+func WriteCSV(result *Result, writer io.Writer) error {
+	output := csv.NewWriter(writer)
+	if err := output.Write(csvHeader); err != nil {
+		return err
+	}
+	var db *Database
+	for _, match := range result.Matches {
+		var name string
+		if match.Metadata != nil {
+			name = match.Metadata.Name
+		} else {
+			if db == nil {
+				var err error
+				if db, err = defaultDatabase(); err != nil {
+					return err
+				}
+			}
+			if metadata, exists := db.SPDXMetadata(match.License); exists {
+				name = metadata.Name
+			}
+		}
+		err := output.Write([]string{
+			match.File, name, match.License,
+			strconv.FormatFloat(float64(match.Confidence), 'f', -1, 32), match.Plan})
+		if err != nil {
+			return err
+		}
+	}
+	output.Flush()
+	return output.Error()
+}
@@ -0,0 +1,21 @@
+package licensedb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteCSV(t *testing.T) {
+	result := &Result{Matches: []Match{
+		{License: "MIT", Confidence: 1, File: "LICENSE", Plan: PlanLicenseFiles},
+		{License: "MulanPSL-2.0", Confidence: 0.95, File: "docs/README, old.md", Plan: PlanReadme},
+	}}
+	output := &bytes.Buffer{}
+	assert.Nil(t, WriteCSV(result, output))
+	assert.Equal(t, `file,license,spdx_id,confidence,source
+LICENSE,MIT License,MIT,1,license files
+"docs/README, old.md","Mulan Permissive Software License, Version 2",MulanPSL-2.0,0.95,readme
+`, output.String())
+}
@@ -0,0 +1,75 @@
+package licensedb
+
+import (
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal"
+)
+
+// ErrDefaultDatabaseDisabled is returned by the detection without Options.Database if
+// the default database is disabled, see DisableDefaultDatabase.
+var ErrDefaultDatabaseDisabled = internal.ErrDefaultDatabaseDisabled
+
+// Database is the index of the reference licenses which the detection matches against,
+// see Options.Database. It is safe for the concurrent use.
+type Database struct {
+	db *internal.Database
+}
+
+// NewDatabase loads the reference licenses and indexes them with the tokenizer,
+// WhitespaceTokenizer if nil. Each call builds a new instance, which takes several seconds
+// and tens of megabytes, so the instances should be reused.
+func NewDatabase(tokenizer Tokenizer) *Database {
+	return &Database{db: internal.NewDatabase(tokenizer)}
+}
+
+// DisableDefaultDatabase forbids the detection to load and use the package-global database,
+// e.g. in the long-running servers which keep their own databases to control the memory.
+// Then the detection requires Options.Database and fails with ErrDefaultDatabaseDisabled
+// otherwise. The same applies to LicenseKey, Result.LicensesByKey, SPDXMetadata, WriteCSV,
+// DetectReadmeOnly, QueryAllScores and IncrementalDetector, and the methods of Database do
+// the same with the explicit database. false allows the default database again.
+func DisableDefaultDatabase(disabled bool) {
+	internal.DisableDefaultDatabase(disabled)
+}
+
+// LicenseKey is the same as the package-level LicenseKey but looks up the license in db.
+func (db *Database) LicenseKey(license string) string {
+	return db.db.LicenseKey(license)
+}
+
+// SPDXMetadata is the same as the package-level SPDXMetadata but looks up the license in db.
+func (db *Database) SPDXMetadata(name string) (Metadata, bool) {
+	return lookupMetadata(name, db.db)
+}
+
+// DetectReadmeOnly is the same as the package-level DetectReadmeOnly but matches against db.
+func (db *Database) DetectReadmeOnly(text []byte, fs filer.Filer) map[string]float32 {
+	if fs == nil {
+		fs = singleFiler{}
+	}
+	return internal.InvestigateReadmeText(text, fs, db.db)
+}
+
+// QueryAllScores is the same as the package-level QueryAllScores but compares the text with
+// every license in db.
+func (db *Database) QueryAllScores(text string) map[string]float32 {
+	return db.db.QueryAllScores(text)
+}
+
+// defaultDatabase returns the package-global database, or ErrDefaultDatabaseDisabled if it
+// is disabled, see DisableDefaultDatabase.
+func defaultDatabase() (*Database, error) {
+	db, err := internal.DefaultDatabase(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Database{db: db}, nil
+}
+
+// database returns Options.Database or the default database of Options.Tokenizer.
+func (options Options) database() (*internal.Database, error) {
+	if options.Database != nil {
+		return options.Database.db, nil
+	}
+	return internal.DefaultDatabase(options.Tokenizer)
+}
@@ -0,0 +1,64 @@
+package licensedb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectExplicitDatabase(t *testing.T) {
+	db := NewDatabase(nil)
+	DisableDefaultDatabase(true)
+	defer DisableDefaultDatabase(false)
+	fs := memoryFiler{"LICENSE": referenceText(t, "MIT")}
+	_, err := Detect(fs)
+	assert.Equal(t, ErrDefaultDatabaseDisabled, err)
+	_, err = DetectDetailedWithOptions(fs, Options{Tokenizer: CJKTokenizer})
+	assert.Equal(t, ErrDefaultDatabaseDisabled, err)
+	readme := []byte("Licensed under the MIT license.")
+	_, err = DetectReadmeOnly(readme, nil)
+	assert.Equal(t, ErrDefaultDatabaseDisabled, err)
+	_, err = QueryAllScores(referenceText(t, "MIT"))
+	assert.Equal(t, ErrDefaultDatabaseDisabled, err)
+	_, err = LicenseKey("MIT")
+	assert.Equal(t, ErrDefaultDatabaseDisabled, err)
+	_, _, err = SPDXMetadata("MIT")
+	assert.Equal(t, ErrDefaultDatabaseDisabled, err)
+	_, err = (&Result{Matches: []Match{{License: "MIT", Confidence: 1}}}).LicensesByKey()
+	assert.Equal(t, ErrDefaultDatabaseDisabled, err)
+	assert.Equal(t, ErrDefaultDatabaseDisabled, WriteCSV(
+		&Result{Matches: []Match{{License: "MIT", Confidence: 1}}}, &bytes.Buffer{}))
+	detector := NewIncrementalDetector()
+	detector.Add("LICENSE", []byte(fs["LICENSE"]))
+	_, err = detector.Result()
+	assert.Equal(t, ErrDefaultDatabaseDisabled, err)
+
+	result, err := DetectDetailedWithOptions(fs, Options{Database: db, StableKeys: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", result.Matches[0].License)
+	assert.Equal(t, "2bd1eaeb-afeb-531f-ae7a-0a0511af4429", result.Matches[0].Key)
+	licenses, err := DetectWithOptions(memoryFiler{"README.md": "# Foo\n\nLicensed under the MIT license.\n"},
+		Options{Database: db})
+	assert.Nil(t, err)
+	assert.Contains(t, licenses, "MIT")
+
+	// the keys of Options.StableKeys do not need the default database
+	keys, err := result.LicensesByKey()
+	assert.Nil(t, err)
+	assert.Len(t, keys, len(result.Matches))
+	assert.Equal(t, float32(1), keys[db.LicenseKey("MIT")])
+	result, err = DetectDetailedWithOptions(fs, Options{Database: db, AttachMetadata: true})
+	assert.Nil(t, err)
+	assert.Nil(t, WriteCSV(result, &bytes.Buffer{}))
+	assert.Contains(t, db.DetectReadmeOnly(readme, nil), "MIT")
+	assert.Contains(t, db.QueryAllScores(referenceText(t, "MIT")), "MIT")
+	metadata, exists := db.SPDXMetadata("MIT")
+	assert.True(t, exists)
+	assert.Equal(t, "MIT License", metadata.Name)
+	detector = NewIncrementalDetectorWithOptions(Options{Database: db})
+	detector.Add("LICENSE", []byte(fs["LICENSE"]))
+	licenses, err = detector.Result()
+	assert.Nil(t, err)
+	assert.Contains(t, licenses, "MIT")
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
@@ -43,3 +44,40 @@ func TestDataset(t *testing.T) {
 		}
 	}
 }
+
+func BenchmarkDataset(b *testing.B) {
+	rootFiler, err := filer.FromZIP("dataset.zip")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer rootFiler.Close()
+	projects, err := rootFiler.ReadDir("")
+	if err != nil {
+		b.Fatal(err)
+	}
+	// load the database outside of the measured loop
+	Detect(filer.NestFiler(rootFiler, projects[0].Name))
+	timings := map[string]*PlanStats{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, project := range projects {
+			_, stats, _ := DetectWithStats(filer.NestFiler(rootFiler, project.Name))
+			for _, plan := range stats.Plans {
+				total := timings[plan.Name]
+				if total == nil {
+					total = &PlanStats{Name: plan.Name}
+					timings[plan.Name] = total
+				}
+				total.Extraction += plan.Extraction
+				total.Investigation += plan.Investigation
+			}
+		}
+	}
+	b.StopTimer()
+	for _, name := range []string{PlanLicenseFiles, PlanReadme, PlanHeaders} {
+		if total := timings[name]; total != nil {
+			b.Logf("%s: extraction %v, investigation %v", name,
+				total.Extraction/time.Duration(b.N), total.Investigation/time.Duration(b.N))
+		}
+	}
+}
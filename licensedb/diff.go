@@ -0,0 +1,124 @@
+package licensedb
+
+import (
+	"sort"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+// principalTolerance is the maximum difference from the best confidence of the licenses which
+// are compared by DetectDiff. The less confident matches are the noise of the fuzzy matching,
+// e.g. AGPL-3.0 next to GPL-3.0, and must not be reported as changes.
+const principalTolerance = 0.05
+
+// LicenseDiff is the difference between the licenses of two versions of the same tree.
+type LicenseDiff struct {
+	// Added are the sorted licenses which appeared in the newer version.
+	Added []string `json:"added,omitempty"`
+	// Removed are the sorted licenses which disappeared in the newer version.
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Empty indicates whether the licenses did not change.
+func (diff LicenseDiff) Empty() bool {
+	return len(diff.Added) == 0 && len(diff.Removed) == 0
+}
+
+// DetectDiff detects the licenses in two versions of the same tree and compares them.
+// Only the principal licenses are compared, see PrincipalLicenses. A version without any
+// license is not an error: all the licenses of the other version are then added or removed.
+func DetectDiff(before, after filer.Filer) (LicenseDiff, error) {
+	oldLicenses, err := detectOrNothing(before)
+	if err != nil {
+		return LicenseDiff{}, err
+	}
+	newLicenses, err := detectOrNothing(after)
+	if err != nil {
+		return LicenseDiff{}, err
+	}
+	return diffLicenses(oldLicenses, newLicenses), nil
+}
+
+// TimelineEntry is the detected licenses of a single version in DetectTimeline.
+type TimelineEntry struct {
+	// Licenses are the same as Detect returns, empty if no license was found.
+	Licenses map[string]float32 `json:"licenses"`
+	// Principal are the sorted principal licenses, see PrincipalLicenses.
+	Principal []string `json:"principal"`
+	// Changed indicates that the principal licenses differ from the previous version.
+	// It is always false for the first version.
+	Changed bool `json:"changed,omitempty"`
+	// Diff is the change since the previous version.
+	Diff LicenseDiff `json:"diff"`
+}
+
+// DetectTimeline detects the licenses in each version of the same tree, e.g. the release
+// tarballs, in the given order and reports where the licenses changed. The entries correspond
+// to the filers.
+func DetectTimeline(versions []filer.Filer) ([]TimelineEntry, error) {
+	timeline := make([]TimelineEntry, 0, len(versions))
+	var previous map[string]float32
+	for i, fs := range versions {
+		licenses, err := detectOrNothing(fs)
+		if err != nil {
+			return nil, err
+		}
+		entry := TimelineEntry{Licenses: licenses, Principal: PrincipalLicenses(licenses)}
+		if i > 0 {
+			entry.Diff = diffLicenses(previous, licenses)
+			entry.Changed = !entry.Diff.Empty()
+		}
+		timeline = append(timeline, entry)
+		previous = licenses
+	}
+	return timeline, nil
+}
+
+// PrincipalLicenses returns the sorted licenses which are almost as confident as the best one,
+// e.g. both MIT and Apache-2.0 in a dual-licensed tree.
+func PrincipalLicenses(licenses map[string]float32) []string {
+	var best float32
+	for _, confidence := range licenses {
+		if confidence > best {
+			best = confidence
+		}
+	}
+	principal := []string{}
+	for name, confidence := range licenses {
+		if best-confidence <= principalTolerance {
+			principal = append(principal, name)
+		}
+	}
+	sort.Strings(principal)
+	return principal
+}
+
+// detectOrNothing is the same as Detect but returns the empty map instead of ErrNoLicenseFound.
+func detectOrNothing(fs filer.Filer) (map[string]float32, error) {
+	licenses, err := Detect(fs)
+	if err == ErrNoLicenseFound {
+		return map[string]float32{}, nil
+	}
+	return licenses, err
+}
+
+func diffLicenses(before, after map[string]float32) LicenseDiff {
+	oldPrincipal := map[string]bool{}
+	for _, name := range PrincipalLicenses(before) {
+		oldPrincipal[name] = true
+	}
+	diff := LicenseDiff{}
+	newPrincipal := map[string]bool{}
+	for _, name := range PrincipalLicenses(after) {
+		newPrincipal[name] = true
+		if !oldPrincipal[name] {
+			diff.Added = append(diff.Added, name)
+		}
+	}
+	for _, name := range PrincipalLicenses(before) {
+		if !newPrincipal[name] {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,41 @@
+package licensedb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+func TestDetectTimeline(t *testing.T) {
+	versions := []filer.Filer{
+		memoryFiler{"LICENSE": referenceText(t, "MIT"), "main.go": "package main"},
+		memoryFiler{"LICENSE": referenceText(t, "Apache-2.0"), "main.go": "package main"},
+		memoryFiler{"LICENSE": referenceText(t, "Apache-2.0"), "main.go": "package main\n\nfunc main() {}"},
+	}
+	timeline, err := DetectTimeline(versions)
+	assert.Nil(t, err)
+	assert.Len(t, timeline, 3)
+	assert.Equal(t, []string{"MIT"}, timeline[0].Principal)
+	assert.False(t, timeline[0].Changed)
+	assert.True(t, timeline[1].Changed)
+	assert.Equal(t, LicenseDiff{Added: []string{"Apache-2.0"}, Removed: []string{"MIT"}}, timeline[1].Diff)
+	assert.Equal(t, []string{"Apache-2.0"}, timeline[1].Principal)
+	assert.False(t, timeline[2].Changed)
+	assert.True(t, timeline[2].Diff.Empty())
+
+	diff, err := DetectDiff(versions[0], versions[1])
+	assert.Nil(t, err)
+	assert.Equal(t, timeline[1].Diff, diff)
+	// the license was dropped
+	diff, err = DetectDiff(versions[2], memoryFiler{"main.go": "package main"})
+	assert.Nil(t, err)
+	assert.Equal(t, LicenseDiff{Removed: []string{"Apache-2.0"}}, diff)
+	// the read errors are not swallowed
+	broken := glitchyFiler{memoryFiler: memoryFiler{}, err: errors.New("permission denied"),
+		failures: 1, attempts: map[string]int{}}
+	timeline, err = DetectTimeline([]filer.Filer{versions[0], broken})
+	assert.Nil(t, timeline)
+	assert.NotNil(t, err)
+}
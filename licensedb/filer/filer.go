@@ -1,10 +1,15 @@
 package filer
 
 import (
+	"archive/tar"
 	"archive/zip"
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"io"
 	"io/ioutil"
 	"os"
+	paths "path"
 	"path/filepath"
 	"strings"
 
@@ -38,6 +43,38 @@ type Filer interface {
 	Close()
 }
 
+// LFSResolver is implemented by the Filer-s which can fetch the git-lfs objects, so that
+// the pointer files can be replaced with the actual contents.
+type LFSResolver interface {
+	// ReadLFSObject returns the contents of the git-lfs object given its SHA-256 hash.
+	ReadLFSObject(oid string) (content []byte, err error)
+}
+
+// PrefixReader is implemented by the Filer-s which can read the leading bytes of a file without
+// reading the rest, e.g. to scan the license headers of the big source files.
+type PrefixReader interface {
+	// ReadFilePrefix returns at most size leading bytes of a file given it's path.
+	ReadFilePrefix(path string, size int) (content []byte, err error)
+}
+
+// ReadFilePrefix returns at most size leading bytes of the file. It reads the whole file and
+// truncates it if fs does not implement PrefixReader.
+func ReadFilePrefix(fs Filer, path string, size int) ([]byte, error) {
+	if reader, ok := fs.(PrefixReader); ok {
+		return reader.ReadFilePrefix(path, size)
+	}
+	content, err := fs.ReadFile(path)
+	if len(content) > size {
+		content = content[:size]
+	}
+	return content, err
+}
+
+// readPrefix reads at most size leading bytes from the reader.
+func readPrefix(reader io.Reader, size int) ([]byte, error) {
+	return ioutil.ReadAll(io.LimitReader(reader, int64(size)))
+}
+
 type localFiler struct {
 	root string
 }
@@ -78,6 +115,23 @@ func (filer *localFiler) ReadFile(path string) ([]byte, error) {
 	return buffer, nil
 }
 
+func (filer *localFiler) ReadFilePrefix(path string, size int) ([]byte, error) {
+	path, err := filer.resolvePath(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read file %s", path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read file %s", path)
+	}
+	defer file.Close()
+	buffer, err := readPrefix(file, size)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read file %s", path)
+	}
+	return buffer, nil
+}
+
 func (filer *localFiler) ReadDir(path string) ([]File, error) {
 	path, err := filer.resolvePath(path)
 	if err != nil {
@@ -97,6 +151,19 @@ func (filer *localFiler) ReadDir(path string) ([]File, error) {
 	return result, nil
 }
 
+// ReadLFSObject reads the object from the local git-lfs cache in .git/lfs/objects.
+func (filer *localFiler) ReadLFSObject(oid string) ([]byte, error) {
+	if len(oid) < 4 || strings.ContainsAny(oid, "/\\.") {
+		return nil, errors.Errorf("invalid git-lfs object id %s", oid)
+	}
+	path := filepath.Join(filer.root, ".git", "lfs", "objects", oid[:2], oid[2:4], oid)
+	buffer, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read git-lfs object %s", oid)
+	}
+	return buffer, nil
+}
+
 func (filer *localFiler) Close() {}
 
 type gitFiler struct {
@@ -144,10 +211,12 @@ func (filer gitFiler) ReadFile(path string) ([]byte, error) {
 		if err != nil {
 			return nil, errors.Wrapf(err, "cannot find file %s", path)
 		}
-		path, err = file.Contents()
+		target, err := file.Contents()
 		if err != nil {
 			return nil, errors.Wrapf(err, "cannot read file %s", path)
 		}
+		// the target is relative to the directory of the symlink
+		path = paths.Join(paths.Dir(path), target)
 	}
 	file, err := filer.root.File(path)
 	if err != nil {
@@ -269,6 +338,33 @@ func FromZIP(path string) (Filer, error) {
 }
 
 func (filer *zipFiler) ReadFile(path string) ([]byte, error) {
+	reader, err := filer.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	buffer, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", path)
+	}
+	return buffer, nil
+}
+
+func (filer *zipFiler) ReadFilePrefix(path string, size int) ([]byte, error) {
+	reader, err := filer.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	buffer, err := readPrefix(reader, size)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", path)
+	}
+	return buffer, nil
+}
+
+// open returns the decompressing reader of the file in the archive.
+func (filer *zipFiler) open(path string) (io.ReadCloser, error) {
 	parts := strings.Split(path, string(filepath.Separator))
 	node := filer.tree
 	for _, part := range parts {
@@ -284,12 +380,7 @@ func (filer *zipFiler) ReadFile(path string) ([]byte, error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "cannot open %s", path)
 	}
-	defer reader.Close()
-	buffer, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return nil, errors.Wrapf(err, "cannot read %s", path)
-	}
-	return buffer, nil
+	return reader, nil
 }
 
 func (filer *zipFiler) ReadDir(path string) ([]File, error) {
@@ -321,6 +412,190 @@ func (filer *zipFiler) Close() {
 	filer.arch.Close()
 }
 
+type tarNode struct {
+	children map[string]*tarNode
+	isDir    bool
+	// offset and size locate the contents in the uncompressed archive
+	offset, size int64
+	// content is loaded from the compressed archive unless it is too big
+	content []byte
+	tooBig  bool
+}
+
+type tarFiler struct {
+	tree *tarNode
+	// file is the uncompressed archive which is read lazily, nil if it is compressed
+	file *os.File
+}
+
+// maxTarballFileSize and maxTarballSize bound the memory which FromTarball spends on
+// the compressed archive: the bigger files and the files after the total size is exceeded
+// are listed but fail to read.
+const (
+	maxTarballFileSize = 1 << 20
+	maxTarballSize     = 64 << 20
+)
+
+// countingReader counts the bytes read from the wrapped reader.
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// FromTarball returns a Filer that allows accessing all the files in a tar archive given its path.
+// The archive may be compressed with gzip, e.g. .tar.gz or .tgz. The files of the uncompressed
+// archive are read at their offsets on demand. Since the compressed archives cannot be read
+// at random, their regular files are loaded into memory up to maxTarballFileSize each and
+// maxTarballSize in total. The symlinks and the other special entries are skipped.
+func FromTarball(path string) (Filer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read tar archive %s", path)
+	}
+	stream := bufio.NewReader(file)
+	counter := &countingReader{reader: stream}
+	var reader io.Reader = counter
+	compressed := false
+	if magic, err := stream.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(stream)
+		if err != nil {
+			file.Close()
+			return nil, errors.Wrapf(err, "cannot read tar archive %s", path)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+		compressed = true
+	}
+	arch := tar.NewReader(reader)
+	root := &tarNode{children: map[string]*tarNode{}, isDir: true}
+	var loaded int64
+	for {
+		header, err := arch.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			file.Close()
+			return nil, errors.Wrapf(err, "cannot read tar archive %s", path)
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeDir {
+			continue
+		}
+		node := root
+		for _, part := range strings.Split(paths.Clean(header.Name), "/") {
+			if part == "" || part == "." {
+				continue
+			}
+			child := node.children[part]
+			if child == nil {
+				child = &tarNode{children: map[string]*tarNode{}, isDir: true}
+				node.children[part] = child
+			}
+			node = child
+		}
+		if node == root || header.Typeflag == tar.TypeDir {
+			continue
+		}
+		node.isDir = false
+		node.size = header.Size
+		if !compressed {
+			// the header blocks are consumed, so the contents start here
+			node.offset = counter.count
+			continue
+		}
+		if header.Size > maxTarballFileSize || loaded+header.Size > maxTarballSize {
+			node.tooBig = true
+			continue
+		}
+		node.content, err = ioutil.ReadAll(arch)
+		if err != nil {
+			file.Close()
+			return nil, errors.Wrapf(err, "cannot read %s in tar archive %s", header.Name, path)
+		}
+		loaded += int64(len(node.content))
+	}
+	if compressed {
+		file.Close()
+		return &tarFiler{tree: root}, nil
+	}
+	return &tarFiler{tree: root, file: file}, nil
+}
+
+func (filer *tarFiler) find(path string) *tarNode {
+	node := filer.tree
+	for _, part := range strings.Split(path, string(filepath.Separator)) {
+		if part == "" {
+			continue
+		}
+		node = node.children[part]
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+func (filer *tarFiler) ReadFile(path string) ([]byte, error) {
+	return filer.ReadFilePrefix(path, -1)
+}
+
+// ReadFilePrefix reads the whole file if size is negative.
+func (filer *tarFiler) ReadFilePrefix(path string, size int) ([]byte, error) {
+	node := filer.find(path)
+	if node == nil {
+		return nil, errors.Errorf("does not exist: %s", path)
+	}
+	if node.isDir {
+		return nil, errors.Errorf("not a file: %s", path)
+	}
+	length := node.size
+	if size >= 0 && int64(size) < length {
+		length = int64(size)
+	}
+	if filer.file == nil {
+		if node.tooBig {
+			return nil, errors.Errorf("too big to be loaded from the compressed archive: %s", path)
+		}
+		return node.content[:length], nil
+	}
+	content := make([]byte, length)
+	if _, err := filer.file.ReadAt(content, node.offset); err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", path)
+	}
+	return content, nil
+}
+
+func (filer *tarFiler) ReadDir(path string) ([]File, error) {
+	node := filer.find(path)
+	if node == nil {
+		return nil, errors.Errorf("does not exist: %s", path)
+	}
+	if !node.isDir {
+		return nil, errors.Errorf("not a directory: %s", path)
+	}
+	result := make([]File, 0, len(node.children))
+	for name, child := range node.children {
+		result = append(result, File{
+			Name:  name,
+			IsDir: child.isDir,
+		})
+	}
+	return result, nil
+}
+
+func (filer *tarFiler) Close() {
+	filer.tree = nil
+	if filer.file != nil {
+		filer.file.Close()
+	}
+}
+
 type nestedFiler struct {
 	origin Filer
 	offset string
@@ -335,6 +610,10 @@ func (filer *nestedFiler) ReadFile(path string) ([]byte, error) {
 	return filer.origin.ReadFile(filepath.Join(filer.offset, path))
 }
 
+func (filer *nestedFiler) ReadFilePrefix(path string, size int) ([]byte, error) {
+	return ReadFilePrefix(filer.origin, filepath.Join(filer.offset, path), size)
+}
+
 func (filer *nestedFiler) ReadDir(path string) ([]File, error) {
 	return filer.origin.ReadDir(filepath.Join(filer.offset, path))
 }
@@ -0,0 +1,312 @@
+package filer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// emptyPayloadHash is the SHA-256 of the empty request body, all the S3 requests are GET-s.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Options configure the access to the S3-compatible object storage in FromS3.
+type S3Options struct {
+	// Endpoint is the base URL of the storage, e.g. http://localhost:9000 for MinIO.
+	// The buckets are addressed in the path style then. If it is empty, the virtual-hosted
+	// AWS endpoint in the region is used, e.g. https://bucket.s3.us-east-1.amazonaws.com.
+	Endpoint string
+	// Region is the signing region, "us-east-1" by default.
+	Region string
+	// AccessKeyID and SecretAccessKey sign the requests with AWS Signature Version 4.
+	// The requests are anonymous if they are empty.
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is the optional token of the temporary credentials.
+	SessionToken string
+	// Client sends the requests, http.DefaultClient by default.
+	Client *http.Client
+}
+
+type s3Filer struct {
+	options S3Options
+	base    *url.URL
+	// prefix is prepended to the object keys, without the leading and trailing "/"
+	prefix string
+	now    func() time.Time
+}
+
+// S3Error is the failed S3 request. The server errors and the throttling are temporary,
+// so that the detection can retry them.
+type S3Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (err *S3Error) Error() string {
+	if err.Code == "" {
+		return fmt.Sprintf("S3 request failed with HTTP %d", err.StatusCode)
+	}
+	return fmt.Sprintf("S3 request failed with HTTP %d: %s: %s", err.StatusCode, err.Code, err.Message)
+}
+
+// Temporary indicates whether the request may succeed if repeated.
+func (err *S3Error) Temporary() bool {
+	return err.StatusCode >= 500 || err.StatusCode == http.StatusTooManyRequests
+}
+
+// s3ListResult is the response of ListObjectsV2.
+type s3ListResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+}
+
+// FromS3 returns a Filer that allows accessing all the objects in an S3 bucket under the
+// specified key prefix, e.g. "artifacts/foo-1.0". The "/" in the keys separates the directories.
+// It works with AWS and with the S3-compatible storages, e.g. MinIO.
+func FromS3(bucket, prefix string, options S3Options) (Filer, error) {
+	if bucket == "" {
+		return nil, errors.New("S3 bucket must not be empty")
+	}
+	if options.Region == "" {
+		options.Region = "us-east-1"
+	}
+	if options.Client == nil {
+		options.Client = http.DefaultClient
+	}
+	filer := &s3Filer{options: options, prefix: strings.Trim(prefix, "/"), now: time.Now}
+	if options.Endpoint == "" {
+		filer.base = &url.URL{
+			Scheme: "https",
+			Host:   fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, options.Region),
+			Path:   "/",
+		}
+		return filer, nil
+	}
+	base, err := url.Parse(options.Endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid S3 endpoint %s", options.Endpoint)
+	}
+	if base.Scheme == "" || base.Host == "" {
+		return nil, errors.Errorf("invalid S3 endpoint %s", options.Endpoint)
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/") + "/" + bucket + "/"
+	filer.base = base
+	return filer, nil
+}
+
+// key converts the path in the Filer to the object key.
+func (filer *s3Filer) key(p string) (string, error) {
+	for _, part := range strings.Split(p, "/") {
+		if part == ".." {
+			return "", errors.Errorf("path is outside of the prefix: %s", p)
+		}
+	}
+	p = strings.Trim(path.Clean("/"+p), "/")
+	if filer.prefix == "" {
+		return p, nil
+	}
+	if p == "" {
+		return filer.prefix, nil
+	}
+	return filer.prefix + "/" + p, nil
+}
+
+func (filer *s3Filer) ReadFile(p string) ([]byte, error) {
+	key, err := filer.key(p)
+	if err != nil {
+		return nil, err
+	}
+	if key == filer.prefix {
+		return nil, errors.Errorf("not a file: %s", p)
+	}
+	body, err := filer.get(key, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", p)
+	}
+	return body, nil
+}
+
+func (filer *s3Filer) ReadDir(p string) ([]File, error) {
+	key, err := filer.key(p)
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		key += "/"
+	}
+	var result []File
+	query := url.Values{"list-type": {"2"}, "delimiter": {"/"}, "prefix": {key}}
+	for {
+		body, err := filer.get("", query)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot list %s", p)
+		}
+		page := s3ListResult{}
+		if err = xml.Unmarshal(body, &page); err != nil {
+			return nil, errors.Wrapf(err, "cannot parse the listing of %s", p)
+		}
+		for _, dir := range page.CommonPrefixes {
+			if name := strings.TrimSuffix(dir.Prefix[len(key):], "/"); name != "" {
+				result = append(result, File{Name: name, IsDir: true})
+			}
+		}
+		for _, object := range page.Contents {
+			// the "directory" placeholders created by some clients end with "/"
+			if name := object.Key[len(key):]; name != "" && !strings.HasSuffix(name, "/") {
+				result = append(result, File{Name: name})
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			break
+		}
+		query.Set("continuation-token", page.NextContinuationToken)
+	}
+	if len(result) == 0 && p != "" {
+		return nil, errors.Errorf("does not exist: %s", p)
+	}
+	return result, nil
+}
+
+func (filer *s3Filer) Close() {}
+
+// get sends the signed GET request of the object key or of the bucket if the key is empty.
+func (filer *s3Filer) get(key string, query url.Values) ([]byte, error) {
+	target := *filer.base
+	target.Path += key
+	// the path is sent exactly as it is signed
+	target.RawPath = s3EscapePath(target.Path)
+	target.RawQuery = s3EncodeQuery(query)
+	request, err := http.NewRequest("GET", target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	filer.sign(request)
+	response, err := filer.options.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		s3err := &S3Error{}
+		xml.Unmarshal(body, s3err)
+		s3err.StatusCode = response.StatusCode
+		return nil, s3err
+	}
+	return body, nil
+}
+
+// sign adds the AWS Signature Version 4 headers to the request, see
+// https://docs.aws.amazon.com/general/latest/gr/sigv4_signing.html
+func (filer *s3Filer) sign(request *http.Request) {
+	if filer.options.AccessKeyID == "" {
+		return
+	}
+	now := filer.now().UTC()
+	timestamp := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	request.Header.Set("X-Amz-Date", timestamp)
+	request.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if filer.options.SessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", filer.options.SessionToken)
+	}
+	headers := map[string]string{"host": request.URL.Host}
+	for name := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(request.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	canonicalHeaders := &strings.Builder{}
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonicalRequest := strings.Join([]string{
+		request.Method, request.URL.EscapedPath(), request.URL.RawQuery,
+		canonicalHeaders.String(), signedHeaders, emptyPayloadHash}, "\n")
+	scope := date + "/" + filer.options.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", timestamp, scope, hexSHA256(canonicalRequest)}, "\n")
+	key := []byte("AWS4" + filer.options.SecretAccessKey)
+	for _, part := range []string{date, filer.options.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		filer.options.AccessKeyID, scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))))
+}
+
+// s3EscapePath encodes the path as SigV4 requires: everything except the unreserved
+// characters and "/".
+func s3EscapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = s3Escape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// s3EncodeQuery encodes the sorted query parameters as SigV4 requires, so that the same
+// string is both sent and signed.
+func s3EncodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			pairs = append(pairs, s3Escape(key)+"="+s3Escape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func s3Escape(s string) string {
+	escaped := &strings.Builder{}
+	for _, c := range []byte(s) {
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			escaped.WriteByte(c)
+		} else {
+			fmt.Fprintf(escaped, "%%%02X", c)
+		}
+	}
+	return escaped.String()
+}
+
+func hexSHA256(s string) string {
+	hash := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSHA256(key []byte, s string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s))
+	return mac.Sum(nil)
+}
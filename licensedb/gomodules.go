@@ -0,0 +1,98 @@
+package licensedb
+
+import (
+	"bufio"
+	"bytes"
+	paths "path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+// goVendorModulesFile is the index of the vendored Go modules written by `go mod vendor`.
+const goVendorModulesFile = "vendor/modules.txt"
+
+// DetectGoVendor detects the licenses of the vendored Go modules listed in vendor/modules.txt.
+// The result maps module paths to the licenses detected in the corresponding vendor
+// directories. The modules without any license are not included.
+func DetectGoVendor(fs filer.Filer) (map[string]map[string]float32, error) {
+	content, err := fs.ReadFile(goVendorModulesFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", goVendorModulesFile)
+	}
+	result := map[string]map[string]float32{}
+	for _, module := range parseGoVendorModules(content) {
+		licenses, err := Detect(filer.NestFiler(fs, paths.Join("vendor", module)))
+		if err == nil {
+			result[module] = licenses
+		}
+	}
+	return result, nil
+}
+
+// DetectGoModuleCache detects the licenses of the Go modules in the module cache, e.g.
+// $GOPATH/pkg/mod, for the tools which walk the whole module graph. The modules are
+// "<module path>@<version>" like in the output of `go list -m all` or `go mod graph`, and
+// each is detected once in its directory of the cache although the graph lists it many times.
+// The result maps the modules to the licenses. The modules which are not in the cache or
+// which have no license are not included.
+func DetectGoModuleCache(root string, modules []string) (map[string]map[string]float32, error) {
+	fs, err := filer.FromDirectory(root)
+	if err != nil {
+		return nil, err
+	}
+	defer fs.Close()
+	result := map[string]map[string]float32{}
+	seen := map[string]bool{}
+	for _, module := range modules {
+		module = strings.TrimSpace(module)
+		// the main module of `go mod graph` has no version
+		if seen[module] || !strings.Contains(module, "@") {
+			continue
+		}
+		seen[module] = true
+		licenses, err := Detect(filer.NestFiler(fs, escapeGoModulePath(module)))
+		if err == nil {
+			result[module] = licenses
+		}
+	}
+	return result, nil
+}
+
+// escapeGoModulePath converts the module path to the directory name in the module cache,
+// which escapes the upper case letters, e.g. github.com/!burnt!sushi/toml@v0.3.1.
+func escapeGoModulePath(module string) string {
+	escaped := &strings.Builder{}
+	for _, char := range module {
+		if char >= 'A' && char <= 'Z' {
+			escaped.WriteByte('!')
+			char += 'a' - 'A'
+		}
+		escaped.WriteRune(char)
+	}
+	return escaped.String()
+}
+
+// parseGoVendorModules returns the module paths listed in vendor/modules.txt.
+// Each module is described by the line "# <module path> <version> [=> <replacement>]",
+// the following lines list the vendored packages and are not needed.
+func parseGoVendorModules(content []byte) []string {
+	var modules []string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "# ") {
+			// packages and "## explicit" markers
+			continue
+		}
+		fields := strings.Fields(line[2:])
+		if len(fields) == 0 || seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+		modules = append(modules, fields[0])
+	}
+	return modules
+}
@@ -0,0 +1,70 @@
+package licensedb
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectGoVendor(t *testing.T) {
+	fs := memoryFiler{
+		"go.mod": "module example.com/foo\n",
+		"vendor/modules.txt": `# github.com/pkg/errors v0.8.0
+## explicit
+github.com/pkg/errors
+# golang.org/x/text v0.3.0 => golang.org/x/text v0.3.2
+golang.org/x/text/unicode/norm
+# example.com/unlicensed v1.0.0
+example.com/unlicensed
+`,
+		"vendor/github.com/pkg/errors/LICENSE":               referenceText(t, "BSD-2-Clause"),
+		"vendor/github.com/pkg/errors/errors.go":             "package errors",
+		"vendor/golang.org/x/text/LICENSE":                   referenceText(t, "BSD-3-Clause"),
+		"vendor/golang.org/x/text/unicode/norm/normalize.go": "package norm",
+		"vendor/example.com/unlicensed/unlicensed.go":        "package unlicensed",
+	}
+	modules, err := DetectGoVendor(fs)
+	assert.Nil(t, err)
+	assert.Len(t, modules, 2)
+	assert.InDelta(t, 1, modules["github.com/pkg/errors"]["BSD-2-Clause"], 0.05)
+	assert.InDelta(t, 1, modules["golang.org/x/text"]["BSD-3-Clause"], 0.05)
+
+	delete(fs, "vendor/modules.txt")
+	modules, err = DetectGoVendor(fs)
+	assert.Nil(t, modules)
+	assert.NotNil(t, err)
+}
+
+func TestDetectGoModuleCache(t *testing.T) {
+	root, err := ioutil.TempDir("", "licensedb-modcache-")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+	for dir, license := range map[string]string{
+		"github.com/pkg/errors@v0.8.0":        "BSD-2-Clause",
+		"github.com/!burnt!sushi/toml@v0.3.1": "MIT",
+	} {
+		assert.Nil(t, os.MkdirAll(path.Join(root, dir), 0755))
+		goMod := "module " + strings.Replace(dir[:strings.Index(dir, "@")], "!b", "B", -1) + "\n"
+		assert.Nil(t, ioutil.WriteFile(path.Join(root, dir, "go.mod"), []byte(goMod), 0644))
+		assert.Nil(t, ioutil.WriteFile(
+			path.Join(root, dir, "LICENSE"), []byte(referenceText(t, license)), 0644))
+	}
+	modules, err := DetectGoModuleCache(root, []string{
+		"example.com/foo",
+		"github.com/pkg/errors@v0.8.0",
+		"github.com/BurntSushi/toml@v0.3.1",
+		"github.com/pkg/errors@v0.8.0",
+		"golang.org/x/text@v0.3.2",
+	})
+	assert.Nil(t, err)
+	assert.Len(t, modules, 2)
+	assert.Equal(t, "BSD-2-Clause", bestLicense(modules["github.com/pkg/errors@v0.8.0"]))
+	assert.Equal(t, "MIT", bestLicense(modules["github.com/BurntSushi/toml@v0.3.1"]))
+
+	_, err = DetectGoModuleCache(path.Join(root, "missing"), nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,92 @@
+package licensedb
+
+import (
+	"sort"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+// Graph links the detected licenses to the files which support them. It is the same
+// information as in Result, but indexed in both directions.
+type Graph struct {
+	// Licenses are the license nodes sorted by confidence in descending order.
+	Licenses []LicenseNode `json:"licenses"`
+	// Files are the sorted paths to all the evidence files.
+	Files []string `json:"files"`
+	// Evidence are the edges between the licenses and the files, one per Match.
+	Evidence []Evidence `json:"evidence"`
+}
+
+// LicenseNode is the detected license with its maximum confidence among the evidence,
+// the same as Detect returns.
+type LicenseNode struct {
+	License    string  `json:"license"`
+	Confidence float32 `json:"confidence"`
+}
+
+// Evidence is the edge from the license to the file which supports it.
+type Evidence struct {
+	License string `json:"license"`
+	File    string `json:"file"`
+	// Plan is the detection plan which found the license in the file, e.g. PlanLicenseFiles.
+	Plan string `json:"plan"`
+	// Confidence is from 0 to 1, 1 means 100% confident.
+	Confidence float32 `json:"confidence"`
+}
+
+// DetectGraph is the same as DetectDetailed but returns the evidence graph.
+func DetectGraph(fs filer.Filer) (*Graph, error) {
+	result, err := DetectDetailed(fs)
+	if err != nil {
+		return nil, err
+	}
+	return result.Graph(), nil
+}
+
+// Graph converts the matches to the evidence graph.
+func (result *Result) Graph() *Graph {
+	graph := &Graph{}
+	files := map[string]bool{}
+	for _, match := range result.Matches {
+		graph.Evidence = append(graph.Evidence, Evidence{
+			License: match.License, File: match.File, Plan: match.Plan,
+			Confidence: match.Confidence})
+		if !files[match.File] {
+			files[match.File] = true
+			graph.Files = append(graph.Files, match.File)
+		}
+	}
+	sort.Strings(graph.Files)
+	nodes := &Result{}
+	for name, confidence := range result.Licenses() {
+		nodes.Matches = append(nodes.Matches, Match{License: name, Confidence: confidence})
+	}
+	nodes.sort()
+	for _, match := range nodes.Matches {
+		graph.Licenses = append(graph.Licenses, LicenseNode{
+			License: match.License, Confidence: match.Confidence})
+	}
+	return graph
+}
+
+// FilesOf returns the evidence of the license in the order of descending confidence.
+func (graph *Graph) FilesOf(license string) []Evidence {
+	var edges []Evidence
+	for _, edge := range graph.Evidence {
+		if edge.License == license {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// LicensesOf returns the licenses supported by the file in the order of descending confidence.
+func (graph *Graph) LicensesOf(file string) []Evidence {
+	var edges []Evidence
+	for _, edge := range graph.Evidence {
+		if edge.File == file {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
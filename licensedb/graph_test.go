@@ -0,0 +1,38 @@
+package licensedb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectGraph(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE-APACHE": referenceText(t, "Apache-2.0"),
+		"LICENSE-MIT":    referenceText(t, "MIT"),
+		"README.md":      "# Foo\n\nDual-licensed under MIT or Apache 2.0.\n",
+	}
+	graph, err := DetectGraph(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"LICENSE-APACHE", "LICENSE-MIT"}, graph.Files)
+	licenses := map[string]float32{}
+	for _, node := range graph.Licenses {
+		licenses[node.License] = node.Confidence
+	}
+	assert.Equal(t, mustDetect(t, fs), licenses)
+	mit := graph.FilesOf("MIT")
+	assert.Equal(t, "LICENSE-MIT", mit[0].File)
+	assert.Equal(t, PlanLicenseFiles, mit[0].Plan)
+	assert.Equal(t, float32(1), mit[0].Confidence)
+	apache := graph.FilesOf("Apache-2.0")
+	assert.Equal(t, "LICENSE-APACHE", apache[0].File)
+	assert.Equal(t, "Apache-2.0", graph.LicensesOf("LICENSE-APACHE")[0].License)
+	assert.Equal(t, "MIT", graph.LicensesOf("LICENSE-MIT")[0].License)
+	for _, edge := range graph.FilesOf("MIT") {
+		assert.NotEqual(t, "LICENSE-APACHE", edge.File)
+	}
+	assert.Empty(t, graph.LicensesOf("README.md"))
+
+	_, err = DetectGraph(memoryFiler{})
+	assert.Equal(t, ErrNoLicenseFound, err)
+}
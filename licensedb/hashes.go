@@ -0,0 +1,67 @@
+package licensedb
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+// hashingFiler looks up the raw contents of the files in Options.KnownHashes as they are read,
+// before the candidates are decoded, e.g. from Markdown, so that the license files are not
+// read again to hash them.
+type hashingFiler struct {
+	filer.Filer
+	// known maps the lower case hashes to the licenses.
+	known map[string]string
+	// licenses map the paths of the read files to their licenses if the hashes are known.
+	licenses map[string]string
+}
+
+// newHashingFiler wraps fs so that the files are hashed if there are any known hashes.
+// The hashes are case insensitive.
+func newHashingFiler(fs filer.Filer, knownHashes map[string]string) *hashingFiler {
+	known := make(map[string]string, len(knownHashes))
+	for hash, license := range knownHashes {
+		known[strings.ToLower(hash)] = license
+	}
+	return &hashingFiler{Filer: fs, known: known, licenses: map[string]string{}}
+}
+
+func (fs *hashingFiler) ReadFile(path string) ([]byte, error) {
+	content, err := fs.Filer.ReadFile(path)
+	if err == nil && len(fs.known) > 0 {
+		if license, exists := knownHashLicense(content, fs.known); exists {
+			fs.licenses[path] = license
+		}
+	}
+	return content, err
+}
+
+func (fs *hashingFiler) ReadFilePrefix(path string, size int) ([]byte, error) {
+	// the prefixes are never the whole license files
+	return filer.ReadFilePrefix(fs.Filer, path, size)
+}
+
+// knownHashLicense returns the license of the raw contents of the license file from
+// the known hashes. The contents are hashed with SHA-1, SHA-256 and as the Git blob,
+// e.g. `git hash-object LICENSE`, so that any of them may be the key.
+func knownHashLicense(content []byte, known map[string]string) (string, bool) {
+	sha1Sum := sha1.Sum(content)
+	sha256Sum := sha256.Sum256(content)
+	blob := sha1.New()
+	fmt.Fprintf(blob, "blob %d\x00", len(content))
+	blob.Write(content)
+	for _, hash := range []string{
+		hex.EncodeToString(sha1Sum[:]), hex.EncodeToString(sha256Sum[:]),
+		hex.EncodeToString(blob.Sum(nil)),
+	} {
+		if license, exists := known[hash]; exists {
+			return license, true
+		}
+	}
+	return "", false
+}
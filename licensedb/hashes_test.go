@@ -0,0 +1,50 @@
+package licensedb
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectKnownHashes(t *testing.T) {
+	// the custom text which the matcher would never identify
+	custom := "Foo Corp grants you the right to use this software internally.\n"
+	sha256Sum := sha256.Sum256([]byte(custom))
+	apache := referenceText(t, "Apache-2.0")
+	blob := sha1.Sum([]byte(fmt.Sprintf("blob %d\x00%s", len(apache), apache)))
+	options := Options{KnownHashes: map[string]string{
+		hex.EncodeToString(sha256Sum[:]): "LicenseRef-Foo",
+		hex.EncodeToString(blob[:]):      "Apache-2.0",
+	}}
+	result, err := DetectDetailedWithOptions(memoryFiler{"LICENSE": custom}, options)
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{License: "LicenseRef-Foo", Confidence: 1, File: "LICENSE",
+		Plan: PlanLicenseFiles}}, result.Matches)
+	_, err = Detect(memoryFiler{"LICENSE": custom})
+	assert.Equal(t, ErrNoLicenseFound, err)
+
+	// the similar licenses are not matched either
+	result, err = DetectDetailedWithOptions(memoryFiler{"LICENSE.txt": apache}, options)
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{License: "Apache-2.0", Confidence: 1, File: "LICENSE.txt",
+		Plan: PlanLicenseFiles}}, result.Matches)
+	result, err = DetectDetailedWithOptions(memoryFiler{"LICENSE.txt": apache + "\n"}, options)
+	assert.Nil(t, err)
+	assert.True(t, len(result.Matches) > 1)
+
+	// the upper case hashes match too and the license file is read once
+	options = Options{KnownHashes: map[string]string{
+		strings.ToUpper(hex.EncodeToString(sha256Sum[:])): "LicenseRef-Foo"}}
+	stats := &Stats{}
+	result, err = detect(memoryFiler{"LICENSE": custom}, options, stats)
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{License: "LicenseRef-Foo", Confidence: 1, File: "LICENSE",
+		Plan: PlanLicenseFiles}}, result.Matches)
+	assert.Equal(t, 1, stats.FilesRead)
+	assert.Equal(t, int64(len(custom)), stats.BytesRead)
+}
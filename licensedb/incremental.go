@@ -0,0 +1,144 @@
+package licensedb
+
+import (
+	"os"
+	paths "path"
+	"strings"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal"
+)
+
+// IncrementalDetector detects the licenses of the files which are fed one by one, e.g.
+// while walking the tree lazily. Each file is investigated once it is added, and the plans
+// take the same precedence as in Detect: the license files win over the README files which
+// win over the header comments. The license files which refer to other files, including
+// the CMake references, are not followed since the rest of the tree is not known. The license
+// override, the manifests and the binary files are not investigated.
+type IncrementalDetector struct {
+	licenseFiles, readmeFiles, headers Result
+	// normalized header comment -> the indices of its matches in headers
+	banners map[string][]int
+	// only ScanHeaders, CollapseVersions and the database are taken into account
+	options Options
+	// the default database is disabled and Options.Database is not set
+	disabled bool
+}
+
+// NewIncrementalDetector creates a new IncrementalDetector without any files.
+func NewIncrementalDetector() *IncrementalDetector {
+	return NewIncrementalDetectorWithOptions(Options{})
+}
+
+// NewIncrementalDetectorWithOptions is the same as NewIncrementalDetector but the header
+// comments of the source files are investigated only if Options.ScanHeaders is set, the
+// uncertain versions are collapsed with Options.CollapseVersions, and the files are matched
+// against Options.Database or the default database of Options.Tokenizer. The other options
+// are ignored. If the default database is disabled and Options.Database is
+// not set, the files are not investigated and Result fails with ErrDefaultDatabaseDisabled.
+func NewIncrementalDetectorWithOptions(options Options) *IncrementalDetector {
+	d := &IncrementalDetector{banners: map[string][]int{}}
+	if db, err := options.database(); err != nil {
+		d.disabled = true
+	} else {
+		d.options = Options{ScanHeaders: options.ScanHeaders, CollapseVersions: options.CollapseVersions,
+			Database: &Database{db: db}}
+	}
+	return d
+}
+
+// Add classifies the file by its path relative to the root of the tree and investigates
+// the content. The files which are ignored by Detect, e.g. the nested license files,
+// are ignored here as well.
+func (d *IncrementalDetector) Add(path string, content []byte) {
+	if d.disabled {
+		return
+	}
+	path = strings.TrimPrefix(paths.Clean(path), "/")
+	fs := singleFiler{path: path, content: content}
+	dir := paths.Dir(path)
+	isRoot := dir == "."
+	if isRoot || (!strings.Contains(dir, "/") && internal.IsLicenseDirectory(dir)) {
+		for file, text := range internal.ExtractLicenseFiles([]string{path}, fs) {
+			d.licenseFiles.addText(file, PlanLicenseFiles, 0, text, d.options)
+		}
+	}
+	if isRoot || dir == wellKnownDirectory {
+		for file, text := range internal.ExtractReadmeFiles([]string{path}, fs) {
+			d.readmeFiles.addMatches(file, PlanReadme, internal.InvestigateReadmeText(text, fs, d.options.Database.db))
+		}
+	}
+	if !d.options.ScanHeaders || !isSourceFileListed(path) {
+		return
+	}
+	sources := internal.ExtractSourceFiles([]string{path}, fs)
+	for file, comment := range internal.ExtractHeaderComments(sources) {
+		key := internal.HeaderBannerKey(comment)
+		if indices, exists := d.banners[key]; exists {
+			for _, i := range indices {
+				d.headers.Matches[i].Occurrences++
+			}
+			continue
+		}
+		before := len(d.headers.Matches)
+		d.headers.addHeader(internal.HeaderBanner{Text: comment, Files: []string{file}}, d.options)
+		indices := []int{}
+		for i := before; i < len(d.headers.Matches); i++ {
+			indices = append(indices, i)
+		}
+		d.banners[key] = indices
+	}
+}
+
+// Result returns the licenses detected in the files added so far by PlanLicenseFiles,
+// PlanReadme and PlanHeaders. This is what Detect returns for the whole tree unless PlanOverride
+// or PlanManifests take precedence, or PlanBinaries is needed. It returns ErrNoLicenseFound if
+// there are no licenses yet.
+func (d *IncrementalDetector) Result() (map[string]float32, error) {
+	if d.disabled {
+		return nil, ErrDefaultDatabaseDisabled
+	}
+	for _, result := range []*Result{&d.licenseFiles, &d.readmeFiles, &d.headers} {
+		if len(result.Matches) == 0 {
+			continue
+		}
+		if !d.options.CollapseVersions {
+			return result.Licenses(), nil
+		}
+		// the matches of the headers are indexed by the banners, so they must stay intact
+		collapsed := &Result{Matches: append([]Match{}, result.Matches...)}
+		collapsed.collapseVersions()
+		return collapsed.Licenses(), nil
+	}
+	return nil, ErrNoLicenseFound
+}
+
+// isSourceFileListed indicates whether listSourceFiles visits the file.
+func isSourceFileListed(path string) bool {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ".") || (i < len(parts)-1 && skippedSourceDirectories[part]) {
+			return false
+		}
+	}
+	return true
+}
+
+// singleFiler is the Filer which contains only one file.
+type singleFiler struct {
+	path    string
+	content []byte
+}
+
+func (fs singleFiler) ReadFile(path string) ([]byte, error) {
+	if path != fs.path {
+		return nil, os.ErrNotExist
+	}
+	return fs.content, nil
+}
+
+func (fs singleFiler) ReadDir(path string) ([]filer.File, error) {
+	return nil, os.ErrNotExist
+}
+
+func (fs singleFiler) Close() {}
@@ -0,0 +1,47 @@
+package licensedb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncrementalDetector(t *testing.T) {
+	trees := []memoryFiler{
+		{
+			"LICENSE":          referenceText(t, "MIT"),
+			"README.md":        "# Project\n\nLicensed under the Apache License 2.0.",
+			"docs/LICENSE":     referenceText(t, "GPL-3.0-only"),
+			"licenses/BSD.txt": referenceText(t, "BSD-3-Clause"),
+			"main.go":          "package main",
+		},
+		{
+			"README.md": "# Project\n\nLicensed under the Apache License 2.0.",
+			"src/a.go":  fmt.Sprintf(apacheHeader, 2017),
+		},
+		{
+			"src/a.go":        fmt.Sprintf(apacheHeader, 2017),
+			"src/b.go":        fmt.Sprintf(apacheHeader, 2018),
+			"vendor/x/x.go":   "// Copyright 2018 X\n// Licensed under the MIT license.\npackage x",
+			".hidden/LICENSE": referenceText(t, "MIT"),
+		},
+		{
+			"README.md": "# Foo\n\n## License\n\nGPL\n",
+		},
+	}
+	for _, options := range []Options{{}, {ScanHeaders: true}, {CollapseVersions: true}} {
+		for _, fs := range trees {
+			detector := NewIncrementalDetectorWithOptions(options)
+			_, err := detector.Result()
+			assert.Equal(t, ErrNoLicenseFound, err)
+			for path, content := range fs {
+				detector.Add(path, []byte(content))
+			}
+			licenses, err := detector.Result()
+			expected, expectedErr := DetectWithOptions(fs, options)
+			assert.Equal(t, expectedErr, err)
+			assert.Equal(t, expected, licenses)
+		}
+	}
+}
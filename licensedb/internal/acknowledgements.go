@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/xml"
+	paths "path"
+	"strings"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+// IsAcknowledgementsFile indicates whether the file is the property list with the third party
+// acknowledgements of an iOS or macOS app, e.g. Settings.bundle/Acknowledgements.plist or
+// Pods-App-acknowledgements.plist generated by CocoaPods.
+func IsAcknowledgementsFile(fileName string) bool {
+	name := strings.ToLower(paths.Base(fileName))
+	return name == "acknowledgements.plist" || strings.HasSuffix(name, "-acknowledgements.plist")
+}
+
+// ExtractAcknowledgements returns the contents of the acknowledgements property lists mapped
+// from the file paths, see IsAcknowledgementsFile.
+func ExtractAcknowledgements(files []string, fs filer.Filer) map[string][]byte {
+	plists := map[string][]byte{}
+	for _, file := range files {
+		if IsAcknowledgementsFile(file) {
+			data, err := fs.ReadFile(file)
+			if err == nil {
+				plists[file] = data
+			}
+		}
+	}
+	return plists
+}
+
+// InvestigateAcknowledgements parses the XML property list with the acknowledgements and
+// returns the license of each entry in PreferenceSpecifiers whose Title is the component.
+// FooterText is matched against the reference licenses, and if it matches nothing,
+// the License field written by CocoaPods, e.g. "MIT", is taken. The entries without
+// a license, e.g. the "Generated by CocoaPods" footer, are skipped.
+func InvestigateAcknowledgements(data []byte, db *Database) []ThirdPartyLicense {
+	var components []ThirdPartyLicense
+	for _, entry := range parsePlistDicts(data) {
+		title := strings.TrimSpace(entry["Title"])
+		if title == "" {
+			continue
+		}
+		license, confidence := bestLicense(db.QueryLicenseText(entry["FooterText"]))
+		if license == "" && entry["License"] != "" {
+			license, confidence = bestLicense(InvestigateDeclaredLicense(entry["License"], db))
+		}
+		if license != "" {
+			components = append(components, ThirdPartyLicense{
+				Component: title, License: license, Confidence: confidence})
+		}
+	}
+	return components
+}
+
+// parsePlistDicts returns the string values of each <dict> in the XML property list,
+// in the order of the closing tags, so that the nested dictionaries go first. The other
+// values, e.g. <integer>, are ignored. The malformed property lists are parsed up to
+// the first error.
+func parsePlistDicts(data []byte) []map[string]string {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// DOCTYPE refers to the external DTD of Apple which is never fetched
+	decoder.Strict = false
+	var dicts, stack []map[string]string
+	var key string
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return dicts
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "key", "string":
+				var value string
+				if decoder.DecodeElement(&value, &element) != nil {
+					return dicts
+				}
+				if element.Name.Local == "key" {
+					key = value
+				} else if len(stack) > 0 && key != "" {
+					stack[len(stack)-1][key] = value
+					key = ""
+				}
+			case "dict":
+				stack = append(stack, map[string]string{})
+				key = ""
+			default:
+				key = ""
+			}
+		case xml.EndElement:
+			if element.Name.Local == "dict" && len(stack) > 0 {
+				dicts = append(dicts, stack[len(stack)-1])
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// e.g. https://img.shields.io/badge/License-MIT-yellow.svg, see https://shields.io/#static-badge
+	shieldsBadgeRe = regexp.MustCompile("(?i)img\\.shields\\.io/badge/([^/?#()\\[\\]\"'\\s<>]+)")
+	// e.g. https://badgen.net/badge/license/MIT/blue
+	badgenBadgeRe = regexp.MustCompile(
+		"(?i)badgen\\.net/badge/licen[cs]e/([^/?#()\\[\\]\"'\\s<>]+)")
+	badgeLabelRe = regexp.MustCompile("(?i)licen[cs]e")
+)
+
+// extractBadgeLicenses returns the license names declared by the static badges in the README
+// source, e.g. ![License: MIT](https://img.shields.io/badge/License-MIT-yellow.svg).
+// The badges are images, so they disappear after the Markdown or HTML rendering.
+func extractBadgeLicenses(text []byte) []string {
+	var names []string
+	for _, match := range shieldsBadgeRe.FindAllSubmatch(text, -1) {
+		parts := splitShieldsBadge(string(match[1]))
+		// label-message-color
+		if len(parts) == 3 && badgeLabelRe.MatchString(parts[0]) && parts[1] != "" {
+			names = append(names, parts[1])
+		}
+	}
+	for _, match := range badgenBadgeRe.FindAllSubmatch(text, -1) {
+		if name, err := url.PathUnescape(string(match[1])); err == nil && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// splitShieldsBadge splits the static shields.io badge path into its parts and decodes them:
+// "--" is the dash, "__" is the underscore and "_" is the space.
+func splitShieldsBadge(path string) []string {
+	parts := strings.Split(strings.Replace(path, "--", "\x00", -1), "-")
+	for i, part := range parts {
+		part = strings.Replace(part, "\x00", "-", -1)
+		part = strings.Replace(part, "__", "\x00", -1)
+		part = strings.Replace(part, "_", " ", -1)
+		part = strings.Replace(part, "\x00", "_", -1)
+		if unescaped, err := url.PathUnescape(part); err == nil {
+			part = unescaped
+		}
+		parts[i] = strings.TrimSpace(part)
+	}
+	return parts
+}
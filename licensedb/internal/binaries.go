@@ -0,0 +1,100 @@
+package internal
+
+import (
+	paths "path"
+	"strings"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+var (
+	// the extensions of the compiled binaries and libraries, see ExtractBinaryStrings
+	binaryExtensions = map[string]bool{
+		".a":     true,
+		".bin":   true,
+		".dll":   true,
+		".dylib": true,
+		".exe":   true,
+		".lib":   true,
+		".node":  true,
+		".o":     true,
+		".so":    true,
+		".wasm":  true,
+	}
+)
+
+const (
+	// maxBinaryFiles is the maximum number of the binary files which are read.
+	maxBinaryFiles = 64
+	// maxBinarySize is the maximum size of the binary file whose strings are extracted.
+	// Filer cannot tell the size before reading, so the bigger files are read and dropped.
+	maxBinarySize = 16 << 20
+	// minBinaryStringLength is the minimum length of the extracted string. The shortest
+	// license texts, e.g. 0BSD, are longer.
+	minBinaryStringLength = 128
+	// maxBinaryStrings is the maximum number of the strings extracted from a single file.
+	maxBinaryStrings = 32
+)
+
+// IsBinaryFile indicates whether the file name is likely to belong to a compiled binary or
+// library, e.g. "libfoo.so.1" or "foo.dll".
+func IsBinaryFile(fileName string) bool {
+	name := strings.ToLower(paths.Base(fileName))
+	if binaryExtensions[paths.Ext(name)] {
+		return true
+	}
+	// the versioned shared libraries
+	return strings.Contains(name, ".so.")
+}
+
+// ExtractBinaryStrings reads the binary files, see IsBinaryFile, and returns the long runs of
+// the printable ASCII characters in each of them, like the strings utility, mapped from the file
+// paths. The files without such runs are not included. The number and the size of the files,
+// as well as the number of the runs, are limited, see maxBinaryFiles.
+func ExtractBinaryStrings(files []string, fs filer.Filer) map[string][][]byte {
+	candidates := map[string][][]byte{}
+	read := 0
+	for _, file := range files {
+		if read >= maxBinaryFiles {
+			break
+		}
+		if !IsBinaryFile(file) {
+			continue
+		}
+		read++
+		content, err := fs.ReadFile(file)
+		if err != nil || len(content) > maxBinarySize {
+			continue
+		}
+		if runs := binaryStrings(content); len(runs) > 0 {
+			candidates[file] = runs
+		}
+	}
+	return candidates
+}
+
+// binaryStrings returns the copies of the runs of the printable ASCII characters and
+// the whitespace which are at least minBinaryStringLength long, up to maxBinaryStrings runs.
+func binaryStrings(content []byte) [][]byte {
+	var runs [][]byte
+	start := -1
+	for i := 0; i <= len(content); i++ {
+		if i < len(content) {
+			char := content[i]
+			if (char >= 0x20 && char < 0x7f) || char == '\n' || char == '\r' || char == '\t' {
+				if start < 0 {
+					start = i
+				}
+				continue
+			}
+		}
+		if start >= 0 && i-start >= minBinaryStringLength {
+			runs = append(runs, append([]byte(nil), content[start:i]...))
+			if len(runs) == maxBinaryStrings {
+				break
+			}
+		}
+		start = -1
+	}
+	return runs
+}
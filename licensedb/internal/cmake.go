@@ -0,0 +1,110 @@
+package internal
+
+import (
+	paths "path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+var (
+	cmakeFileRe       = regexp.MustCompile("^cmakelists\\.txt$")
+	cmakeCommentRe    = regexp.MustCompile("(?m)#.*$")
+	cmakeInstallRe    = regexp.MustCompile("(?is)\\binstall\\s*\\(\\s*FILES\\s+([^)]*)\\)")
+	cmakeLicenseVarRe = regexp.MustCompile("(?i)\\bset\\s*\\(\\s*\\w*licen[cs]e\\w*\\s+([^\\s)]+)")
+	cmakeSourceDirRe  = regexp.MustCompile(
+		"^\\$\\{(CMAKE_CURRENT_SOURCE_DIR|CMAKE_SOURCE_DIR|PROJECT_SOURCE_DIR|CMAKE_CURRENT_LIST_DIR)\\}/")
+
+	// the keywords of install() which end the list of the files; the files themselves may be
+	// upper case, e.g. README or LICENSE
+	cmakeInstallKeywords = map[string]bool{
+		"ALL_COMPONENTS": true, "ARCHIVE": true, "BUNDLE": true, "CODE": true, "COMPONENT": true,
+		"CONFIGURATIONS": true, "DESTINATION": true, "DIRECTORY": true, "DIRECTORY_PERMISSIONS": true,
+		"EXCLUDE_FROM_ALL": true, "EXPORT": true, "FILES": true, "FILES_MATCHING": true,
+		"FILE_PERMISSIONS": true, "FRAMEWORK": true, "INCLUDES": true, "LIBRARY": true,
+		"MESSAGE_NEVER": true, "NAMELINK_COMPONENT": true, "NAMELINK_ONLY": true,
+		"NAMELINK_SKIP": true, "OBJECTS": true, "OPTIONAL": true, "PATTERN": true,
+		"PERMISSIONS": true, "PRIVATE_HEADER": true, "PROGRAMS": true, "PUBLIC_HEADER": true,
+		"REGEX": true, "RENAME": true, "RESOURCE": true, "RUNTIME": true, "SCRIPT": true,
+		"TARGETS": true, "TYPE": true, "USE_SOURCE_PERMISSIONS": true,
+	}
+)
+
+// ExtractCMakeLicenseFiles returns the texts of the license files referenced in CMakeLists.txt
+// mapped from the file paths.
+// Two kinds of references are recognized: install(FILES ...) of a file which looks like a license
+// and set(<VARIABLE WITH LICENSE IN THE NAME> path), e.g. CPACK_RESOURCE_FILE_LICENSE.
+// The files which are already discovered by ExtractLicenseFiles are skipped.
+func ExtractCMakeLicenseFiles(files []string, fs filer.Filer) map[string][]byte {
+	candidates := map[string][]byte{}
+	known := map[string]bool{}
+	for _, file := range files {
+		if licenseFileRe.MatchString(strings.ToLower(paths.Base(file))) {
+			known[file] = true
+		}
+	}
+	for _, file := range files {
+		if !cmakeFileRe.MatchString(strings.ToLower(file)) {
+			continue
+		}
+		text, err := fs.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, ref := range parseCMakeLicenseReferences(string(text)) {
+			if known[ref] {
+				continue
+			}
+			known[ref] = true
+			content, err := fs.ReadFile(ref)
+			if err != nil {
+				continue
+			}
+			if preprocessor, exists := filePreprocessors[paths.Ext(ref)]; exists {
+				content = preprocessor(content)
+			}
+			candidates[ref] = content
+		}
+	}
+	return candidates
+}
+
+// parseCMakeLicenseReferences extracts the paths to license files from the CMake source.
+// The paths are relative to the project root.
+func parseCMakeLicenseReferences(text string) []string {
+	text = cmakeCommentRe.ReplaceAllString(text, "")
+	var refs []string
+	for _, match := range cmakeInstallRe.FindAllStringSubmatch(text, -1) {
+		for _, arg := range strings.Fields(match[1]) {
+			if cmakeInstallKeywords[arg] {
+				break
+			}
+			if path := cleanCMakePath(arg); path != "" &&
+				licenseFileRe.MatchString(strings.ToLower(paths.Base(path))) {
+				refs = append(refs, path)
+			}
+		}
+	}
+	for _, match := range cmakeLicenseVarRe.FindAllStringSubmatch(text, -1) {
+		if path := cleanCMakePath(match[1]); path != "" {
+			refs = append(refs, path)
+		}
+	}
+	return refs
+}
+
+// cleanCMakePath turns a CMake path argument into a path relative to the project root.
+// It returns an empty string if the path cannot be resolved statically.
+func cleanCMakePath(arg string) string {
+	arg = strings.Trim(arg, "\"")
+	arg = cmakeSourceDirRe.ReplaceAllString(arg, "")
+	if arg == "" || strings.Contains(arg, "${") || paths.IsAbs(arg) {
+		return ""
+	}
+	arg = paths.Clean(arg)
+	if strings.HasPrefix(arg, "..") {
+		return ""
+	}
+	return arg
+}
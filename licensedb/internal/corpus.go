@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal/assets"
+)
+
+// SPDXDataVersion is the version of the SPDX license list data which the assets are
+// generated from. It must be the same as SPDX_DATA_VERSION in the Makefile.
+const SPDXDataVersion = "3.0"
+
+var corpusVersion struct {
+	sync.Once
+	version string
+}
+
+// CorpusVersion returns the version of the embedded reference licenses, e.g. "3.0-5f1c2a9e":
+// SPDXDataVersion and the digest of the assets. The digest changes whenever the assets are
+// regenerated with different texts, including the extra licenses, even if SPDXDataVersion
+// stays the same. The matching algorithms are not covered, so the results of the same
+// version may differ between the releases.
+func CorpusVersion() string {
+	corpusVersion.Do(func() {
+		names := assets.AssetNames()
+		sort.Strings(names)
+		hash := sha1.New()
+		for _, name := range names {
+			data, err := assets.Asset(name)
+			if err != nil {
+				log.Fatalf("failed to load %s from the assets: %v", name, err)
+			}
+			hash.Write([]byte(name))
+			hash.Write([]byte{0})
+			hash.Write(data)
+		}
+		corpusVersion.version = fmt.Sprintf("%s-%x", SPDXDataVersion, hash.Sum(nil)[:4])
+	})
+	return corpusVersion.version
+}
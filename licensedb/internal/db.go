@@ -3,6 +3,7 @@ package internal
 import (
 	"archive/tar"
 	"bytes"
+	"crypto/sha1"
 	"encoding/csv"
 	"fmt"
 	"index/suffixarray"
@@ -13,6 +14,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"unicode"
 
 	"github.com/ekzhu/minhash-lsh"
 	"github.com/sergi/go-diff/diffmatchpatch"
@@ -28,21 +30,64 @@ var (
 	licenseReadmeMentionRe = regexp.MustCompile(
 		fmt.Sprintf("(?i)[^\\s]+/[^/\\s]*(%s)[^\\s]*",
 			strings.Join(licenseFileNames, "|")))
+	// reStructuredText field list, e.g. ":License: MIT", or humans.txt field, e.g. "License: MIT"
+	licenseFieldRe   = regexp.MustCompile("(?im)^[ \\t]*(?::licen[cs]e:|licen[cs]e:)[ \\t]*(\\S.*)$")
+	leadingArticleRe = regexp.MustCompile("(?i)^the\\s+")
+	// "CeCILL v2.1", "CeCILL-B"; the name substrings cannot tell "2.1" from "1.1" by the "1"
+	cecillNameRe = regexp.MustCompile("(?i)^cecill[-_ ]*(?:v(?:ersion)?[ ]*)?([0-9]\\.[0-9]|[bc])$")
+	// the blank line between the paragraphs, see ignorePreamble
+	paragraphBreakRe = regexp.MustCompile("\\n[ \\t\\r]*\\n\\s*")
+	// the preamble which refers to the license below it, e.g. "Rails is released under the MIT
+	// License." or "distributed under the following terms", see ignorePreamble
+	preambleMarkerRe = regexp.MustCompile(
+		"(?i)\\b(released|licensed|distributed|available|provided)\\s+under\\b|" +
+			"\\bunder\\s+the\\s+(following|terms\\s+below)\\b")
+	// the attribution requirement of MIT which MIT-0 lacks, see discriminateAttribution;
+	// the words may be separated by the comment markers of the header
+	attributionClauseRe = regexp.MustCompile("(?i)above[\\W_]+copyright[\\W_]+notice[\\W_]+and[\\W_]+" +
+		"this[\\W_]+permission[\\W_]+notice[\\W_]+shall[\\W_]+be[\\W_]+included")
+	// the explicit refusal of the later versions, e.g. "GPL version 2 only" or "version 2 of
+	// the GPL, not any later version", which captures the version, see discriminateLaterVersions
+	onlyVersionRe = regexp.MustCompile("(?i)\\bversions?[\\W_]+(\\d+(?:\\.\\d+)?)[\\W_]+only\\b|" +
+		"\\bv(\\d+(?:\\.\\d+)?)[\\W_]+only\\b|" +
+		"\\bversions?[\\W_]+(\\d+(?:\\.\\d+)?)\\b[^.]{0,60}?\\bnot?[\\W_]+(?:any[\\W_]+|a[\\W_]+)?" +
+		"later[\\W_]+versions?\\b")
+	// the grant and the advertising clause of the Historical Permission Notice and Disclaimer,
+	// see discriminateHistoricalNotice
+	hpndGrantRe       = regexp.MustCompile("(?i)permission[\\W_]+to[\\W_]+use[\\W_]+copy[\\W_]+modify")
+	hpndAdvertisingRe = regexp.MustCompile("(?i)not[\\W_]+be[\\W_]+used[\\W_]+in[\\W_]+advertising[\\W_]+" +
+		"or[\\W_]+publicity[\\W_]+pertaining[\\W_]+to[\\W_]+distribution")
+	// the permissive licenses which the HPND notices are mistaken for
+	hpndLookalikes = map[string]bool{"MIT": true, "MIT-0": true, "X11": true, "0BSD": true, "ISC": true}
 )
 
 // database holds the license texts, their hashes and the hashtables to query for nearest
 // neighbors.
 type database struct {
 	debug bool
+	// splits the lines of the normalized texts into the tokens
+	tokenizer Tokenizer
 
 	// license name -> text
 	licenseTexts map[string]string
+	// license name -> the stable key derived from the original text
+	licenseKeys map[string]string
+	// the name of the part of the license text in licenseTexts -> license name, see cjkPortion
+	portions map[string]string
+	// license name -> the warranty disclaimer in its text, see findDisclaimer
+	disclaimers map[string]disclaimer
+	// lower case license name without "deprecated_" -> license name
+	licenseIDs map[string]string
+	// SPDX identifier -> full name, e.g. "MIT" -> "MIT License"
+	names map[string]string
 	// minimum license text length
 	minLicenseLength int
 	// official license URLs
 	urls map[string]string
 	// all URLs joined
 	urlRe *regexp.Regexp
+	// license name -> the other licenses whose URLs are mentioned in its text
+	urlReferences map[string]map[string]bool
 	// first line of each license OR-ed - used to split
 	firstLineRe *regexp.Regexp
 	// unique unigrams -> index
@@ -71,11 +116,36 @@ type substring struct {
 const (
 	numHashes           = 154
 	similarityThreshold = 0.75
+	// cjkLSHThreshold is the LSH threshold of the database indexed with CJKTokenizer.
+	cjkLSHThreshold = 0.65
+	// unorderedShingleSize is the number of consecutive tokens in each shingle compared by
+	// QueryLicenseTextUnordered.
+	unorderedShingleSize = 3
+	// minTruncatedCompleteness is the minimum share of the license text which must remain
+	// in the truncated copy, so that e.g. the first paragraph of BSD is not truncated BSD.
+	minTruncatedCompleteness = 0.3
+	// maxTruncatedCompleteness is the maximum share of the license text which remains
+	// in the truncated copy. The texts which lack only a few last words are not truncated.
+	maxTruncatedCompleteness = 0.95
+	// truncatedPrefixLength is the length of the beginning of the normalized text which
+	// the truncated copy must share with the license text, see lookupTruncatedLicenses.
+	truncatedPrefixLength = 100
+	// hpndNoticeConfidence is the confidence of the historical notice which carries the HPND
+	// grant and advertising clause but is not similar enough to any license, see
+	// discriminateHistoricalNotice.
+	hpndNoticeConfidence = 0.8
+	// maxPreambleLines is the maximum number of the lines of the leading paragraph which may be
+	// the project-specific preamble of the license, e.g. "Rails is released under the MIT
+	// License." above the text of MIT.
+	maxPreambleLines = 3
+	// preambleSimilarityThreshold is the similarity below which the preamble is suspected and
+	// which the rest of the text must reach to ignore the preamble.
+	preambleSimilarityThreshold = 0.95
 )
 
 // Length returns the number of registered licenses.
 func (db database) Length() int {
-	return len(db.licenseTexts)
+	return len(db.licenseTexts) - len(db.portions)
 }
 
 // VocabularySize returns the number of unique unigrams.
@@ -117,7 +187,9 @@ func loadNames(db *database) {
 	}
 	db.nameSubstringSizes = map[string]int{}
 	db.nameSubstrings = map[string][]substring{}
+	db.names = map[string]string{}
 	for _, record := range records {
+		db.names[record[0]] = record[1]
 		registerNameSubstrings(record[1], record[0], db.nameSubstringSizes, db.nameSubstrings)
 	}
 }
@@ -142,8 +214,8 @@ func registerNameSubstrings(
 
 // Load takes the licenses from the embedded storage, normalizes, hashes them and builds the
 // LSH hashtables.
-func loadLicenses() *database {
-	db := &database{}
+func loadLicenses(tokenizer Tokenizer) *database {
+	db := &database{tokenizer: tokenizer}
 	if os.Getenv("LICENSE_DEBUG") != "" {
 		db.debug = true
 	}
@@ -156,6 +228,12 @@ func loadLicenses() *database {
 	tarStream := bytes.NewBuffer(tarBytes)
 	archive := tar.NewReader(tarStream)
 	db.licenseTexts = map[string]string{}
+	db.licenseKeys = map[string]string{}
+	db.portions = map[string]string{}
+	db.disclaimers = map[string]disclaimer{}
+	db.urlReferences = map[string]map[string]bool{}
+	// license name -> the SPDX identifiers referenced by the well-known URLs in its text
+	wellKnownReferences := map[string][]string{}
 	tokenFreqs := map[string]map[string]int{}
 	firstLineWriter := &bytes.Buffer{}
 	firstLineWriter.WriteString("(^|\\n)((.*licen[cs]e\\n\\n)|(")
@@ -172,24 +250,66 @@ func loadLicenses() *database {
 		if int64(readSize) != header.Size {
 			log.Fatalf("failed to load licenses.tar from the assets: %s: incomplete read", header.Name)
 		}
+		db.licenseKeys[key] = stableKey(licenseTextVariant(key), text)
+		// e.g. Unicode-DFS-2016 refers to the Terms of Use by the Unicode-TOU URL
+		for _, url := range db.urlRe.FindAllString(string(text), -1) {
+			if other := db.urls[url]; other != key {
+				if db.urlReferences[key] == nil {
+					db.urlReferences[key] = map[string]bool{}
+				}
+				db.urlReferences[key][other] = true
+			}
+		}
+		wellKnownReferences[key] = findWellKnownURLs(string(text))
 		normedText := normalize.LicenseText(string(text), normalize.Moderate)
 		if db.minLicenseLength == 0 || db.minLicenseLength > len(normedText) {
 			db.minLicenseLength = len(normedText)
 		}
-		db.licenseTexts[key] = normedText
-		newLinePos := strings.Index(normedText, "\n")
-		if newLinePos >= 0 {
-			firstLineWriter.WriteString(regexp.QuoteMeta(normedText[:newLinePos]))
-			firstLineWriter.WriteRune('|')
-		}
-		normedText = normalize.Relax(normedText)
-		lines := strings.Split(normedText, "\n")
-		myUniqueTokens := map[string]int{}
-		tokenFreqs[key] = myUniqueTokens
-		for _, line := range lines {
-			tokens := strings.Split(line, " ")
-			for _, token := range tokens {
-				myUniqueTokens[token]++
+		index := func(key, normedText string) {
+			db.licenseTexts[key] = normedText
+			newLinePos := strings.Index(normedText, "\n")
+			if newLinePos >= 0 {
+				firstLineWriter.WriteString(regexp.QuoteMeta(normedText[:newLinePos]))
+				firstLineWriter.WriteRune('|')
+			}
+			normedText = normalize.Relax(normedText)
+			lines := strings.Split(normedText, "\n")
+			myUniqueTokens := map[string]int{}
+			tokenFreqs[key] = myUniqueTokens
+			for _, line := range lines {
+				tokens := db.tokenizer.Tokenize(line)
+				for _, token := range tokens {
+					myUniqueTokens[token]++
+				}
+			}
+		}
+		index(key, normedText)
+		if section, exists := db.findDisclaimer(normedText); exists {
+			db.disclaimers[key] = section
+		}
+		// the bilingual licenses are also matched by their CJK halves alone
+		if portion := cjkPortion(normedText); portion != "" {
+			db.portions[key+cjkPortionSuffix] = key
+			index(key+cjkPortionSuffix, portion)
+		}
+	}
+	db.licenseIDs = map[string]string{}
+	for key := range db.licenseTexts {
+		if _, isPortion := db.portions[key]; isPortion {
+			continue
+		}
+		id := strings.ToLower(strings.TrimPrefix(key, "deprecated_"))
+		if existing, exists := db.licenseIDs[id]; !exists || strings.HasPrefix(existing, "deprecated_") {
+			db.licenseIDs[id] = key
+		}
+	}
+	for key, ids := range wellKnownReferences {
+		for _, id := range ids {
+			if other, exists := db.resolveURLLicense(id); exists && other != key {
+				if db.urlReferences[key] == nil {
+					db.urlReferences[key] = map[string]bool{}
+				}
+				db.urlReferences[key][other] = true
 			}
 		}
 	}
@@ -221,7 +341,13 @@ func loadLicenses() *database {
 		db.tokens[token] = i
 		db.docfreqs[i] = docfreqs[token]
 	}
-	db.lsh = minhashlsh.NewMinhashLSH64(numHashes, similarityThreshold)
+	lshThreshold := similarityThreshold
+	if tokenizer == CJKTokenizer {
+		// every CJK character is a separate token, so the weighted MinHash of the shorter
+		// CJK portions is noisier and the similar texts need a wider margin to be found
+		lshThreshold = cjkLSHThreshold
+	}
+	db.lsh = minhashlsh.NewMinhashLSH64(numHashes, lshThreshold)
 	if db.debug {
 		k, l := db.lsh.Params()
 		log.Println("LSH:", k, l)
@@ -241,7 +367,9 @@ func loadLicenses() *database {
 			}
 		}
 		db.lsh.Add(key, db.hasher.Hash(values, indices))
-		registerNameSubstrings(key, key, db.nameShortSubstringSizes, db.nameShortSubstrings)
+		if _, isPortion := db.portions[key]; !isPortion {
+			registerNameSubstrings(key, key, db.nameShortSubstringSizes, db.nameShortSubstrings)
+		}
 	}
 	db.lsh.Index()
 	return db
@@ -249,22 +377,231 @@ func loadLicenses() *database {
 
 // QueryLicenseText returns the most similar registered licenses.
 func (db *database) QueryLicenseText(text string) map[string]float32 {
+	return db.queryLicenseText(text, false, "")
+}
+
+// QueryLicenseTextUnordered is the same as QueryLicenseText but the similarity does not
+// depend on the order of the paragraphs and the clauses, see shingleSimilarity.
+func (db *database) QueryLicenseTextUnordered(text string) map[string]float32 {
+	return db.queryLicenseText(text, true, "")
+}
+
+// QueryAllScores returns the similarity of the text to every registered license, including
+// the licenses below similarityThreshold and those which the hashtables do not consider close,
+// e.g. to tune the thresholds. The scores of the licenses which QueryLicenseText matches are
+// the confidences which it returns.
+func (db *database) QueryAllScores(text string) map[string]float32 {
+	normalizedModerate := normalize.LicenseText(text, normalize.Moderate)
+	scores := map[string]float32{}
+	for key := range db.licenseTexts {
+		similarity := db.similarity(normalizedModerate, key)
+		if similarity < 0 {
+			similarity = 0
+		}
+		if license, exists := db.portions[key]; exists {
+			key = license
+		}
+		if scores[key] <= similarity {
+			scores[key] = similarity
+		}
+	}
+	for key, val := range db.QueryLicenseText(text) {
+		scores[key] = val
+	}
+	return scores
+}
+
+// queryLicenseText matches the text normalized without the skipped step, see
+// normalize.LicenseTextSkipping. Nothing is skipped if it is empty.
+func (db *database) queryLicenseText(text string, unordered bool, skipped string) map[string]float32 {
 	parts := normalize.Split(text)
 	licenses := map[string]float32{}
 	for _, part := range parts {
-		for key, val := range db.queryLicenseAbstract(part) {
+		for key, val := range db.queryLicenseAbstract(part, unordered, skipped) {
 			if licenses[key] < val {
 				licenses[key] = val
 			}
 		}
 	}
+	discriminateAttribution(text, licenses)
+	discriminateLaterVersions(text, licenses)
+	discriminateHistoricalNotice(text, licenses)
 	return licenses
 }
 
-func (db *database) queryLicenseAbstract(text string) map[string]float32 {
-	normalizedModerate := normalize.LicenseText(text, normalize.Moderate)
+// discriminateHistoricalNotice replaces the plain permissive licenses, e.g. MIT or 0BSD, with
+// HPND if they are the best match of the text which carries the HPND grant and advertising
+// clause. The historical notices of the old code, e.g. of MIT/X Consortium, vary the optional
+// sentences of HPND, so they are often closer to the shorter licenses by the fuzzy matching,
+// or match nothing at all, and then HPND is reported with hpndNoticeConfidence.
+func discriminateHistoricalNotice(text string, licenses map[string]float32) {
+	if len(licenses) == 0 {
+		if hpndGrantRe.MatchString(text) && hpndAdvertisingRe.MatchString(text) {
+			licenses["HPND"] = hpndNoticeConfidence
+		}
+		return
+	}
+	var bestKey string
+	var best float32
+	for key, val := range licenses {
+		if val > best || (val == best && key < bestKey) {
+			bestKey, best = key, val
+		}
+	}
+	if !hpndLookalikes[bestKey] || !hpndGrantRe.MatchString(text) ||
+		!hpndAdvertisingRe.MatchString(text) {
+		return
+	}
+	for key := range licenses {
+		if hpndLookalikes[key] {
+			delete(licenses, key)
+		}
+	}
+	if licenses["HPND"] < best {
+		licenses["HPND"] = best
+	}
+}
+
+// discriminateLaterVersions removes the "-or-later" and "+" licenses whose "-only" counterparts
+// are matched as well if the text explicitly refuses the later versions of the same version.
+// The reference texts of GPL-2.0-only and GPL-2.0-or-later are the same, so only such
+// a statement tells them, while e.g. "requires Python v3 only" is unrelated to GPL-2.0.
+func discriminateLaterVersions(text string, licenses map[string]float32) {
+	versions := map[string]bool{}
+	for _, groups := range onlyVersionRe.FindAllStringSubmatch(text, -1) {
+		for _, version := range groups[1:] {
+			if version != "" {
+				versions[normalizeVersion(version)] = true
+			}
+		}
+	}
+	if len(versions) == 0 {
+		return
+	}
+	for key := range licenses {
+		var base string
+		switch {
+		case strings.HasSuffix(key, "-or-later"):
+			base = strings.TrimSuffix(key, "-or-later")
+		case strings.HasSuffix(key, "+"):
+			base = strings.TrimSuffix(strings.TrimPrefix(key, "deprecated_"), "+")
+		default:
+			continue
+		}
+		if _, exists := licenses[base+"-only"]; !exists {
+			continue
+		}
+		if version := base[strings.LastIndex(base, "-")+1:]; versions[normalizeVersion(version)] {
+			delete(licenses, key)
+		}
+	}
+}
+
+// normalizeVersion appends the minor version if it is missing, so that "2" equals "2.0".
+func normalizeVersion(version string) string {
+	if !strings.Contains(version, ".") {
+		return version + ".0"
+	}
+	return version
+}
+
+// discriminateAttribution keeps either MIT or MIT-0 by the attribution clause, since their
+// texts are too similar to tell by the fuzzy matching alone, while MIT-0 materially differs
+// by not requiring the attribution.
+func discriminateAttribution(text string, licenses map[string]float32) {
+	_, mit := licenses["MIT"]
+	_, mit0 := licenses["MIT-0"]
+	if !mit || !mit0 {
+		return
+	}
+	if attributionClauseRe.MatchString(text) {
+		delete(licenses, "MIT-0")
+	} else {
+		delete(licenses, "MIT")
+	}
+}
+
+// Truncation describes the license text which is cut off, see QueryTruncatedLicenseText.
+type Truncation struct {
+	// Similarity is the similarity of the text to the beginning of the license text.
+	Similarity float32
+	// Completeness is the share of the license text which the text contains, from 0 to 1.
+	Completeness float32
+}
+
+// QueryTruncatedLicenseText returns the registered licenses whose beginning is similar to
+// the text while their end is missing from it, e.g. the LICENSE file copied halfway.
+func (db *database) QueryTruncatedLicenseText(text string) map[string]Truncation {
+	truncations := map[string]Truncation{}
+	dmp := diffmatchpatch.New()
+	for _, part := range normalize.Split(text) {
+		normalizedModerate := normalize.LicenseText(part, normalize.Moderate)
+		for _, key := range db.lookupTruncatedLicenses(normalizedModerate) {
+			myRunes, yourRunes, diff := db.diffLicenseText(normalizedModerate, db.licenseTexts[key])
+			if len(myRunes) == 0 || len(yourRunes) == 0 {
+				continue
+			}
+			// the trailing differences are the missing end of the license text and
+			// the garbage after the cut, e.g. the half of the last word
+			var missing, garbage int
+			for i := len(diff) - 1; i >= 0 && diff[i].Type != diffmatchpatch.DiffEqual; i-- {
+				if diff[i].Type == diffmatchpatch.DiffInsert {
+					missing += len([]rune(diff[i].Text))
+				} else {
+					garbage += len([]rune(diff[i].Text))
+				}
+			}
+			if missing <= garbage {
+				continue
+			}
+			distance := dmp.DiffLevenshtein(diff) - missing + garbage
+			truncation := Truncation{
+				Similarity:   float32(1) - float32(distance)/float32(len(myRunes)),
+				Completeness: float32(len(yourRunes)-missing) / float32(len(yourRunes)),
+			}
+			if truncation.Similarity < similarityThreshold ||
+				truncation.Completeness < minTruncatedCompleteness ||
+				truncation.Completeness > maxTruncatedCompleteness {
+				continue
+			}
+			if license, exists := db.portions[key]; exists {
+				key = license
+			}
+			if truncations[key].Similarity < truncation.Similarity {
+				truncations[key] = truncation
+			}
+		}
+	}
+	return truncations
+}
+
+// lookupTruncatedLicenses returns the candidates of QueryTruncatedLicenseText: the nearest
+// neighbors and the licenses whose text starts the same, since the LSH does not find
+// the licenses which lack e.g. the half of the text.
+func (db *database) lookupTruncatedLicenses(normalizedModerate string) []string {
+	keys := db.lookupLicenses(normalizedModerate)
+	if len(normalizedModerate) < truncatedPrefixLength {
+		return keys
+	}
+	found := map[string]bool{}
+	for _, key := range keys {
+		found[key] = true
+	}
+	prefix := normalizedModerate[:truncatedPrefixLength]
+	for key, text := range db.licenseTexts {
+		if !found[key] && strings.HasPrefix(text, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (db *database) queryLicenseAbstract(text string, unordered bool, skipped string) map[string]float32 {
+	normalizedModerate := normalize.LicenseTextSkipping(text, normalize.Moderate, skipped)
 	titlePositions := db.firstLineRe.FindAllStringIndex(normalizedModerate, -1)
-	candidates := db.queryLicenseAbstractNormalized(normalizedModerate)
+	candidates := db.queryLicenseAbstractNormalized(normalizedModerate, unordered)
+	db.ignorePreamble(candidates, text, unordered, skipped)
 	var prevPos int
 	var prevMatch string
 	for i, titlePos := range titlePositions {
@@ -291,7 +628,7 @@ func (db *database) queryLicenseAbstract(text string) map[string]float32 {
 		if float64(len(part)) < float64(db.minLicenseLength)*similarityThreshold {
 			continue
 		}
-		newCandidates := db.queryLicenseAbstractNormalized(part)
+		newCandidates := db.queryLicenseAbstractNormalized(part, unordered)
 		if len(newCandidates) == 0 {
 			continue
 		}
@@ -305,11 +642,44 @@ func (db *database) queryLicenseAbstract(text string) map[string]float32 {
 	return candidates
 }
 
+// ignorePreamble matches the text without its short leading paragraph if no license is matched
+// strongly, e.g. MIT is depressed by the preamble of the project above it. Only the strong
+// matches of the rest are kept. The paragraph must refer to the license, see preambleMarkerRe,
+// so that the other texts are not matched twice. The paragraphs are taken from the original
+// text since the normalization joins them.
+func (db *database) ignorePreamble(
+	candidates map[string]float32, text string, unordered bool, skipped string) {
+	for _, val := range candidates {
+		if val >= preambleSimilarityThreshold {
+			return
+		}
+	}
+	text = strings.TrimLeftFunc(text, unicode.IsSpace)
+	paragraphBreak := paragraphBreakRe.FindStringIndex(text)
+	if paragraphBreak == nil || strings.Count(text[:paragraphBreak[0]], "\n") >= maxPreambleLines ||
+		!preambleMarkerRe.MatchString(text[:paragraphBreak[0]]) {
+		return
+	}
+	rest := normalize.LicenseTextSkipping(text[paragraphBreak[1]:], normalize.Moderate, skipped)
+	if float64(len(rest)) < float64(db.minLicenseLength)*similarityThreshold {
+		return
+	}
+	for key, val := range db.queryLicenseAbstractNormalized(rest, unordered) {
+		if val >= preambleSimilarityThreshold && candidates[key] < val {
+			candidates[key] = val
+		}
+	}
+}
+
 func (db *database) addURLMatches(candidates map[string]float32, text string) {
-	for key := range db.scanForURLs(text) {
+	urlMatches := db.scanForURLs(text)
+	for key := range urlMatches {
 		if db.debug {
 			println("URL:", key)
 		}
+		if db.isReferencedByURL(candidates, key) {
+			continue
+		}
 		if conf := candidates[key]; conf < similarityThreshold {
 			if conf == 0 {
 				candidates[key] = 1
@@ -318,9 +688,67 @@ func (db *database) addURLMatches(candidates map[string]float32, text string) {
 			}
 		}
 	}
+	for key := range db.scanForWellKnownURLs(text) {
+		if urlMatches[key] || db.isReferencedByURL(candidates, key) {
+			continue
+		}
+		if db.debug {
+			println("well-known URL:", key)
+		}
+		if candidates[key] < wellKnownURLConfidence {
+			candidates[key] = wellKnownURLConfidence
+		}
+	}
+}
+
+// isReferencedByURL checks whether the URL of the license is a part of the text of
+// another matched license rather than the mention of the former.
+func (db *database) isReferencedByURL(candidates map[string]float32, key string) bool {
+	for other, conf := range candidates {
+		if conf >= similarityThreshold && db.urlReferences[other][key] {
+			return true
+		}
+	}
+	return false
+}
+
+func (db *database) queryLicenseAbstractNormalized(normalizedModerate string, unordered bool) map[string]float32 {
+	candidates := map[string]float32{}
+	for _, key := range db.lookupLicenses(normalizedModerate) {
+		myRunes, yourRunes, diff := db.diffLicenseText(normalizedModerate, db.licenseTexts[key])
+		distance := diffmatchpatch.New().DiffLevenshtein(diff)
+		candidates[key] = float32(1) - float32(distance)/float32(len(myRunes))
+		if unordered {
+			if sim := shingleSimilarity(myRunes, yourRunes); sim > candidates[key] {
+				candidates[key] = sim
+			}
+		}
+	}
+	for portion, key := range db.portions {
+		if val, exists := candidates[portion]; exists {
+			delete(candidates, portion)
+			if candidates[key] < val {
+				candidates[key] = val
+			}
+		}
+	}
+	weak := make([]string, 0, len(candidates))
+	for key, val := range candidates {
+		if val < similarityThreshold {
+			weak = append(weak, key)
+		}
+	}
+	if len(weak) < len(candidates) {
+		for _, key := range weak {
+			delete(candidates, key)
+		}
+	}
+	return candidates
 }
 
-func (db *database) queryLicenseAbstractNormalized(normalizedModerate string) map[string]float32 {
+// lookupLicenses returns the names of the licenses in the LSH hashtables which are close
+// to the normalized text. They include the parts of the licenses, see database.portions.
+func (db *database) lookupLicenses(normalizedModerate string) []string {
 	normalizedRelaxed := normalize.Relax(normalizedModerate)
 	if db.debug {
 		println("\nqueryAbstractNormed --------\n")
@@ -330,7 +758,7 @@ func (db *database) queryLicenseAbstractNormalized(normalizedModerate string) ma
 	}
 	tokens := map[int]int{}
 	for _, line := range strings.Split(normalizedRelaxed, "\n") {
-		for _, token := range strings.Split(line, " ") {
+		for _, token := range db.tokenizer.Tokenize(line) {
 			if index, exists := db.tokens[token]; exists {
 				tokens[index]++
 			}
@@ -347,64 +775,81 @@ func (db *database) queryLicenseAbstractNormalized(normalizedModerate string) ma
 		}
 	}
 	found := db.lsh.Query(db.hasher.Hash(values, indices))
-	candidates := map[string]float32{}
-	if len(found) == 0 {
-		return candidates
+	keys := make([]string, len(found))
+	for i, keyint := range found {
+		keys[i] = keyint.(string)
 	}
-	for _, keyint := range found {
-		key := keyint.(string)
-		licenseText := db.licenseTexts[key]
-		yourRunes := make([]rune, 0, len(licenseText)/6)
-		vocabulary := map[string]int{}
-		for _, line := range strings.Split(licenseText, "\n") {
-			for _, token := range strings.Split(line, " ") {
-				index, exists := vocabulary[token]
-				if !exists {
-					index = len(vocabulary)
-					vocabulary[token] = index
-				}
-				yourRunes = append(yourRunes, rune(index))
+	return keys
+}
+
+// diffLicenseText compares the tokens of the normalized text with the tokens of the normalized
+// license text. The tokens are mapped to runes, and the consecutive tokens which do not exist
+// in the license text are merged into a single rune.
+func (db *database) diffLicenseText(normalizedModerate, licenseText string) (
+	myRunes, yourRunes []rune, diff []diffmatchpatch.Diff) {
+	yourRunes = make([]rune, 0, len(licenseText)/6)
+	vocabulary := map[string]int{}
+	for _, line := range strings.Split(licenseText, "\n") {
+		for _, token := range db.tokenizer.Tokenize(line) {
+			index, exists := vocabulary[token]
+			if !exists {
+				index = len(vocabulary)
+				vocabulary[token] = index
 			}
+			yourRunes = append(yourRunes, rune(index))
 		}
+	}
 
-		oovRune := rune(len(vocabulary))
-		myRunes := make([]rune, 0, len(normalizedModerate)/6)
-		for _, line := range strings.Split(normalizedModerate, "\n") {
-			for _, token := range strings.Split(line, " ") {
-				if index, exists := vocabulary[token]; exists {
-					myRunes = append(myRunes, rune(index))
-				} else if len(myRunes) == 0 || myRunes[len(myRunes)-1] != oovRune {
-					myRunes = append(myRunes, oovRune)
-				}
+	oovRune := rune(len(vocabulary))
+	myRunes = make([]rune, 0, len(normalizedModerate)/6)
+	for _, line := range strings.Split(normalizedModerate, "\n") {
+		for _, token := range db.tokenizer.Tokenize(line) {
+			if index, exists := vocabulary[token]; exists {
+				myRunes = append(myRunes, rune(index))
+			} else if len(myRunes) == 0 || myRunes[len(myRunes)-1] != oovRune {
+				myRunes = append(myRunes, oovRune)
 			}
 		}
+	}
 
-		dmp := diffmatchpatch.New()
-		diff := dmp.DiffMainRunes(myRunes, yourRunes, false)
+	dmp := diffmatchpatch.New()
+	diff = dmp.DiffMainRunes(myRunes, yourRunes, false)
 
-		if db.debug {
-			tokarr := make([]string, len(db.tokens)+1)
-			for key, val := range vocabulary {
-				tokarr[val] = key
-			}
-			tokarr[len(db.tokens)] = "!"
-			println(dmp.DiffPrettyText(dmp.DiffCharsToLines(diff, tokarr)))
+	if db.debug {
+		tokarr := make([]string, len(db.tokens)+1)
+		for key, val := range vocabulary {
+			tokarr[val] = key
 		}
-		distance := dmp.DiffLevenshtein(diff)
-		candidates[key] = float32(1) - float32(distance)/float32(len(myRunes))
+		tokarr[len(db.tokens)] = "!"
+		println(dmp.DiffPrettyText(dmp.DiffCharsToLines(diff, tokarr)))
 	}
-	weak := make([]string, 0, len(candidates))
-	for key, val := range candidates {
-		if val < similarityThreshold {
-			weak = append(weak, key)
+	return myRunes, yourRunes, diff
+}
+
+// shingleSimilarity is the Jaccard similarity of the sets of the token n-grams, see
+// unorderedShingleSize. Unlike the edit distance, it barely changes if the paragraphs
+// are reordered: only the shingles which span the paragraph boundaries differ.
+func shingleSimilarity(myRunes, yourRunes []rune) float32 {
+	shingles := func(runes []rune) map[[unorderedShingleSize]rune]bool {
+		set := map[[unorderedShingleSize]rune]bool{}
+		for i := 0; i+unorderedShingleSize <= len(runes); i++ {
+			var shingle [unorderedShingleSize]rune
+			copy(shingle[:], runes[i:])
+			set[shingle] = true
 		}
+		return set
 	}
-	if len(weak) < len(candidates) {
-		for _, key := range weak {
-			delete(candidates, key)
+	mine, yours := shingles(myRunes), shingles(yourRunes)
+	if len(mine) == 0 || len(yours) == 0 {
+		return 0
+	}
+	common := 0
+	for shingle := range mine {
+		if yours[shingle] {
+			common++
 		}
 	}
-	return candidates
+	return float32(common) / float32(len(mine)+len(yours)-common)
 }
 
 func (db *database) scanForURLs(text string) map[string]bool {
@@ -419,8 +864,81 @@ func (db *database) scanForURLs(text string) map[string]bool {
 	return licenses
 }
 
+// LicenseKey returns the stable key of the license, see stableKey. The names which are not
+// in the database, e.g. the version families like "GPL", are keyed by the name itself.
+func (db *database) LicenseKey(name string) string {
+	if key, exists := db.licenseKeys[name]; exists {
+		return key
+	}
+	return stableKey("name", []byte(name))
+}
+
+// LookupLicense resolves the SPDX identifier case-insensitively to the license name, which
+// has the "deprecated_" prefix if SPDX deprecated the identifier, and returns its full name.
+func (db *database) LookupLicense(id string) (key string, name string, exists bool) {
+	key, exists = db.licenseIDs[strings.ToLower(strings.TrimPrefix(id, "deprecated_"))]
+	if !exists {
+		return "", "", false
+	}
+	return key, db.names[strings.TrimPrefix(key, "deprecated_")], true
+}
+
+// licenseTextVariant distinguishes the licenses which share the same reference text, e.g.
+// GPL-2.0-only and GPL-2.0-or-later, or MPL-2.0 and MPL-2.0-no-copyleft-exception.
+func licenseTextVariant(key string) string {
+	switch {
+	case strings.HasSuffix(key, "-or-later") || strings.HasSuffix(key, "+"):
+		return "text-or-later"
+	case strings.HasSuffix(key, "-no-copyleft-exception"):
+		return "text-no-copyleft-exception"
+	default:
+		return "text"
+	}
+}
+
+// stableKey formats the SHA-1 of the content as the name-based UUID (version 5). The kind
+// of the content is hashed first, so that the text and the name never collide.
+func stableKey(kind string, content []byte) string {
+	hash := sha1.New()
+	hash.Write([]byte(kind))
+	hash.Write([]byte{0})
+	hash.Write(content)
+	sum := hash.Sum(nil)
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// QueryLicenseURL returns the license which is referenced by the URL, e.g.
+// https://www.apache.org/licenses/LICENSE-2.0. The scheme is ignored.
+func (db *database) QueryLicenseURL(url string) map[string]float32 {
+	url = strings.TrimSpace(url)
+	if pos := strings.Index(url, "://"); pos >= 0 {
+		url = url[pos:]
+	} else {
+		url = "://" + url
+	}
+	trimmed := strings.TrimRight(url, "/")
+	for _, variant := range []string{url, trimmed, trimmed + "/"} {
+		if key, exists := db.urls[variant]; exists {
+			return map[string]float32{key: 1}
+		}
+	}
+	candidates := map[string]float32{}
+	for key := range db.scanForURLs(url) {
+		candidates[key] = 1
+	}
+	return candidates
+}
+
 // QueryReadmeText tries to detect licenses mentioned in the README.
 func (db *database) QueryReadmeText(text string, fs filer.Filer) map[string]float32 {
+	return db.QueryReadmeTextWithOptions(text, fs, ReadmeOptions{})
+}
+
+// QueryReadmeTextWithOptions is the same as QueryReadmeText but tuned by the options.
+func (db *database) QueryReadmeTextWithOptions(
+	text string, fs filer.Filer, options ReadmeOptions) map[string]float32 {
 	candidates := map[string]float32{}
 	append := func(others map[string]float32) {
 		for key, val := range others {
@@ -439,9 +957,13 @@ func (db *database) QueryReadmeText(text string, fs filer.Filer) map[string]floa
 			append(db.QueryLicenseText(string(content)))
 		}
 	}
+	for _, match := range licenseFieldRe.FindAllStringSubmatch(text, -1) {
+		append(db.QueryLicenseName(match[1]))
+	}
 	if len(candidates) == 0 {
-		append(investigateReadmeFile(text, db.nameSubstrings, db.nameSubstringSizes))
-		append(investigateReadmeFile(text, db.nameShortSubstrings, db.nameShortSubstringSizes))
+		append(db.investigateReadmeFile(text, db.nameSubstrings, db.nameSubstringSizes, options))
+		append(db.investigateReadmeFile(
+			text, db.nameShortSubstrings, db.nameShortSubstringSizes, options))
 	}
 	if db.debug {
 		for key, val := range candidates {
@@ -452,6 +974,46 @@ func (db *database) QueryReadmeText(text string, fs filer.Filer) map[string]floa
 	return candidates
 }
 
+// QueryLicenseName resolves the declared license name or SPDX identifier, e.g. taken from
+// the package metadata, to the registered licenses. SPDX identifiers are matched exactly with
+// the confidence 1, the names are matched by their substrings and only the best candidates
+// are returned.
+func (db *database) QueryLicenseName(name string) map[string]float32 {
+	name = strings.TrimSpace(name)
+	// "The MIT License" must not match "The Unlicense" by "the"
+	name = leadingArticleRe.ReplaceAllString(name, "")
+	if key, exists := db.licenseIDs[strings.ToLower(name)]; exists {
+		return map[string]float32{key: 1}
+	}
+	if match := cecillNameRe.FindStringSubmatch(name); match != nil {
+		if key := "CECILL-" + strings.ToUpper(match[1]); db.names[key] != "" {
+			return map[string]float32{key: 1}
+		}
+	}
+	scores := scoreLicenseName(name, db.nameSubstrings, db.nameSubstringSizes)
+	for key, val := range scoreLicenseName(name, db.nameShortSubstrings, db.nameShortSubstringSizes) {
+		if scores[key] < val {
+			scores[key] = val
+		}
+	}
+	var best float32
+	for _, val := range scores {
+		if val > best {
+			best = val
+		}
+	}
+	candidates := map[string]float32{}
+	if best < 0.5 {
+		return candidates
+	}
+	for key, val := range scores {
+		if val == best {
+			candidates[key] = val
+		}
+	}
+	return candidates
+}
+
 func tfidf(freq int, docfreq int, ndocs int) float32 {
 	weight := fastlog.Log(1+float32(freq)) * fastlog.Log(float32(ndocs)/float32(docfreq))
 	if weight < 0 {
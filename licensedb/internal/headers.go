@@ -0,0 +1,362 @@
+package internal
+
+import (
+	"bytes"
+	paths "path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal/normalize"
+)
+
+// headerWindowSize is the number of leading bytes of each source file which are scanned
+// for the license header comments. E.g. the standard Qt header takes about 2KB, and it may
+// follow a generated banner or a pragma.
+const headerWindowSize = 4096
+
+const (
+	// minifiedLineLength is the line length which indicates the minified code, e.g.
+	// jquery.min.js, provided that the line has few spaces, unlike the unwrapped prose.
+	minifiedLineLength = 500
+	// minifiedSpaceRatio is the maximum fraction of spaces in the minified line.
+	minifiedSpaceRatio = 0.05
+	// binaryControlRatio is the fraction of the control characters which indicates binary data.
+	binaryControlRatio = 0.1
+)
+
+var (
+	// file extension -> programming language
+	sourceLanguages = map[string]string{
+		".c":      "C",
+		".h":      "C",
+		".cc":     "C++",
+		".cpp":    "C++",
+		".cxx":    "C++",
+		".hpp":    "C++",
+		".hh":     "C++",
+		".cs":     "C#",
+		".m":      "Objective-C",
+		".mm":     "Objective-C",
+		".go":     "Go",
+		".java":   "Java",
+		".kt":     "Kotlin",
+		".scala":  "Scala",
+		".groovy": "Groovy",
+		".js":     "JavaScript",
+		".jsx":    "JavaScript",
+		".mjs":    "JavaScript",
+		".ts":     "TypeScript",
+		".tsx":    "TypeScript",
+		".swift":  "Swift",
+		".rs":     "Rust",
+		".dart":   "Dart",
+		".php":    "PHP",
+		".css":    "CSS",
+		".scss":   "SCSS",
+		".py":     "Python",
+		".rb":     "Ruby",
+		".pl":     "Perl",
+		".pm":     "Perl",
+		".sh":     "Shell",
+		".bash":   "Shell",
+		".r":      "R",
+		".jl":     "Julia",
+		".ex":     "Elixir",
+		".exs":    "Elixir",
+		".cmake":  "CMake",
+		".lua":    "Lua",
+		".sql":    "SQL",
+		".hs":     "Haskell",
+		".el":     "Emacs Lisp",
+		".clj":    "Clojure",
+		".lisp":   "Common Lisp",
+		".erl":    "Erlang",
+		".tex":    "TeX",
+		".sty":    "TeX",
+		".cls":    "TeX",
+		".dtx":    "TeX",
+		".ins":    "TeX",
+		".ltx":    "TeX",
+		".f90":    "Fortran",
+		".vb":     "Visual Basic",
+		".html":   "HTML",
+		".xml":    "XML",
+	}
+
+	cStyleComments   = newCommentSyntax([]string{"//"}, [][2]string{{"/*", "*/"}})
+	hashComments     = newCommentSyntax([]string{"#"}, nil)
+	markupComments   = newCommentSyntax(nil, [][2]string{{"<!--", "-->"}})
+	percentComments  = newCommentSyntax([]string{"%"}, nil)
+	semicolonComment = newCommentSyntax([]string{";"}, nil)
+
+	// programming language -> comment syntax
+	languageComments = map[string]*commentSyntax{
+		"C":            cStyleComments,
+		"C++":          cStyleComments,
+		"C#":           cStyleComments,
+		"Objective-C":  cStyleComments,
+		"Go":           cStyleComments,
+		"Java":         cStyleComments,
+		"Kotlin":       cStyleComments,
+		"Scala":        cStyleComments,
+		"Groovy":       cStyleComments,
+		"JavaScript":   cStyleComments,
+		"TypeScript":   cStyleComments,
+		"Swift":        cStyleComments,
+		"Rust":         cStyleComments,
+		"Dart":         cStyleComments,
+		"PHP":          newCommentSyntax([]string{"//", "#"}, [][2]string{{"/*", "*/"}}),
+		"CSS":          newCommentSyntax(nil, [][2]string{{"/*", "*/"}}),
+		"SCSS":         cStyleComments,
+		"Python":       hashComments,
+		"Ruby":         newCommentSyntax([]string{"#"}, [][2]string{{"=begin", "=end"}}),
+		"Perl":         hashComments,
+		"Shell":        hashComments,
+		"R":            hashComments,
+		"Julia":        hashComments,
+		"Elixir":       hashComments,
+		"CMake":        hashComments,
+		"Lua":          newCommentSyntax([]string{"--"}, [][2]string{{"--[[", "]]"}}),
+		"SQL":          newCommentSyntax([]string{"--"}, [][2]string{{"/*", "*/"}}),
+		"Haskell":      newCommentSyntax([]string{"--"}, [][2]string{{"{-", "-}"}}),
+		"Emacs Lisp":   semicolonComment,
+		"Clojure":      semicolonComment,
+		"Common Lisp":  newCommentSyntax([]string{";"}, [][2]string{{"#|", "|#"}}),
+		"Erlang":       percentComments,
+		"TeX":          percentComments,
+		"Fortran":      newCommentSyntax([]string{"!"}, nil),
+		"Visual Basic": newCommentSyntax([]string{"'"}, nil),
+		"HTML":         markupComments,
+		"XML":          markupComments,
+	}
+
+	// decorations which prefix the lines of block comments, e.g. " * "
+	blockCommentDecorationRe = regexp.MustCompile("(?m)^[ \\t]*[*#!;%-]*[ \\t]?")
+	shebangRe                = regexp.MustCompile("^#![^\\n]*\\n")
+	// the first line of the Swift package manifest, Package.swift, which looks like a comment
+	swiftToolsVersionRe = regexp.MustCompile("^//[ \\t]*swift-tools-version[^\\n]*\\n")
+	// the words which the license headers are made of, see licenseCommentBlock
+	licenseTermRe = regexp.MustCompile("(?i)licen[cs]|copyright|copyleft|\\bpermi|warrant|" +
+		"redistribut|liabl|\\bspdx-")
+)
+
+// commentSyntax finds the comments in the source code of a particular programming language.
+type commentSyntax struct {
+	re *regexp.Regexp
+	// regexp group index -> comment delimiter; group 0 is the whole match
+	delimiters map[int][2]string
+}
+
+func newCommentSyntax(line []string, block [][2]string) *commentSyntax {
+	syntax := &commentSyntax{delimiters: map[int][2]string{}}
+	var alternatives []string
+	for _, delims := range block {
+		// unterminated comments span till the end of the window
+		alternatives = append(alternatives, "("+regexp.QuoteMeta(delims[0])+"(?s:.*?)(?:"+
+			regexp.QuoteMeta(delims[1])+"|$))")
+		syntax.delimiters[len(alternatives)] = delims
+	}
+	for _, prefix := range line {
+		alternatives = append(alternatives, "("+regexp.QuoteMeta(prefix)+"[^\\n]*)")
+		syntax.delimiters[len(alternatives)] = [2]string{prefix, ""}
+	}
+	syntax.re = regexp.MustCompile(strings.Join(alternatives, "|"))
+	return syntax
+}
+
+// ExtractBlocks returns the text of each comment block in the code, without the delimiters.
+// The line comments on the adjacent lines make a single block.
+func (syntax *commentSyntax) ExtractBlocks(code []byte) [][]byte {
+	var blocks [][]byte
+	result := &bytes.Buffer{}
+	prevLine := false
+	prevEnd := 0
+	for _, match := range syntax.re.FindAllSubmatchIndex(code, -1) {
+		for group, delims := range syntax.delimiters {
+			beg, end := match[2*group], match[2*group+1]
+			if beg < 0 {
+				continue
+			}
+			comment := code[beg+len(delims[0]) : end]
+			isLine := delims[1] == ""
+			if !isLine {
+				comment = bytes.TrimSuffix(comment, []byte(delims[1]))
+				comment = blockCommentDecorationRe.ReplaceAll(comment, nil)
+			} else {
+				comment = bytes.TrimLeft(comment, delims[0][:1])
+				comment = bytes.TrimPrefix(comment, []byte(" "))
+			}
+			gap := code[prevEnd:beg]
+			adjacent := bytes.Count(gap, []byte{'\n'}) <= 1 && len(bytes.TrimSpace(gap)) == 0
+			if result.Len() > 0 && !(isLine && prevLine && adjacent) {
+				blocks = append(blocks, append([]byte(nil), result.Bytes()...))
+				result.Reset()
+			}
+			result.Write(bytes.TrimRight(comment, " \t\r\n"))
+			result.WriteRune('\n')
+			prevLine = isLine
+			prevEnd = end
+		}
+	}
+	if result.Len() > 0 {
+		blocks = append(blocks, result.Bytes())
+	}
+	return blocks
+}
+
+// licenseCommentBlock returns the comment block which mentions the license terms most often,
+// e.g. the license header after the generated banner. If none mentions them, all the blocks
+// are joined and separated with an empty line.
+func licenseCommentBlock(blocks [][]byte) []byte {
+	var best []byte
+	bestScore := 0
+	for _, block := range blocks {
+		if score := len(licenseTermRe.FindAllIndex(block, -1)); score > bestScore {
+			best = block
+			bestScore = score
+		}
+	}
+	if best == nil {
+		return bytes.Join(blocks, []byte{'\n'})
+	}
+	return best
+}
+
+// SourceLanguage returns the programming language of the source file by its extension.
+// It returns an empty string if the language is unknown.
+func SourceLanguage(file string) string {
+	return sourceLanguages[strings.ToLower(paths.Ext(file))]
+}
+
+// ExtractSourceFiles reads the leading bytes of the source files, where the license header
+// is likely to be, and returns them mapped from the file paths. The files in unknown
+// programming languages, the minified and the binary files are ignored.
+func ExtractSourceFiles(files []string, fs filer.Filer) map[string][]byte {
+	candidates := map[string][]byte{}
+	for _, file := range files {
+		if SourceLanguage(file) == "" {
+			continue
+		}
+		text, err := filer.ReadFilePrefix(fs, file, headerWindowSize)
+		if err != nil {
+			continue
+		}
+		if isMinifiedOrBinary(text) {
+			continue
+		}
+		candidates[file] = text
+	}
+	return candidates
+}
+
+// isMinifiedOrBinary indicates whether the leading bytes of the source file belong to
+// the minified code or to a binary blob with a source extension. Such files produce garbage
+// instead of the header comments.
+func isMinifiedOrBinary(text []byte) bool {
+	if len(text) == 0 {
+		return false
+	}
+	if bytes.IndexByte(text, 0) >= 0 {
+		return true
+	}
+	for _, line := range bytes.Split(text, []byte{'\n'}) {
+		if len(line) > minifiedLineLength &&
+			float64(bytes.Count(line, []byte{' '})) < float64(len(line))*minifiedSpaceRatio {
+			return true
+		}
+	}
+	control := 0
+	for _, c := range text {
+		if (c < ' ' && c != '\n' && c != '\r' && c != '\t' && c != '\f') || c == 0x7f {
+			control++
+		}
+	}
+	return float64(control) > float64(len(text))*binaryControlRatio
+}
+
+// ExtractHeaderComments takes the leading bytes of the source files produced by
+// ExtractSourceFiles and returns the comment block of each which mentions the license terms
+// most often, see licenseCommentBlock, mapped from the file paths. The files without comments
+// are not included.
+func ExtractHeaderComments(sources map[string][]byte) map[string][]byte {
+	return extractHeaderComments(sources, licenseCommentBlock)
+}
+
+// ExtractFirstHeaderComments is the same as ExtractHeaderComments but takes the first comment
+// block of each file, e.g. the license banner followed by the doc comments, and never joins
+// the blocks.
+func ExtractFirstHeaderComments(sources map[string][]byte) map[string][]byte {
+	return extractHeaderComments(sources, firstCommentBlock)
+}
+
+// extractHeaderComments extracts the comment blocks of each source file and picks the header
+// among them.
+func extractHeaderComments(sources map[string][]byte, pick func(blocks [][]byte) []byte) map[string][]byte {
+	comments := map[string][]byte{}
+	for file, code := range sources {
+		syntax := languageComments[SourceLanguage(file)]
+		if syntax == nil {
+			continue
+		}
+		code = shebangRe.ReplaceAll(code, nil)
+		code = swiftToolsVersionRe.ReplaceAll(code, nil)
+		if text := pick(syntax.ExtractBlocks(code)); len(bytes.TrimSpace(text)) > 0 {
+			comments[file] = text
+		}
+	}
+	return comments
+}
+
+// firstCommentBlock returns the first comment block which is not blank.
+func firstCommentBlock(blocks [][]byte) []byte {
+	for _, block := range blocks {
+		if len(bytes.TrimSpace(block)) > 0 {
+			return block
+		}
+	}
+	return nil
+}
+
+// HeaderBanner is the header comment which is shared by one or more source files.
+// Projects usually repeat the same license banner in every file, so each banner must
+// be investigated and counted once.
+type HeaderBanner struct {
+	// Text is the header comment of the first file.
+	Text []byte
+	// Files are the sorted paths to all the files with this banner.
+	Files []string
+}
+
+// HeaderBannerKey returns the normalized header comment which is the same for the identical
+// banners, e.g. regardless of the copyright years.
+func HeaderBannerKey(comment []byte) string {
+	return normalize.LicenseText(string(comment), normalize.Moderate)
+}
+
+// GroupHeaderComments merges the identical header comments returned by ExtractHeaderComments.
+// The comments are compared after the normalization, so that e.g. different copyright
+// years do not matter. The banners are sorted by the number of files in descending order.
+func GroupHeaderComments(comments map[string][]byte) []HeaderBanner {
+	files := make([]string, 0, len(comments))
+	for file := range comments {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	index := map[string]int{}
+	var banners []HeaderBanner
+	for _, file := range files {
+		key := HeaderBannerKey(comments[file])
+		if i, exists := index[key]; exists {
+			banners[i].Files = append(banners[i].Files, file)
+			continue
+		}
+		index[key] = len(banners)
+		banners = append(banners, HeaderBanner{Text: comments[file], Files: []string{file}})
+	}
+	sort.SliceStable(banners, func(i, j int) bool {
+		return len(banners[i].Files) > len(banners[j].Files)
+	})
+	return banners
+}
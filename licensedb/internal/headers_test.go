@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractHeaderComments(t *testing.T) {
+	comments := ExtractHeaderComments(map[string][]byte{
+		"main.go": []byte(`/*
+ * Copyright 2018 Foo.
+ *
+ * Licensed under the MIT license.
+ */
+
+// Package main does nothing.
+// Really.
+package main
+`),
+		"run.py": []byte(`#!/usr/bin/env python
+# Licensed under the MIT license.
+import sys  # inline
+`),
+		"style.css":  []byte("body {}"),
+		"data.bin":   []byte("// not a source file"),
+		"index.html": []byte("<!-- Licensed\n under MIT --><html></html>"),
+		"gen.go": []byte(`// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: foo.proto
+
+// Copyright 2018 Foo.
+// Use of this source code is governed by the MIT license.
+
+package foo
+`),
+		"doc.go": []byte("// Package doc does nothing.\n\n/* Really. */\npackage doc\n"),
+	})
+	assert.Equal(t, map[string][]byte{
+		"main.go": []byte(`
+Copyright 2018 Foo.
+
+Licensed under the MIT license.
+`),
+		"run.py":     []byte("Licensed under the MIT license.\n"),
+		"index.html": []byte("Licensed\nunder MIT\n"),
+		// the license header follows the generated banner
+		"gen.go": []byte("Copyright 2018 Foo.\nUse of this source code is governed by the MIT license.\n"),
+		// no block mentions the license
+		"doc.go": []byte("Package doc does nothing.\n\nReally.\n"),
+	}, comments)
+}
+
+func TestExtractFirstHeaderComments(t *testing.T) {
+	const key = `// Copyright 2018 Foo.
+// Licensed under the MIT license.
+
+// Package key derives the license keys. The key of each license in the copyright
+// notices is stable, so that the licenses which are redistributed under the other
+// license names keep the same key, see the license list.
+package key
+`
+	comments := ExtractFirstHeaderComments(map[string][]byte{
+		"key.go": []byte(key),
+		"doc.go": []byte("\n\n// Package doc does nothing.\n\n/* Really. */\npackage doc\n"),
+	})
+	assert.Equal(t, map[string][]byte{
+		"key.go": []byte("Copyright 2018 Foo.\nLicensed under the MIT license.\n"),
+		"doc.go": []byte("Package doc does nothing.\n"),
+	}, comments)
+	// the doc comment mentions the license terms more often
+	comments = ExtractHeaderComments(map[string][]byte{"key.go": []byte(key)})
+	assert.Contains(t, string(comments["key.go"]), "Package key")
+}
+
+func TestGroupHeaderComments(t *testing.T) {
+	banners := GroupHeaderComments(map[string][]byte{
+		"a.go": []byte("Copyright 2017 Foo.\nLicensed under the MIT license.\n"),
+		"b.go": []byte("Copyright 2018 Foo.\nLicensed under the MIT license.\n"),
+		"c.go": []byte("Licensed under the MIT license.\n"),
+		"d.go": []byte("Helper functions.\n"),
+	})
+	assert.Len(t, banners, 2)
+	assert.Equal(t, []string{"a.go", "b.go", "c.go"}, banners[0].Files)
+	assert.Equal(t, "Copyright 2017 Foo.\nLicensed under the MIT license.\n", string(banners[0].Text))
+	assert.Equal(t, []string{"d.go"}, banners[1].Files)
+}
+
+func TestIsMinifiedOrBinary(t *testing.T) {
+	code := []byte("// Licensed under the MIT license.\npackage main\n\nfunc main() {}\n")
+	assert.False(t, isMinifiedOrBinary(code))
+	prose := bytes.Repeat([]byte("permission is hereby granted "), 30)
+	assert.False(t, isMinifiedOrBinary(append([]byte("// "), prose...)))
+	minified := append([]byte("/*! foo v1.0 | MIT */\n"),
+		bytes.Repeat([]byte("!function(e,t){return e.x=t,e};"), 30)...)
+	assert.True(t, isMinifiedOrBinary(minified))
+	assert.True(t, isMinifiedOrBinary([]byte("\x7fELF\x02\x01\x01\x00\x00\x00")))
+	assert.True(t, isMinifiedOrBinary(bytes.Repeat([]byte("ab\x01\x02\x03"), 20)))
+}
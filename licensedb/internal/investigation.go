@@ -2,13 +2,22 @@ package internal
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	paths "path"
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
 
 	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal/assets"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal/normalize"
 	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal/processors"
 )
 
@@ -19,7 +28,7 @@ var (
 	}
 	globalLicenseDatabase = func() *database {
 		globalLicenseDB.Once.Do(func() {
-			globalLicenseDB.database = loadLicenses()
+			globalLicenseDB.database = loadLicenses(WhitespaceTokenizer)
 		})
 		return globalLicenseDB.database
 	}
@@ -57,46 +66,182 @@ var (
 		fmt.Sprintf("^(|.*[-_. ])(%s)(|[-_. ].*)$",
 			strings.Join(licenseFileNames, "|")))
 
-	readmeFileRe = regexp.MustCompile(fmt.Sprintf("^(readme|guidelines)(%s)$",
+	// humans.txt and .well-known/license are the web conventions to credit the authors
+	readmeFileRe = regexp.MustCompile(fmt.Sprintf("^(readme|guidelines|humans|\\.well-known/li[cs]en[cs]e)(%s)$",
+		strings.Replace(strings.Join(fileExtensions, "|"), ".", "\\.", -1)))
+
+	// CREDITS, CREDITS.md; some projects fold the license into the credits of the authors
+	creditsFileRe = regexp.MustCompile(fmt.Sprintf("^credits(%s)$",
+		strings.Replace(strings.Join(fileExtensions, "|"), ".", "\\.", -1)))
+
+	// LICENSE.commercial, COMMERCIAL-LICENSE.md, LICENSE_PROPRIETARY.txt
+	commercialLicenseFileRe = regexp.MustCompile(fmt.Sprintf(
+		"^(li[cs]en[cs]e[-_. ](commercial|proprietary)|(commercial|proprietary)[-_. ]li[cs]en[cs]e)(%s)$",
 		strings.Replace(strings.Join(fileExtensions, "|"), ".", "\\.", -1)))
 
 	licenseDirectoryRe = regexp.MustCompile(fmt.Sprintf(
 		"^(%s)$", strings.Join(licenseFileNames, "|")))
+
+	// PATENTS of Facebook, ADDITIONAL_GRANT and PATENT_GRANT of Google and others
+	patentsFileRe = regexp.MustCompile(fmt.Sprintf("^(patents|additional[-_ ]grant|patent[-_ ]grant)(%s)$",
+		strings.Replace(strings.Join(fileExtensions, "|"), ".", "\\.", -1)))
+	// NOTICE, NOTICE.txt of the Apache License
+	noticeFileRe = regexp.MustCompile(fmt.Sprintf("^notice(%s)$",
+		strings.Replace(strings.Join(fileExtensions, "|"), ".", "\\.", -1)))
+	base64Re      = regexp.MustCompile("^[A-Za-z0-9+/\\r\\n]+={0,2}$")
+	patentGrantRe = regexp.MustCompile("(?i)grant\\s+of\\s+patent|patent\\s+(rights|license)")
 )
 
-// ExtractLicenseFiles returns the list of possible license texts.
+// ErrDefaultDatabaseDisabled is returned instead of the default databases if they are
+// disabled, see DisableDefaultDatabase.
+var ErrDefaultDatabaseDisabled = errors.New(
+	"the default license database is disabled, set Options.Database")
+
+// defaultDatabaseDisabled is 1 if the default databases must not be used, see
+// DisableDefaultDatabase.
+var defaultDatabaseDisabled int32
+
+// DisableDefaultDatabase forbids or allows again the use of the shared databases returned by
+// DefaultDatabase, e.g. to control the memory of the server which loads its own databases.
+// The already loaded default databases are not freed.
+func DisableDefaultDatabase(disabled bool) {
+	var value int32
+	if disabled {
+		value = 1
+	}
+	atomic.StoreInt32(&defaultDatabaseDisabled, value)
+}
+
+// IsDefaultDatabaseDisabled indicates whether the default databases are disabled,
+// see DisableDefaultDatabase.
+func IsDefaultDatabaseDisabled() bool {
+	return atomic.LoadInt32(&defaultDatabaseDisabled) != 0
+}
+
+// defaultLicenseDatabase returns the global database, or ErrDefaultDatabaseDisabled if
+// the default databases are disabled.
+func defaultLicenseDatabase() (*database, error) {
+	if IsDefaultDatabaseDisabled() {
+		return nil, ErrDefaultDatabaseDisabled
+	}
+	return globalLicenseDatabase(), nil
+}
+
+// ExtractLicenseFiles returns the list of possible license texts mapped from the file paths.
 // The file names are matched against the template.
 // Reader is used to to read file contents.
-func ExtractLicenseFiles(files []string, fs filer.Filer) [][]byte {
-	candidates := [][]byte{}
+func ExtractLicenseFiles(files []string, fs filer.Filer) map[string][]byte {
+	candidates := map[string][]byte{}
 	for _, file := range files {
-		if licenseFileRe.MatchString(strings.ToLower(paths.Base(file))) {
+		if licenseFileRe.MatchString(strings.ToLower(paths.Base(file))) && !IsThirdPartyNoticesFile(file) {
 			text, err := fs.ReadFile(file)
 			if len(text) < 128 {
 				// e.g. https://github.com/Unitech/pm2/blob/master/LICENSE
-				realText, err := fs.ReadFile(string(bytes.TrimSpace(text)))
-				if err == nil {
-					file = string(bytes.TrimSpace(text))
+				// or the symlink checked out as a plain file, e.g. LICENSE -> LICENSES/MIT.txt
+				if target, realText := readLicenseLink(file, text, fs); realText != nil {
+					file = target
 					text = realText
 				}
 			}
 			if err == nil {
+				text = decodeBase64Text(text)
 				if preprocessor, exists := filePreprocessors[paths.Ext(file)]; exists {
 					text = preprocessor(text)
 				}
-				candidates = append(candidates, text)
+				candidates[file] = text
 			}
 		}
 	}
 	return candidates
 }
 
+// readLicenseLink returns the path and the contents of the file which the license file refers to
+// if its whole text is the path. The path is relative to the directory of the license file,
+// like the symlinks, or to the root.
+func readLicenseLink(file string, text []byte, fs filer.Filer) (string, []byte) {
+	target := string(bytes.TrimSpace(text))
+	if target == "" || strings.ContainsAny(target, "\n\x00") {
+		return "", nil
+	}
+	for _, path := range []string{paths.Join(paths.Dir(file), target), target} {
+		if path == file || strings.HasPrefix(path, "../") {
+			continue
+		}
+		if realText, err := fs.ReadFile(path); err == nil {
+			return path, realText
+		}
+	}
+	return "", nil
+}
+
+// REUSELicenseID returns the SPDX license identifier which names the file in the LICENSES
+// directory of the REUSE layout, e.g. "MIT" for LICENSES/MIT.txt. It returns an empty string
+// if the file is elsewhere or its name is not a known license.
+func REUSELicenseID(file string, db *Database) string {
+	if paths.Base(paths.Dir(file)) != "LICENSES" {
+		return ""
+	}
+	name := paths.Base(file)
+	name = strings.TrimSuffix(name, paths.Ext(name))
+	return db.licenseIDs[strings.ToLower(name)]
+}
+
+// Commercial is the pseudo license of the proprietary license file of the project which is
+// dual licensed, see IsCommercialLicenseFile. The terms are custom, so they are not matched.
+const Commercial = "commercial"
+
+// IsCommercialLicenseFile indicates whether the file name marks the commercial option of
+// the dual licensing, e.g. LICENSE.commercial next to LICENSE.opensource.
+func IsCommercialLicenseFile(fileName string) bool {
+	return commercialLicenseFileRe.MatchString(strings.ToLower(paths.Base(fileName)))
+}
+
+// decodeBase64Text decodes the text if it looks like base64 and the decoded result is readable.
+// Otherwise, the text is returned as is. Some automated tools commit encoded license files.
+func decodeBase64Text(text []byte) []byte {
+	trimmed := bytes.TrimSpace(text)
+	if len(trimmed) < 64 || !base64Re.Match(trimmed) {
+		return text
+	}
+	encoded := bytes.Join(bytes.Fields(trimmed), nil)
+	decoded := make([]byte, base64.StdEncoding.DecodedLen(len(encoded)))
+	size, err := base64.StdEncoding.Decode(decoded, encoded)
+	if err != nil {
+		return text
+	}
+	decoded = decoded[:size]
+	if !isReadableText(decoded) {
+		return text
+	}
+	return decoded
+}
+
+// isReadableText indicates whether the bytes are valid UTF-8 text which consists of printable
+// characters and has several words.
+func isReadableText(text []byte) bool {
+	if !utf8.Valid(text) || bytes.Count(text, []byte(" ")) < 8 {
+		return false
+	}
+	printable := 0
+	total := 0
+	for _, char := range string(text) {
+		total++
+		if unicode.IsPrint(char) || unicode.IsSpace(char) {
+			printable++
+		}
+	}
+	return float32(printable) >= 0.95*float32(total)
+}
+
 // InvestigateLicenseTexts takes the list of candidate license texts and returns the most probable
 // reference licenses matched. Each match has the confidence assigned, from 0 to 1, 1 means 100% confident.
-func InvestigateLicenseTexts(texts [][]byte) map[string]float32 {
+func InvestigateLicenseTexts(texts map[string][]byte) (map[string]float32, error) {
 	maxLicenses := map[string]float32{}
 	for _, text := range texts {
-		candidates := InvestigateLicenseText(text)
+		candidates, err := InvestigateLicenseText(text)
+		if err != nil {
+			return nil, err
+		}
 		for name, sim := range candidates {
 			maxSim := maxLicenses[name]
 			if sim > maxSim {
@@ -104,39 +249,125 @@ func InvestigateLicenseTexts(texts [][]byte) map[string]float32 {
 			}
 		}
 	}
-	return maxLicenses
+	return maxLicenses, nil
 }
 
 // InvestigateLicenseText takes the license text and returns the most probable reference licenses matched.
 // Each match has the confidence assigned, from 0 to 1, 1 means 100% confident.
-func InvestigateLicenseText(text []byte) map[string]float32 {
-	return globalLicenseDatabase().QueryLicenseText(string(text))
+func InvestigateLicenseText(text []byte) (map[string]float32, error) {
+	db, err := defaultLicenseDatabase()
+	if err != nil {
+		return nil, err
+	}
+	return db.QueryLicenseText(string(text)), nil
+}
+
+// InvestigateLicenseTextUnordered is the same as InvestigateLicenseText but tolerates
+// the reordered paragraphs and clauses, e.g. the warranty disclaimer moved to the top.
+func InvestigateLicenseTextUnordered(text []byte) (map[string]float32, error) {
+	db, err := defaultLicenseDatabase()
+	if err != nil {
+		return nil, err
+	}
+	return db.QueryLicenseTextUnordered(string(text)), nil
+}
+
+// InvestigateLicenseTextTokenized is the same as InvestigateLicenseText, or
+// InvestigateLicenseTextUnordered if `unordered` is true, but matches against the database,
+// e.g. the one which compares the tokens split by CJKTokenizer.
+func InvestigateLicenseTextTokenized(text []byte, db *Database, unordered bool) map[string]float32 {
+	return db.queryLicenseText(string(text), unordered, "")
+}
+
+// InvestigateTruncatedLicenseText returns the licenses which the text is a cut-off copy of,
+// see Truncation. The texts are compared like in InvestigateLicenseTextTokenized.
+func InvestigateTruncatedLicenseText(text []byte, db *Database) map[string]Truncation {
+	return db.QueryTruncatedLicenseText(string(text))
+}
+
+// NormalizationSteps are the names of the optional normalization steps, see ExplainNormalization.
+var NormalizationSteps = normalize.Steps
+
+// ExplainNormalization investigates the text like InvestigateLicenseTextTokenized once per
+// each step in normalize.Steps which is skipped. It returns the matched licenses by the step.
+func ExplainNormalization(text []byte, db *Database, unordered bool) map[string]map[string]float32 {
+	explanation := map[string]map[string]float32{}
+	for _, step := range normalize.Steps {
+		explanation[step] = db.queryLicenseText(string(text), unordered, step)
+	}
+	return explanation
 }
 
-// ExtractReadmeFiles searches for README files.
+// CanonicalLicenseText returns the text normalized like the license texts before they are
+// compared, that is, lower case and without the copyright lines, with all the whitespace
+// including the line breaks folded into single spaces, since the comparison is by the words.
+func CanonicalLicenseText(text []byte) string {
+	return strings.Join(strings.Fields(normalize.LicenseText(string(text), normalize.Moderate)), " ")
+}
+
+// ExtractReadmeFiles searches for README and README-like, e.g. humans.txt, files and returns their texts mapped from the file paths.
 // Reader is used to to read file contents.
-func ExtractReadmeFiles(files []string, fs filer.Filer) [][]byte {
-	candidates := [][]byte{}
+func ExtractReadmeFiles(files []string, fs filer.Filer) map[string][]byte {
+	candidates := map[string][]byte{}
 	for _, file := range files {
 		if readmeFileRe.MatchString(strings.ToLower(file)) {
+			text, err := fs.ReadFile(file)
+			if err == nil {
+				badges := extractBadgeLicenses(text)
+				if section := readmeLicenseSection(file, text); section != nil {
+					text = section
+				} else if preprocessor, exists := filePreprocessors[paths.Ext(file)]; exists {
+					text = preprocessor(text)
+				}
+				// the badges turn into the fields which QueryReadmeText understands
+				for _, name := range badges {
+					text = append(text, "\nLicense: "+name+"\n"...)
+				}
+				candidates[file] = text
+			}
+		}
+	}
+	return candidates
+}
+
+// ExtractCreditsFiles returns the texts of the CREDITS files mapped from the file paths.
+// Such files often list the licenses of the dependencies as well, so they are not the license
+// files unless requested.
+func ExtractCreditsFiles(files []string, fs filer.Filer) map[string][]byte {
+	candidates := map[string][]byte{}
+	for _, file := range files {
+		if creditsFileRe.MatchString(strings.ToLower(paths.Base(file))) {
 			text, err := fs.ReadFile(file)
 			if err == nil {
 				if preprocessor, exists := filePreprocessors[paths.Ext(file)]; exists {
 					text = preprocessor(text)
 				}
-				candidates = append(candidates, text)
+				candidates[file] = text
 			}
 		}
 	}
 	return candidates
 }
 
+// readmeLicenseSection returns the plain text of the license section of the README marked
+// with an anchor, e.g. <h2 id="license"> in HTML. It returns nil if there is no such section.
+func readmeLicenseSection(file string, text []byte) []byte {
+	if paths.Ext(file) == ".html" {
+		return processors.HTMLLicenseSection(text)
+	}
+	return nil
+}
+
 // InvestigateReadmeTexts scans README files for licensing information and outputs the
 // probable names using NER.
-func InvestigateReadmeTexts(texts [][]byte, fs filer.Filer) map[string]float32 {
+func InvestigateReadmeTexts(texts map[string][]byte, fs filer.Filer) (map[string]float32, error) {
+	db, err := defaultLicenseDatabase()
+	if err != nil {
+		return nil, err
+	}
 	maxLicenses := map[string]float32{}
 	for _, text := range texts {
-		candidates := InvestigateReadmeText(text, fs)
+		candidates := InvestigateReadmeText(text, fs, db)
 		for name, sim := range candidates {
 			maxSim := maxLicenses[name]
 			if sim > maxSim {
@@ -144,16 +375,126 @@ func InvestigateReadmeTexts(texts [][]byte, fs filer.Filer) map[string]float32 {
 			}
 		}
 	}
-	return maxLicenses
+	return maxLicenses, nil
 }
 
 // InvestigateReadmeText scans the README file for licensing information and outputs probable
-// names found with Named Entity Recognition from NLP.
-func InvestigateReadmeText(text []byte, fs filer.Filer) map[string]float32 {
-	return globalLicenseDatabase().QueryReadmeText(string(text), fs)
+// names found with Named Entity Recognition from NLP. If there are none, the free-form
+// non-commercial notice is reported as NonCommercial and the public domain dedication is
+// reported as PublicDomain. "The same terms as Ruby" are resolved by RecognizeRubyTerms.
+func InvestigateReadmeText(text []byte, fs filer.Filer, db *Database) map[string]float32 {
+	return InvestigateReadmeTextWithOptions(text, fs, db, ReadmeOptions{})
+}
+
+// InvestigateReadmeTextWithOptions is the same as InvestigateReadmeText but tuned by
+// the options, e.g. weights the license name mentions by their proximity to the words
+// "license", "licensed" and "licensing".
+func InvestigateReadmeTextWithOptions(
+	text []byte, fs filer.Filer, db *Database, options ReadmeOptions) map[string]float32 {
+	candidates := db.QueryReadmeTextWithOptions(string(text), fs, options)
+	for _, notice := range RecognizeRubyTerms(text) {
+		if candidates[notice.License] < notice.Confidence {
+			candidates[notice.License] = notice.Confidence
+		}
+	}
+	if len(candidates) == 0 {
+		notices := append(RecognizeNonCommercialNotice(text), RecognizePublicDomainDedication(text)...)
+		for _, notice := range notices {
+			candidates[notice.License] = notice.Confidence
+		}
+	}
+	return candidates
+}
+
+// IsLicenseFile indicates whether the file name is likely to belong to a license file.
+func IsLicenseFile(fileName string) bool {
+	return licenseFileRe.MatchString(strings.ToLower(paths.Base(fileName)))
 }
 
 // IsLicenseDirectory indicates whether the directory is likely to contain licenses.
 func IsLicenseDirectory(fileName string) bool {
 	return licenseDirectoryRe.MatchString(strings.ToLower(fileName))
 }
+
+// ExtractPatentGrants searches for PATENTS, ADDITIONAL_GRANT and PATENT_GRANT files which grant
+// additional patent rights and returns their texts mapped from the file paths.
+func ExtractPatentGrants(files []string, fs filer.Filer) map[string][]byte {
+	grants := map[string][]byte{}
+	for _, file := range files {
+		if patentsFileRe.MatchString(strings.ToLower(file)) {
+			text, err := fs.ReadFile(file)
+			if err == nil && patentGrantRe.Match(text) {
+				grants[file] = text
+			}
+		}
+	}
+	return grants
+}
+
+// ExtractNoticeFiles searches for the NOTICE files which accompany the Apache License and
+// returns their texts mapped from the file paths.
+func ExtractNoticeFiles(files []string, fs filer.Filer) map[string][]byte {
+	notices := map[string][]byte{}
+	for _, file := range files {
+		if noticeFileRe.MatchString(strings.ToLower(file)) {
+			text, err := fs.ReadFile(file)
+			if err == nil {
+				if preprocessor, exists := filePreprocessors[paths.Ext(file)]; exists {
+					text = preprocessor(text)
+				}
+				notices[file] = text
+			}
+		}
+	}
+	return notices
+}
+
+// maxPatentGrantSummaryLength is the maximum number of the characters in PatentGrantSummary.
+const maxPatentGrantSummaryLength = 100
+
+// PatentGrantSummary returns the title of the patent grant, that is, its first non-empty line,
+// e.g. "Additional Grant of Patent Rights Version 2". It is shortened to
+// maxPatentGrantSummaryLength characters.
+func PatentGrantSummary(text []byte) string {
+	for _, line := range strings.Split(string(text), "\n") {
+		line = strings.TrimSpace(strings.Trim(strings.TrimSpace(line), "#=*-"))
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > maxPatentGrantSummaryLength {
+			line = strings.TrimSpace(string(runes[:maxPatentGrantSummaryLength])) + "..."
+		}
+		return line
+	}
+	return ""
+}
+
+// LicenseKey returns the stable key of the license which does not depend on its SPDX
+// identifier but on the original text of the reference license.
+func LicenseKey(name string) (string, error) {
+	db, err := defaultLicenseDatabase()
+	if err != nil {
+		return "", err
+	}
+	return db.LicenseKey(name), nil
+}
+
+var databaseDigest struct {
+	sync.Once
+	value string
+}
+
+// DatabaseDigest returns the hex SHA-256 of the embedded reference licenses, their names
+// and URLs, which identifies the version of the license database.
+func DatabaseDigest() string {
+	databaseDigest.Do(func() {
+		hash := sha256.New()
+		for _, name := range []string{"licenses.tar", "names.csv", "urls.csv"} {
+			asset := assets.MustAsset(name)
+			fmt.Fprintf(hash, "%s %d\n", name, len(asset))
+			hash.Write(asset)
+		}
+		databaseDigest.value = hex.EncodeToString(hash.Sum(nil))
+	})
+	return databaseDigest.value
+}
@@ -0,0 +1,381 @@
+package internal
+
+import (
+	"encoding/xml"
+	paths "path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+var (
+	// lower case file name -> function which extracts the declared license expressions
+	manifestParsers = map[string]func(text string) []string{
+		"dockerfile":    parseDockerfileLicenses,
+		"containerfile": parseDockerfileLicenses,
+		"chart.yaml":    parseArtifactHubLicense,
+		"sbom.yaml":     parseYAMLSBOM,
+		"sbom.yml":      parseYAMLSBOM,
+		"bom.yaml":      parseYAMLSBOM,
+		"bom.yml":       parseYAMLSBOM,
+		"pubspec.yaml":  parsePubspecLicense,
+	}
+	// lower case file name suffix -> function which extracts the declared license expressions
+	manifestSuffixParsers = map[string]func(text string) []string{
+		bundleManifestSuffix: parseArtifactHubLicense,
+		".xml":               parseXMLLicenses,
+		".spdx.yaml":         parseYAMLSBOM,
+		".spdx.yml":          parseYAMLSBOM,
+		".cdx.yaml":          parseYAMLSBOM,
+		".cdx.yml":           parseYAMLSBOM,
+		".gemspec":           parseGemspecLicenses,
+		".nuspec":            parseNuspecLicenses,
+	}
+
+	// Dockerfile labels which declare the license of the image, the first is the OCI
+	// standard annotation and the second is the older Label Schema convention
+	dockerLicenseLabels = map[string]bool{
+		"org.opencontainers.image.licenses": true,
+		"org.label-schema.license":          true,
+	}
+
+	dockerContinuationRe = regexp.MustCompile("\\\\[ \\t]*\\r?\\n")
+	dockerLabelRe        = regexp.MustCompile("(?im)^[ \\t]*LABEL[ \\t]+(.*)$")
+	dockerLabelPairRe    = regexp.MustCompile(
+		"(\"(?:[^\"\\\\]|\\\\.)*\"|[^\\s=]+)=(\"(?:[^\"\\\\]|\\\\.)*\"|'[^']*'|\\S*)")
+	spdxOperatorRe = regexp.MustCompile("(?i)^(and|or|with)$")
+
+	// the annotation of Helm charts and operator bundles, see
+	// https://artifacthub.io/docs/topics/annotations/
+	artifactHubLicenseRe = regexp.MustCompile(
+		"(?m)^[ \\t]*[\"']?artifacthub\\.io/license[\"']?[ \\t]*:[ \\t]*([^\\r\\n]*)")
+	yamlCommentRe = regexp.MustCompile("(^|[ \\t])#.*$")
+	// the top level license field of the Dart pubspec.yaml, which is not standard but common
+	pubspecLicenseRe     = regexp.MustCompile("(?m)^licen[cs]e[ \\t]*:[ \\t]*([^\\r\\n]*)")
+	pubspecLicenseFileRe = regexp.MustCompile("(?i)^(\\./)?([\\w.-]+/)*(li[cs]en[cs]e|copying)(\\.\\w+)?$")
+	// spec.license = "MIT" or spec.licenses = ["MIT", "Ruby"] in the Ruby *.gemspec
+	gemspecLicenseRe       = regexp.MustCompile("(?m)^[ \\t]*\\w+\\.licen[cs]es?[ \\t]*=[ \\t]*([^\\r\\n]*)")
+	gemspecWordArrayRe     = regexp.MustCompile("^%[wW][\\[({<]([^\\])}>]*)")
+	gemspecStringLiteralRe = regexp.MustCompile("\"([^\"]*)\"|'([^']*)'")
+	// <licenseUrl> of the NuGet *.nuspec which predates <license>
+	nuspecLicenseURLRe = regexp.MustCompile("(?i)<licenseUrl>\\s*([^<\\s]+)\\s*</licenseUrl>")
+)
+
+// nuspecDeprecatedLicenseURL is what NuGet puts in <licenseUrl> next to <license> for the old
+// clients, so it does not refer to any license.
+const nuspecDeprecatedLicenseURL = "https://aka.ms/deprecateLicenseUrl"
+
+// bundleManifestSuffix is the name suffix of the ClusterServiceVersion in the operator bundle.
+const bundleManifestSuffix = ".clusterserviceversion.yaml"
+
+// IsBundleManifest indicates whether the file is the ClusterServiceVersion manifest of
+// the Kubernetes operator bundle, which is usually in the "manifests" directory.
+func IsBundleManifest(file string) bool {
+	return strings.HasSuffix(strings.ToLower(file), bundleManifestSuffix)
+}
+
+// manifestParser returns the function which extracts the declared license expressions
+// from the manifest, or nil if the file is not a known manifest.
+func manifestParser(file string) func(text string) []string {
+	name := strings.ToLower(paths.Base(file))
+	if parser := manifestParsers[name]; parser != nil {
+		return parser
+	}
+	for suffix, parser := range manifestSuffixParsers {
+		if strings.HasSuffix(name, suffix) {
+			return parser
+		}
+	}
+	return nil
+}
+
+// ExtractDeclaredLicenses reads the manifests, e.g. Dockerfile or Chart.yaml, and returns
+// the license expressions which they declare mapped from the file paths. The files without
+// declarations are not included.
+func ExtractDeclaredLicenses(files []string, fs filer.Filer) map[string][]string {
+	declarations := map[string][]string{}
+	for _, file := range files {
+		parser := manifestParser(file)
+		if parser == nil {
+			continue
+		}
+		text, err := fs.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		if expressions := parser(string(text)); len(expressions) > 0 {
+			declarations[file] = expressions
+		}
+	}
+	return declarations
+}
+
+// InvestigateDeclaredLicense resolves the declared SPDX license expression, e.g.
+// "MIT OR Apache-2.0", to the licenses it mentions, see InvestigateDeclaredExceptions for
+// the exceptions after WITH. The user defined identifiers, e.g. "LicenseRef-Foo", are returned
+// as is and the other unknown identifiers are dropped. The manifests which are not SPDX-aware
+// may declare the license name instead, e.g. "Apache License, Version 2.0", or its URL, which
+// are only matched if the expression has no identifiers at all.
+func InvestigateDeclaredLicense(expression string, db *Database) map[string]float32 {
+	if strings.Contains(expression, "://") {
+		return db.QueryLicenseURL(expression)
+	}
+	candidates := map[string]float32{}
+	for _, field := range declaredLicenseFields(expression) {
+		if key := declaredLicenseKey(field.license, db); key != "" {
+			candidates[key] = 1
+		}
+	}
+	if len(candidates) == 0 {
+		return db.QueryLicenseName(expression)
+	}
+	return candidates
+}
+
+// InvestigateDeclaredExceptions returns the exceptions after WITH in the declared SPDX license
+// expression by the licenses of InvestigateDeclaredLicense, e.g. "GPL-2.0-only WITH
+// Classpath-exception-2.0" maps GPL-2.0-only to Classpath-exception-2.0. The exceptions are
+// returned as declared.
+func InvestigateDeclaredExceptions(expression string, db *Database) map[string]string {
+	exceptions := map[string]string{}
+	for _, field := range declaredLicenseFields(expression) {
+		if key := declaredLicenseKey(field.license, db); key != "" && field.exception != "" {
+			exceptions[key] = field.exception
+		}
+	}
+	return exceptions
+}
+
+// declaredLicense is the license identifier in the SPDX license expression with the exception
+// which follows it, if any.
+type declaredLicense struct {
+	license, exception string
+}
+
+// declaredLicenseFields splits the SPDX license expression into the licenses.
+func declaredLicenseFields(expression string) []declaredLicense {
+	var licenses []declaredLicense
+	fields := strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(expression))
+	for i, field := range fields {
+		if spdxOperatorRe.MatchString(field) {
+			continue
+		}
+		if i > 0 && strings.EqualFold(fields[i-1], "with") {
+			if len(licenses) > 0 {
+				licenses[len(licenses)-1].exception = field
+			}
+			continue
+		}
+		licenses = append(licenses, declaredLicense{license: field})
+	}
+	return licenses
+}
+
+// declaredLicenseKey returns the key of the known license identifier, the user defined
+// identifier as is or the empty string.
+func declaredLicenseKey(id string, db *Database) string {
+	if key, exists := db.licenseIDs[strings.ToLower(id)]; exists {
+		return key
+	}
+	if strings.HasPrefix(id, "LicenseRef-") || strings.HasPrefix(id, "DocumentRef-") {
+		return id
+	}
+	return ""
+}
+
+// parseDockerfileLicenses returns the values of the license labels in the Dockerfile, e.g.
+// LABEL org.opencontainers.image.licenses="MIT".
+func parseDockerfileLicenses(text string) []string {
+	text = dockerContinuationRe.ReplaceAllString(text, " ")
+	var expressions []string
+	for _, label := range dockerLabelRe.FindAllStringSubmatch(text, -1) {
+		for _, pair := range dockerLabelPairRe.FindAllStringSubmatch(label[1], -1) {
+			if !dockerLicenseLabels[strings.ToLower(unquoteDockerValue(pair[1]))] {
+				continue
+			}
+			if value := strings.TrimSpace(unquoteDockerValue(pair[2])); value != "" {
+				expressions = append(expressions, value)
+			}
+		}
+	}
+	return expressions
+}
+
+// unquoteDockerValue removes the quotes around the Dockerfile key or value.
+func unquoteDockerValue(value string) string {
+	if len(value) < 2 || (value[0] != '"' && value[0] != '\'') || value[len(value)-1] != value[0] {
+		return value
+	}
+	if value[0] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	return strings.Replace(value[1:len(value)-1], "\\\"", "\"", -1)
+}
+
+// parseArtifactHubLicense returns the value of the artifacthub.io/license annotation in
+// the Helm Chart.yaml or in the operator ClusterServiceVersion, e.g.
+//
+//	annotations:
+//	  artifacthub.io/license: Apache-2.0
+func parseArtifactHubLicense(text string) []string {
+	var expressions []string
+	for _, match := range artifactHubLicenseRe.FindAllStringSubmatch(text, -1) {
+		if value := yamlScalar(match[1]); value != "" {
+			expressions = append(expressions, value)
+		}
+	}
+	return expressions
+}
+
+// parsePubspecLicense returns the value of the license field in the Dart pubspec.yaml, e.g.
+// "license: BSD-3-Clause". The field which refers to the license file, e.g. "license: LICENSE",
+// is skipped because the license files are investigated anyway.
+func parsePubspecLicense(text string) []string {
+	match := pubspecLicenseRe.FindStringSubmatch(text)
+	if match == nil {
+		return nil
+	}
+	value := yamlScalar(match[1])
+	if value == "" || pubspecLicenseFileRe.MatchString(value) {
+		return nil
+	}
+	return []string{value}
+}
+
+// parseGemspecLicenses returns the licenses assigned in the Ruby gem specification, e.g.
+//
+//	spec.license = "MIT"
+//	spec.licenses = ["MIT", "Ruby"]
+//	spec.licenses = %w[MIT Ruby]
+//
+// "The same terms as Ruby" are turned into the dual license of Ruby, see RecognizeRubyTerms.
+func parseGemspecLicenses(text string) []string {
+	var expressions []string
+	for _, match := range gemspecLicenseRe.FindAllStringSubmatch(text, -1) {
+		var values []string
+		if words := gemspecWordArrayRe.FindStringSubmatch(match[1]); words != nil {
+			values = strings.Fields(words[1])
+		} else {
+			for _, literal := range gemspecStringLiteralRe.FindAllStringSubmatch(match[1], -1) {
+				values = append(values, literal[1]+literal[2])
+			}
+		}
+		for _, value := range values {
+			value = strings.TrimSpace(value)
+			if rubyTermsRe.MatchString(value) {
+				value = strings.Join(rubyTermsLicenses, " OR ")
+			}
+			if value != "" {
+				expressions = append(expressions, value)
+			}
+		}
+	}
+	return expressions
+}
+
+// yamlScalar returns the plain or the quoted YAML scalar value without the trailing comment.
+func yamlScalar(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			value = value[1 : end+1]
+		}
+		return value
+	}
+	return strings.TrimSpace(yamlCommentRe.ReplaceAllString(value, ""))
+}
+
+// parseNuspecLicenses returns the license expression of the NuGet *.nuspec, see
+// parseXMLLicenses, or its <licenseUrl> if there is no <license>.
+func parseNuspecLicenses(text string) []string {
+	if expressions := parseXMLLicenses(text); len(expressions) > 0 {
+		return expressions
+	}
+	match := nuspecLicenseURLRe.FindStringSubmatch(text)
+	if match == nil || strings.EqualFold(match[1], nuspecDeprecatedLicenseURL) {
+		return nil
+	}
+	return []string{match[1]}
+}
+
+// parseXMLLicenses returns the names and the URLs in the <license> elements anywhere in
+// the XML manifest, so that Maven, Ivy, Felix and the like do not need their own parsers, e.g.
+//
+//	<license name="Apache License, Version 2.0" url="https://www.apache.org/licenses/LICENSE-2.0"/>
+//	<license><name>MIT</name><url>https://opensource.org/licenses/MIT</url></license>
+//	<license type="expression">MIT</license>
+//
+// The name goes before the URL of the same element. The malformed XML is read up to the error.
+func parseXMLLicenses(text string) []string {
+	decoder := xml.NewDecoder(strings.NewReader(text))
+	decoder.Strict = false
+	var expressions []string
+	// depth inside the current <license>, 0 outside
+	depth := 0
+	var name, url, child string
+	var file bool
+	content := &strings.Builder{}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if depth > 0 {
+				depth++
+				child = strings.ToLower(token.Name.Local)
+				continue
+			}
+			if !strings.EqualFold(token.Name.Local, "license") {
+				continue
+			}
+			depth = 1
+			name, url, child, file = "", "", "", false
+			content.Reset()
+			for _, attr := range token.Attr {
+				switch strings.ToLower(attr.Name.Local) {
+				case "name":
+					name = strings.TrimSpace(attr.Value)
+				case "url", "href":
+					url = strings.TrimSpace(attr.Value)
+				case "type":
+					// NuGet refers to the license file with type="file"
+					file = strings.EqualFold(attr.Value, "file")
+				}
+			}
+		case xml.CharData:
+			if depth == 0 {
+				continue
+			}
+			value := strings.TrimSpace(string(token))
+			switch {
+			case depth == 1:
+				content.WriteString(value)
+			case depth == 2 && child == "name" && name == "":
+				name = value
+			case depth == 2 && child == "url" && url == "":
+				url = value
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth > 0 {
+				child = ""
+				continue
+			}
+			if name == "" && !file {
+				name = content.String()
+			}
+			for _, value := range []string{name, url} {
+				if value != "" {
+					expressions = append(expressions, value)
+				}
+			}
+		}
+	}
+	return expressions
+}
@@ -0,0 +1,180 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDockerfileLicenses(t *testing.T) {
+	assert.Equal(t, []string{"MIT"}, parseDockerfileLicenses(
+		"FROM scratch\nLABEL org.opencontainers.image.licenses=MIT\n"))
+	assert.Equal(t, []string{"GPL-2.0-only WITH Classpath-exception-2.0", "BSD-3-Clause"},
+		parseDockerfileLicenses(`FROM scratch
+label maintainer="Foo <foo@example.com>" \
+  "org.opencontainers.image.licenses"="GPL-2.0-only WITH Classpath-exception-2.0"
+LABEL org.label-schema.license='BSD-3-Clause'
+`))
+	assert.Nil(t, parseDockerfileLicenses(
+		"FROM scratch\n# LABEL org.opencontainers.image.licenses=MIT\nLABEL version=1.0\n"))
+}
+
+func TestInvestigateDeclaredLicense(t *testing.T) {
+	assert.Equal(t, map[string]float32{"MIT": 1, "Apache-2.0": 1},
+		InvestigateDeclaredLicense("(MIT OR Apache-2.0)", globalLicenseDatabase()))
+	assert.Equal(t, map[string]float32{"GPL-2.0-only": 1},
+		InvestigateDeclaredLicense("GPL-2.0-only WITH Classpath-exception-2.0", globalLicenseDatabase()))
+	assert.Equal(t, map[string]string{"GPL-2.0-only": "Classpath-exception-2.0"},
+		InvestigateDeclaredExceptions("MIT OR (GPL-2.0-only WITH Classpath-exception-2.0)",
+			globalLicenseDatabase()))
+	// the user defined identifiers are never guessed
+	assert.Equal(t, map[string]float32{"LicenseRef-MIT-Style": 1, "Apache-2.0": 1},
+		InvestigateDeclaredLicense("LicenseRef-MIT-Style OR Apache-2.0", globalLicenseDatabase()))
+	assert.Equal(t, map[string]float32{"LicenseRef-Proprietary": 1},
+		InvestigateDeclaredLicense("LicenseRef-Proprietary", globalLicenseDatabase()))
+	assert.Equal(t, map[string]float32{"MIT": 1},
+		InvestigateDeclaredLicense("MIT OR Foo-Bar", globalLicenseDatabase()))
+	assert.Equal(t, map[string]float32{"Apache-2.0": 1},
+		InvestigateDeclaredLicense("https://www.apache.org/licenses/LICENSE-2.0", globalLicenseDatabase()))
+	assert.Contains(t, InvestigateDeclaredLicense("Apache License, Version 2.0", globalLicenseDatabase()), "Apache-2.0")
+}
+
+func TestParseArtifactHubLicense(t *testing.T) {
+	assert.Equal(t, []string{"Apache-2.0"}, parseArtifactHubLicense(`apiVersion: v2
+name: foo
+version: 1.0.0
+annotations:
+  artifacthub.io/license: Apache-2.0 # the chart only
+  artifacthub.io/links: |
+    - name: source
+      url: https://example.com/foo
+`))
+	assert.Equal(t, []string{"MIT OR GPL-2.0-only"}, parseArtifactHubLicense(
+		"metadata:\n  annotations:\n    \"artifacthub.io/license\": \"MIT OR GPL-2.0-only\"\n"))
+	assert.Nil(t, parseArtifactHubLicense("apiVersion: v2\nname: foo\n# artifacthub.io/license: MIT\n"))
+	assert.True(t, IsBundleManifest("foo.v1.0.0.clusterserviceversion.yaml"))
+	assert.False(t, IsBundleManifest("foo.crd.yaml"))
+}
+
+func TestParsePubspecLicense(t *testing.T) {
+	assert.Equal(t, []string{"MIT"}, parsePubspecLicense("name: foo\nlicense: MIT # see LICENSE\n"))
+	assert.Equal(t, []string{"Apache License, Version 2.0"}, parsePubspecLicense(
+		"name: foo\nlicense: \"Apache License, Version 2.0\"\n"))
+	assert.Nil(t, parsePubspecLicense("name: foo\nlicense: LICENSE\n"))
+	assert.Nil(t, parsePubspecLicense("name: foo\nlicense: ./docs/LICENSE.md\n"))
+	assert.Nil(t, parsePubspecLicense("name: foo\ndependencies:\n  license: ^1.0.0\n"))
+}
+
+func TestParseGemspecLicenses(t *testing.T) {
+	assert.Equal(t, []string{"Ruby"}, parseGemspecLicenses(`Gem::Specification.new do |spec|
+  spec.name    = "foo"
+  spec.license = "Ruby"
+end
+`))
+	assert.Equal(t, []string{"MIT", "Ruby", "BSD-2-Clause"}, parseGemspecLicenses(
+		"Gem::Specification.new do |s|\n  s.licenses = ['MIT', \"Ruby\"]\n  s.licenses = %w[BSD-2-Clause]\nend\n"))
+	assert.Equal(t, []string{"Ruby OR GPL-2.0-only"}, parseGemspecLicenses(
+		"Gem::Specification.new do |s|\n  s.license = 'the same terms as Ruby'\nend\n"))
+	assert.Nil(t, parseGemspecLicenses("Gem::Specification.new do |s|\n  s.name = 'license'\nend\n"))
+}
+
+func TestParseXMLLicenses(t *testing.T) {
+	assert.Equal(t, []string{"Apache License, Version 2.0", "http://www.apache.org/licenses/LICENSE-2.0"},
+		parseXMLLicenses(`<ivy-module version="2.0">
+  <info organisation="org.example" module="foo">
+    <license name="Apache License, Version 2.0" url="http://www.apache.org/licenses/LICENSE-2.0"/>
+  </info>
+</ivy-module>`))
+	assert.Equal(t, []string{"MIT", "https://opensource.org/licenses/MIT", "BSD-3-Clause"},
+		parseXMLLicenses(`<project>
+  <licenses>
+    <license>
+      <name>MIT</name>
+      <url>https://opensource.org/licenses/MIT</url>
+      <distribution>repo</distribution>
+    </license>
+    <License Name="BSD-3-Clause"/>
+  </licenses>
+</project>`))
+	assert.Equal(t, []string{"MIT"}, parseXMLLicenses(
+		`<package><metadata><license type="expression">MIT</license></metadata></package>`))
+	assert.Nil(t, parseXMLLicenses(
+		`<package><metadata><license type="file">LICENSE.txt</license></metadata></package>`))
+	assert.Nil(t, parseXMLLicenses(`<project name="foo"><licensed>no</licensed></project>`))
+	assert.Equal(t, []string{"MIT"}, parseXMLLicenses(`<a><license>MIT</license><b>`))
+}
+
+func TestParseNuspecLicenses(t *testing.T) {
+	assert.Equal(t, []string{"MIT"}, parseNuspecLicenses(`<package><metadata>
+  <license type="expression">MIT</license>
+  <licenseUrl>https://aka.ms/deprecateLicenseUrl</licenseUrl>
+</metadata></package>`))
+	assert.Equal(t, []string{"https://opensource.org/licenses/MIT"}, parseNuspecLicenses(
+		`<package><metadata><licenseUrl>https://opensource.org/licenses/MIT</licenseUrl></metadata></package>`))
+	assert.Nil(t, parseNuspecLicenses(
+		`<package><metadata><licenseUrl>https://aka.ms/deprecateLicenseUrl</licenseUrl></metadata></package>`))
+}
+
+func TestParseYAMLSBOM(t *testing.T) {
+	assert.Equal(t, []string{"MIT OR Apache-2.0"}, parseYAMLSBOM(`spdxVersion: SPDX-2.3
+dataLicense: CC0-1.0
+SPDXID: SPDXRef-DOCUMENT
+name: foo-1.0
+documentDescribes:
+  - SPDXRef-Package-foo
+packages:
+  - name: bar
+    SPDXID: SPDXRef-Package-bar
+    licenseDeclared: GPL-3.0-only
+  - name: foo
+    SPDXID: SPDXRef-Package-foo
+    licenseConcluded: MIT
+    licenseDeclared: "MIT OR Apache-2.0"  # dual
+    checksums:
+      - algorithm: SHA1
+        checksumValue: 85ed0817af83a24ad8da68c2b5094de69833983c
+`))
+	assert.Equal(t, []string{"BSD-3-Clause"}, parseYAMLSBOM(`spdxVersion: SPDX-2.2
+dataLicense: CC0-1.0
+packages:
+- name: bar
+  SPDXID: SPDXRef-Package-bar
+  licenseDeclared: GPL-3.0-only
+- name: foo
+  SPDXID: SPDXRef-Package-foo
+  licenseDeclared: NOASSERTION
+  licenseConcluded: BSD-3-Clause
+relationships:
+- spdxElementId: SPDXRef-DOCUMENT
+  relationshipType: DESCRIBES
+  relatedSpdxElement: SPDXRef-Package-foo
+`))
+	assert.Equal(t, []string{"GPL-3.0-only"}, parseYAMLSBOM(`spdxVersion: SPDX-2.3
+dataLicense: CC0-1.0
+packages:
+  - name: bar
+    licenseDeclared: GPL-3.0-only
+`))
+	assert.Equal(t, []string{"MIT", "Apache License 2.0", "MIT OR Apache-2.0"}, parseYAMLSBOM(`bomFormat: CycloneDX
+specVersion: "1.5"
+metadata:
+  component:
+    name: foo
+    licenses:
+      - license:
+          id: MIT
+      - license:
+          name: Apache License 2.0
+          url: https://www.apache.org/licenses/LICENSE-2.0
+      - expression: MIT OR Apache-2.0
+    version: 1.0.0
+components:
+  - name: bar
+    licenses:
+      - license:
+          id: GPL-3.0-only
+`))
+	assert.Equal(t, []string{"MIT", "ISC"}, parseYAMLSBOM("name: foo\nlicenses: [MIT, 'ISC']\n"))
+	assert.Equal(t, []string{"Zlib"}, parseYAMLSBOM("name: foo\nlicenses:\n- Zlib\nversion: 1\n"))
+	assert.Nil(t, parseYAMLSBOM("name: foo\nversion: 1\n"))
+}
@@ -17,18 +17,39 @@ var (
 	licenseNamePartRe   = regexp.MustCompile("([a-z]+)|([0-9]+)")
 	digitsRe            = regexp.MustCompile("[0-9]+")
 	disabledNamePartsRe = regexp.MustCompile("clause|or|only|deprecated|later")
+	licenseWordRe       = regexp.MustCompile("(?i)^licen[cs](e|ed|es|ing)$")
+	// "license" in the other languages, see ReadmeOptions.Multilingual
+	foreignLicenseMarkReadmeRe = regexp.MustCompile(
+		"(?i)ライセンス|使用許諾|许可证|許可證|授权|授權|라이선스|라이센스|lizenz|licença|licenza|лицензи")
 
 	tagger = tag.NewPerceptronTagger()
 )
 
+// licenseProximityWindow is the number of words around "license", "licensed" or "licensing"
+// within which the license name mentions keep their confidence, see proximityWeight.
+const licenseProximityWindow = 10
+
+// ReadmeOptions tune the investigation of the README files, see InvestigateReadmeTextWithOptions.
+type ReadmeOptions struct {
+	// Proximity scales the confidence of the license name mentions by proximityWeight.
+	Proximity bool
+	// Multilingual looks for the license mentions in the sections of all the languages, e.g.
+	// after "ライセンス" in the Japanese section, rather than only in the English ones.
+	Multilingual bool
+}
+
 // investigateReadmeFile uses NER to match license name mentions.
 // It takes two arguments: licenseNameParts and licenseNameSizes.
 // The idea is to map substrings to real licenses, and the confidence is
 // <the number of matches> / <overall number of substrings>.
-func investigateReadmeFile(
+func (db *database) investigateReadmeFile(
 	text string, licenseNameParts map[string][]substring,
-	licenseNameSizes map[string]int) map[string]float32 {
+	licenseNameSizes map[string]int, options ReadmeOptions) map[string]float32 {
 	matches := licenseMarkReadmeRe.FindAllStringIndex(text, -1)
+	if options.Multilingual {
+		matches = append(matches, foreignLicenseMarkReadmeRe.FindAllStringIndex(text, -1)...)
+		sort.Slice(matches, func(i, j int) bool { return matches[i][0] < matches[j][0] })
+	}
 	if len(matches) == 0 {
 		return map[string]float32{}
 	}
@@ -40,7 +61,7 @@ func investigateReadmeFile(
 	endIndex := matches[len(matches)-1][1]
 	for ; endIndex < len(text)-1 && text[endIndex:endIndex+2] != "\n\n"; endIndex++ {
 	}
-	candidates := globalLicenseDatabase().QueryLicenseText(text[beginIndex:endIndex])
+	candidates := db.QueryLicenseText(text[beginIndex:endIndex])
 
 	beginIndex = matches[0][0]
 	endIndex = beginIndex + 50
@@ -63,54 +84,125 @@ func investigateReadmeFile(
 		endIndex = len(text)
 	}
 	suspectedText := text[beginIndex:endIndex]
+	if options.Multilingual {
+		suspectedText = separateCJK(suspectedText)
+	}
 	suspectedWords := tokenize.TextToWords(suspectedText)
-	for _, entity := range chunk.Chunk(tagger.Tag(suspectedWords), chunk.TreebankNamedEntities) {
+	tagged := tagger.Tag(suspectedWords)
+	for _, loc := range chunk.Locate(tagged, chunk.TreebankNamedEntities) {
+		words := make([]string, 0, loc[1]-loc[0])
+		for _, token := range tagged[loc[0]:loc[1]] {
+			words = append(words, token.Text)
+		}
+		entity := strings.Join(words, " ")
 		if garbageReadmeRe.MatchString(entity) {
 			continue
 		}
-		scores := map[string]map[string]int{}
-		entity = licenseReadmeRe.ReplaceAllString(entity, " ")
-		substrs := splitLicenseName(entity)
-		for _, substr := range substrs {
-			for _, match := range licenseNameParts[substr.value] {
-				common := match.count
-				if substr.count < common {
-					common = substr.count
-				}
-				matchSubstrs := scores[match.value]
-				if matchSubstrs == nil {
-					matchSubstrs = map[string]int{}
-					scores[match.value] = matchSubstrs
-				}
-				matchSubstrs[substr.value] = common
-			}
+		weight := float32(1)
+		if options.Proximity {
+			weight = proximityWeight(tagged, loc[0], loc[1])
 		}
-		// if the only reason a license matched is a single digit, drop it
-		toRemove := []string{}
-		for key, matchSubstrs := range scores {
-			if len(matchSubstrs) == 1 {
-				for substr := range matchSubstrs {
-					if digitsRe.MatchString(substr) {
-						toRemove = append(toRemove, key)
-					}
-				}
+		for key, confidence := range scoreLicenseName(entity, licenseNameParts, licenseNameSizes) {
+			confidence *= weight
+			if candidates[key] < confidence && confidence >= 0.3 {
+				candidates[key] = confidence
 			}
 		}
-		for _, key := range toRemove {
-			delete(scores, key)
+	}
+	return candidates
+}
+
+// proximityWeight returns the weight of the named entity between the tagged words `begin` and
+// `end` by its distance to the nearest "license", "licensed" or "licensing" word: 1 within
+// licenseProximityWindow words and decreasing inversely with the distance beyond, so that e.g.
+// "MIT" in the unrelated sentence does not outweigh "licensed under the MIT License".
+func proximityWeight(tagged []tag.Token, begin, end int) float32 {
+	distance := -1
+	for i, token := range tagged {
+		if !licenseWordRe.MatchString(token.Text) && !foreignLicenseMarkReadmeRe.MatchString(token.Text) {
+			continue
+		}
+		d := 0
+		if i < begin {
+			d = begin - i
+		} else if i >= end {
+			d = i - end + 1
 		}
-		for key, val := range scores {
-			matchSize := 0
-			for _, n := range val {
-				matchSize += n
+		if distance < 0 || d < distance {
+			distance = d
+		}
+	}
+	if distance < 0 {
+		return 0
+	}
+	if distance <= licenseProximityWindow {
+		return 1
+	}
+	return float32(licenseProximityWindow) / float32(distance)
+}
+
+// separateCJK surrounds the runs of the Chinese, Japanese and Korean characters with spaces
+// like CJKTokenizer separates them, e.g. "MITライセンス" becomes "MIT ライセンス", so that
+// the license names stand out as the separate words, since these scripts do not separate them.
+func separateCJK(text string) string {
+	separated := &strings.Builder{}
+	wasCJK := false
+	for i, char := range text {
+		cjk := isCJK(char)
+		if i > 0 && cjk != wasCJK {
+			separated.WriteByte(' ')
+		}
+		wasCJK = cjk
+		separated.WriteRune(char)
+	}
+	return separated.String()
+}
+
+// scoreLicenseName matches the substrings of the license name mention to the registered licenses.
+// The confidence is <the number of matches> / <overall number of substrings>.
+func scoreLicenseName(
+	entity string, licenseNameParts map[string][]substring,
+	licenseNameSizes map[string]int) map[string]float32 {
+	scores := map[string]map[string]int{}
+	entity = licenseReadmeRe.ReplaceAllString(entity, " ")
+	substrs := splitLicenseName(entity)
+	for _, substr := range substrs {
+		for _, match := range licenseNameParts[substr.value] {
+			common := match.count
+			if substr.count < common {
+				common = substr.count
 			}
-			confidence := float32(matchSize) / float32(licenseNameSizes[key])
-			if candidates[key] < confidence && confidence >= 0.3 {
-				candidates[key] = confidence
+			matchSubstrs := scores[match.value]
+			if matchSubstrs == nil {
+				matchSubstrs = map[string]int{}
+				scores[match.value] = matchSubstrs
 			}
+			matchSubstrs[substr.value] = common
 		}
 	}
-	return candidates
+	// if the only reason a license matched is a single digit, drop it
+	toRemove := []string{}
+	for key, matchSubstrs := range scores {
+		if len(matchSubstrs) == 1 {
+			for substr := range matchSubstrs {
+				if digitsRe.MatchString(substr) {
+					toRemove = append(toRemove, key)
+				}
+			}
+		}
+	}
+	for _, key := range toRemove {
+		delete(scores, key)
+	}
+	result := map[string]float32{}
+	for key, val := range scores {
+		matchSize := 0
+		for _, n := range val {
+			matchSize += n
+		}
+		result[key] = float32(matchSize) / float32(licenseNameSizes[key])
+	}
+	return result
 }
 
 func splitLicenseName(name string) []substring {
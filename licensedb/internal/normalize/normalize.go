@@ -14,8 +14,8 @@ import (
 var (
 	lineEndingsRe = regexp.MustCompile("\\r\\n?")
 	// 3.1.1 All whitespace should be treated as a single blank space.
-	whitespaceRe         = regexp.MustCompile("[ \\t\\f\\r             　​]+")
-	trailingWhitespaceRe = regexp.MustCompile("(?m)[ \\t\\f\\r             　​]$")
+	whitespaceRe         = regexp.MustCompile("[ \\t\\f\\r              　​]+")
+	trailingWhitespaceRe = regexp.MustCompile("(?m)[ \\t\\f\\r              　​]$")
 	licenseHeaderRe      = regexp.MustCompile("(licen[cs]e)\\.?\\n\\n")
 	leadingWhitespaceRe  = regexp.MustCompile("(?m)^(( \\n?)|\\n)")
 	// 5.1.2 Hyphens, Dashes  Any hyphen, dash, en dash, em dash, or other variation should be
@@ -23,7 +23,18 @@ var (
 	punctuationRe = regexp.MustCompile("[-‒–—―⁓⸺⸻~˗‐‑⁃⁻₋−∼⎯⏤─➖𐆑֊﹘﹣－]+")
 	// 5.1.3 Quotes  Any variation of quotations (single, double, curly, etc.) should be considered
 	// equivalent.
-	quotesRe = regexp.MustCompile("[\"'“”‘’„‚«»‹›❛❜❝❞`]+")
+	quotesRe = regexp.MustCompile("[\"'“”‘’„‚‛‟«»‹›❛❜❝❞′″〝〞〟＂＇`]+")
+	// the typographic ligatures and symbols inserted by the word processors and PDF converters
+	typographyReplacer = strings.NewReplacer(
+		"ﬀ", "ff",
+		"ﬁ", "fi",
+		"ﬂ", "fl",
+		"ﬃ", "ffi",
+		"ﬄ", "ffl",
+		"ﬅ", "st",
+		"ﬆ", "st",
+		"…", "...",
+	)
 	// 7.1.1 Where a line starts with a bullet, number, letter, or some form of a list item
 	// (determined where list item is followed by a space, then the text of the sentence), ignore
 	// the list item for matching purposes.
@@ -82,7 +93,8 @@ var (
 	brokenLinkRe    = regexp.MustCompile("http s ://")
 	urlCleanupRe    = regexp.MustCompile("[<(](http(s?)://[^\\s]+)[)>]")
 	copyrightLineRe = regexp.MustCompile("(?m)^((©.*)|(all rights reserved(\\.)?)|(li[cs]en[cs]e))\n")
-	nonAlphaNumRe   = regexp.MustCompile("[^- \\na-z0-9]")
+	// the Chinese, Japanese and Korean characters are the letters too
+	nonAlphaNumRe = regexp.MustCompile("[^- \\na-z0-9\\p{Han}\\p{Hiragana}\\p{Katakana}\\p{Hangul}]")
 
 	// used in Split()
 	splitRe = regexp.MustCompile("\\n\\s*[^a-zA-Z0-9_,()]{3,}\\s*\\n")
@@ -101,10 +113,45 @@ const (
 	Relaxed Strictness = 2
 )
 
+// The names of the optional steps of LicenseText which LicenseTextSkipping can skip.
+const (
+	StepUnicodeComposition = "unicode composition"
+	StepWhitespace         = "whitespace"
+	StepPlaceholders       = "placeholders"
+	StepPunctuation        = "punctuation"
+	StepBullets            = "bullets"
+	StepSpelling           = "spelling"
+	StepCopyrightSymbols   = "copyright symbols"
+	StepURLs               = "urls"
+	StepTrailingDots       = "trailing dots"
+	StepCopyrightLines     = "copyright lines"
+)
+
+// Steps are all the optional steps of LicenseText in the order of application.
+var Steps = []string{
+	StepUnicodeComposition, StepWhitespace, StepPlaceholders, StepPunctuation, StepBullets,
+	StepSpelling, StepCopyrightSymbols, StepURLs, StepTrailingDots, StepCopyrightLines,
+}
+
 // LicenseText makes a license text ready for analysis.
 // It follows SPDX guidelines at
 // https://spdx.org/spdx-license-list/matching-guidelines
 func LicenseText(text string, strictness Strictness) string {
+	return LicenseTextSkipping(text, strictness, "")
+}
+
+// LicenseTextSkipping is the same as LicenseText but does not apply the specified step,
+// one of Steps, e.g. to measure its influence on the matching.
+func LicenseTextSkipping(text string, strictness Strictness, skipped string) string {
+	apply := func(step string) bool {
+		return step != skipped
+	}
+
+	// the same characters may be composed differently, e.g. "é" and "e" + U+0301
+	if apply(StepUnicodeComposition) {
+		text = norm.NFC.String(text)
+	}
+
 	// Line endings
 	text = lineEndingsRe.ReplaceAllString(text, "\n")
 
@@ -112,30 +159,47 @@ func LicenseText(text string, strictness Strictness) string {
 	text = strings.ToLower(text)
 
 	// 3. Whitespace
-	text = whitespaceRe.ReplaceAllString(text, " ")
-	text = trailingWhitespaceRe.ReplaceAllString(text, "")
-	text = licenseHeaderRe.ReplaceAllString(text, "$1\nthisislikelyalicenseheaderplaceholder\n")
-	text = leadingWhitespaceRe.ReplaceAllString(text, "")
+	if apply(StepWhitespace) {
+		text = whitespaceRe.ReplaceAllString(text, " ")
+		text = trailingWhitespaceRe.ReplaceAllString(text, "")
+	}
+	if apply(StepPlaceholders) {
+		text = licenseHeaderRe.ReplaceAllString(text, "$1\nthisislikelyalicenseheaderplaceholder\n")
+	}
+	if apply(StepWhitespace) {
+		text = leadingWhitespaceRe.ReplaceAllString(text, "")
+	}
 
 	// 5. Punctuation
-	text = punctuationRe.ReplaceAllString(text, "-")
-	text = quotesRe.ReplaceAllString(text, "\"")
+	if apply(StepPunctuation) {
+		text = punctuationRe.ReplaceAllString(text, "-")
+		text = quotesRe.ReplaceAllString(text, "\"")
+		text = typographyReplacer.Replace(text)
+	}
 
 	// 7. Bullets and Numbering
-	text = bulletRe.ReplaceAllString(text, "")
+	if apply(StepBullets) {
+		text = bulletRe.ReplaceAllString(text, "")
+	}
 
 	// 8. Varietal Word Spelling
-	text = wordReplacer.Replace(text)
+	if apply(StepSpelling) {
+		text = wordReplacer.Replace(text)
+	}
 
 	// 9. Copyright Symbol
-	text = copyrightRe.ReplaceAllString(text, "©")
-	text = trademarkRe.ReplaceAllString(text, "™")
+	if apply(StepCopyrightSymbols) {
+		text = copyrightRe.ReplaceAllString(text, "©")
+		text = trademarkRe.ReplaceAllString(text, "™")
+	}
 
-	// fix broken URLs in SPDX source texts
-	text = brokenLinkRe.ReplaceAllString(text, "https://")
+	if apply(StepURLs) {
+		// fix broken URLs in SPDX source texts
+		text = brokenLinkRe.ReplaceAllString(text, "https://")
 
-	// fix URLs in <> - erase the decoration
-	text = urlCleanupRe.ReplaceAllString(text, "$1")
+		// fix URLs in <> - erase the decoration
+		text = urlCleanupRe.ReplaceAllString(text, "$1")
+	}
 
 	// collapse several non-alphanumeric characters
 	{
@@ -153,9 +217,13 @@ func LicenseText(text string, strictness Strictness) string {
 
 	if strictness > Enforced {
 		// there are common mismatches because of trailing dots
-		text = strings.Replace(text, ".", "", -1)
+		if apply(StepTrailingDots) {
+			text = strings.Replace(text, ".", "", -1)
+		}
 		// usually copyright lines are custom and occur multiple times
-		text = copyrightLineRe.ReplaceAllString(text, "")
+		if apply(StepCopyrightLines) {
+			text = copyrightLineRe.ReplaceAllString(text, "")
+		}
 	}
 
 	if strictness > Moderate {
@@ -21,6 +21,9 @@ permissions granted by this license.`},
 		{"normalize links", "A <https://fsf.org/> B", "a https:/fsf.org/ b"},
 		{"license", "license.\n\nlicence\n\n", "license\n\nlicense\n\n"},
 		{"punctuation", "a-‒–—―⁓⸺⸻~˗‐‑⁃⁻₋−∼⎯⏤─➖𐆑֊﹘﹣－", "a-"},
+		{"typography", "„Software‟ ″as is″ ﬁles…", "\"software\" \"as is\" files."},
+		{"composition", "Universit\u0065\u0301", "universit\u00e9"},
+		{"nbsp", "a\u00a0b\u00a0", "a b"},
 		{"bullet", "-\n*\n✱\n﹡\n•\n●\n⚫\n⏺\n🞄\n∙\n⋅\n", ""},
 		{"license", "", ""},
 	}
@@ -29,3 +32,12 @@ permissions granted by this license.`},
 		assert.Equal(t, tc.out, LicenseText(tc.in, Enforced))
 	}
 }
+
+func TestNormalizeSkipping(t *testing.T) {
+	text := "Copyright (c) 2020 Foo\nThe licence.\n"
+	assert.Equal(t, "the license\n", LicenseTextSkipping(text, Moderate, ""))
+	assert.Equal(t, LicenseText(text, Moderate), LicenseTextSkipping(text, Moderate, ""))
+	assert.Equal(t, "© © 2020 foo\nthe license\n",
+		LicenseTextSkipping(text, Moderate, StepCopyrightLines))
+	assert.Equal(t, "the licence\n", LicenseTextSkipping(text, Moderate, StepSpelling))
+}
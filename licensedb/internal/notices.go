@@ -0,0 +1,365 @@
+package internal
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// Notice is a well-known license notice which refers to the license instead of including
+// its text, e.g. the standard header of the Qt sources.
+type Notice struct {
+	// License is the SPDX identifier of the referenced license.
+	License string
+	// Exception is the SPDX identifier of the license exception, if any.
+	Exception string
+	// Confidence is from 0 to 1, 1 means 100% confident.
+	Confidence float32
+}
+
+// PublicDomain is the pseudo license of the works which are dedicated to the public domain
+// in free form, without CC0, the Unlicense or other standard text.
+const PublicDomain = "public-domain"
+
+// publicDomainConfidence is the confidence of the free-form public domain dedication. The phrase
+// is certain, but it is not a license and may come with conditions elsewhere in the text.
+const publicDomainConfidence = 0.75
+
+// publicDomainNegationWindow is the number of the bytes before the public domain phrase in
+// the same sentence which are checked for the negation.
+const publicDomainNegationWindow = 40
+
+// noticeRecognizer returns the notices of a particular kind which are found in the text.
+type noticeRecognizer func(text string) []Notice
+
+var (
+	noticeRecognizers = []noticeRecognizer{
+		recognizeQtNotice,
+		recognizeBeerwareNotice,
+		recognizeLPPLNotice,
+		recognizeFontExceptionNotice,
+		recognizeASFNotice,
+	}
+
+	qtLicenseMarkerRe = regexp.MustCompile("\\$QT_BEGIN_LICENSE:([A-Z0-9-]+)\\$")
+	qtLGPL21Re        = regexp.MustCompile("(?i)lesser\\s+general\\s+public\\s+license\\s+version\\s+2\\.1")
+	qtLGPLExceptionRe = regexp.MustCompile("(?i)qt\\s+lgpl\\s+exception")
+	// $QT_BEGIN_LICENSE:<marker>$ -> SPDX identifiers
+	qtLicenses = map[string]Notice{
+		"LGPL":       {License: "LGPL-3.0-only"},
+		"LGPL3":      {License: "LGPL-3.0-only"},
+		"LGPL21":     {License: "LGPL-2.1-only"},
+		"LGPL-ONLY":  {License: "LGPL-3.0-only"},
+		"GPL":        {License: "GPL-3.0-only"},
+		"GPL-EXCEPT": {License: "GPL-3.0-only", Exception: "Qt-GPL-exception-1.0"},
+		"BSD":        {License: "BSD-3-Clause"},
+		"MIT":        {License: "MIT"},
+		"FDL":        {License: "GFDL-1.3-only"},
+	}
+
+	publicDomainVerbRe = regexp.MustCompile("(?i)\\b(?:dedicat|releas|plac|put|donat|giv)\\w*\\b" +
+		"[^.;]{0,80}?\\b(?:in|into|to)\\s+the\\s+public\\s+domain\\b")
+	publicDomainStateRe = regexp.MustCompile("(?i)\\b(?:is|are)\\s+(?:\\w+\\s+)?" +
+		"(?:in|part\\s+of|belongs?\\s+to)\\s+the\\s+public\\s+domain\\b")
+	// "not", "never", "isn't"; checked in the match and in the words before it
+	publicDomainNegationRe = regexp.MustCompile("(?i)\\b(?:not|never)\\b|n['’]t\\b")
+
+	// "for non-commercial research use", "noncommercial purposes"
+	nonCommercialUseRe = regexp.MustCompile("(?i)\\bnon-?\\s?commercial\\s+(?:[\\w/-]+\\s+){0,3}?" +
+		"(?:use|uses|usage|purposes?)\\b")
+	// "solely for academic research", "for research purposes only"
+	researchOnlyRe = regexp.MustCompile("(?i)\\b(?:(?:solely|only|exclusively|strictly)\\s+for\\s+" +
+		"(?:[\\w/-]+\\s+){0,2}?(?:academic|research|educational|scientific|teaching|evaluation)\\b|" +
+		"(?:academic|research|educational|scientific|evaluation)\\s+(?:use|uses|purposes?)\\s+only\\b)")
+	// "must not be used for commercial purposes", "commercial use is prohibited"
+	commercialProhibitionRe = regexp.MustCompile("(?i)\\b(?:(?:may|shall|must|can)\\s*not\\s+be\\s+used\\s+" +
+		"(?:in\\s+|for\\s+)(?:any\\s+)?commercial|commercial\\s+(?:use|usage|purposes|exploitation)" +
+		"[^.;]{0,40}?\\b(?:is\\s+|are\\s+)?(?:prohibited|forbidden|not\\s+(?:permitted|allowed)))")
+	// "for any purpose, commercial or non-commercial" is the permission, not the restriction
+	commercialAlternativeRe = regexp.MustCompile("(?i)\\bcommercial\\s+(?:or|and|and/or)\\s+$")
+
+	commonsClauseTitleRe = regexp.MustCompile("(?i)\\bcommons\\s+clause\\b")
+	commonsClauseGrantRe = regexp.MustCompile("(?i)does\\s+not\\s+grant\\s+to\\s+you,?\\s+" +
+		"the\\s+right\\s+to\\s+sell\\s+the\\s+software")
+
+	beerwareTitleRe  = regexp.MustCompile("(?i)\\bbeer-?ware\\s+license")
+	beerwareClauseRe = regexp.MustCompile("(?i)retain\\s+this\\s+notice[\\s\\S]{0,200}?" +
+		"buy\\s+me\\s+a\\s+beer\\s+in\\s+return")
+
+	lpplNoticeRe = regexp.MustCompile("(?i)conditions\\s+of\\s+the\\s+latex\\s+project\\s+public\\s+" +
+		"license,?\\s+either\\s+version\\s+(1\\.[0-3][ac]?)\\s+of\\s+this\\s+license")
+	// the versions in the LPPL notices -> SPDX identifiers
+	lpplVersions = map[string]string{
+		"1.0": "LPPL-1.0", "1.1": "LPPL-1.1", "1.2": "LPPL-1.2", "1.3a": "LPPL-1.3a", "1.3c": "LPPL-1.3c",
+	}
+
+	// "if you create a document which uses this font, and embed this font ... into the document,
+	// this font does not by itself cause the resulting document to be covered by the GPL"
+	fontExceptionClauseRe = regexp.MustCompile("(?i)create\\s+a\\s+document\\s+which\\s+uses\\s+this\\s+font" +
+		"[\\s\\S]{0,300}?does\\s+not\\s+by\\s+itself\\s+cause\\s+the\\s+resulting\\s+document")
+	// "GPL with font exception", "GPLv3+ with the font exception"
+	fontExceptionTitleRe = regexp.MustCompile("(?i)\\b(?:gpl|general\\s+public\\s+license)(?:\\s*v?[23](?:\\.0)?)?" +
+		"\\+?,?\\s+(?:with|plus|\\+)\\s+(?:the\\s+)?font\\s+exception\\b")
+	// "GNU General Public License ... version 2", "GPLv3"
+	gplVersionRe = regexp.MustCompile("(?i)(?:general\\s+public\\s+license|\\bgpl)" +
+		"(?:[^.;]{0,80}?\\bversion\\s+|\\s*v)([23])(?:\\.0)?\\b(\\+?)")
+	gplLaterRe = regexp.MustCompile("(?i)\\bany\\s+later\\s+version\\b")
+
+	// the header of the sources of the Apache Software Foundation projects
+	asfNoticeRe = regexp.MustCompile("(?i)licensed\\s+to\\s+the\\s+apache\\s+software\\s+foundation\\s+" +
+		"(?:\\(asf\\)\\s+)?under\\s+one\\s+or\\s+more\\s+contributor\\s+license\\s+agreements")
+	asfLicenseRe = regexp.MustCompile("(?i)\\bunder\\s+the\\s+apache\\s+license,?\\s+version\\s+2\\.0\\b")
+)
+
+// lpplNoticeMaxLength is the maximum length of the text with the LPPL notice. The texts of
+// LPPL contain the same notice as the example, and they are matched fuzzily.
+const lpplNoticeMaxLength = 2048
+
+// RecognizeNotices finds the well-known license notices in the text. Unlike the fuzzy matching
+// of the license texts, the notices are recognized precisely.
+func RecognizeNotices(text []byte) []Notice {
+	var notices []Notice
+	str := string(text)
+	for _, recognizer := range noticeRecognizers {
+		notices = append(notices, recognizer(str)...)
+	}
+	return notices
+}
+
+// recognizeQtNotice resolves the "$QT_BEGIN_LICENSE:...$" headers of the Qt sources.
+// E.g. "LGPL" offers the commercial license or LGPL-3.0 (or GPL as the fallback), and
+// "GPL-EXCEPT" is GPL-3.0 with the Qt GPL exception. Qt 4 headers are LGPL-2.1 with
+// the Nokia Qt LGPL exception.
+func recognizeQtNotice(text string) []Notice {
+	var notices []Notice
+	for _, match := range qtLicenseMarkerRe.FindAllStringSubmatch(text, -1) {
+		notice, exists := qtLicenses[strings.ToUpper(match[1])]
+		if !exists {
+			// e.g. COMM - the commercial license only
+			continue
+		}
+		if strings.HasPrefix(notice.License, "LGPL") {
+			if qtLGPL21Re.MatchString(text) {
+				notice.License = "LGPL-2.1-only"
+			}
+			if qtLGPLExceptionRe.MatchString(text) {
+				notice.Exception = "Nokia-Qt-exception-1.1"
+			}
+		}
+		notice.Confidence = 0.95
+		notices = append(notices, notice)
+	}
+	return notices
+}
+
+// recognizeBeerwareNotice matches the single clause of "THE BEER-WARE LICENSE" by Poul-Henning
+// Kamp. It is too short for the fuzzy matching, which needs some minimum text length.
+func recognizeBeerwareNotice(text string) []Notice {
+	if !beerwareClauseRe.MatchString(text) {
+		return nil
+	}
+	notice := Notice{License: "Beerware", Confidence: 0.9}
+	if beerwareTitleRe.MatchString(text) {
+		notice.Confidence = 1
+	}
+	return []Notice{notice}
+}
+
+// recognizeLPPLNotice matches the standard notice of the LaTeX packages, "This work may be
+// distributed and/or modified under the conditions of the LaTeX Project Public License, either
+// version 1.3c of this license or (at your option) any later version."
+func recognizeLPPLNotice(text string) []Notice {
+	if len(text) > lpplNoticeMaxLength {
+		return nil
+	}
+	match := lpplNoticeRe.FindStringSubmatch(text)
+	if match == nil {
+		return nil
+	}
+	license, exists := lpplVersions[strings.ToLower(match[1])]
+	if !exists {
+		return nil
+	}
+	return []Notice{{License: license, Confidence: 0.95}}
+}
+
+// recognizeFontExceptionNotice matches the GPL font exception, which allows to embed the font
+// into the documents without covering them by the GPL. The version of the GPL is taken from
+// the same text; the GPL without the version allows to choose any version ever published.
+func recognizeFontExceptionNotice(text string) []Notice {
+	notice := Notice{Exception: "Font-exception-2.0", Confidence: 0.95}
+	if !fontExceptionClauseRe.MatchString(text) {
+		if !fontExceptionTitleRe.MatchString(text) {
+			return nil
+		}
+		notice.Confidence = 0.9
+	}
+	match := gplVersionRe.FindStringSubmatch(text)
+	if match == nil {
+		notice.License = "GPL-1.0-or-later"
+	} else if match[2] != "" || gplLaterRe.MatchString(text) {
+		notice.License = "GPL-" + match[1] + ".0-or-later"
+	} else {
+		notice.License = "GPL-" + match[1] + ".0-only"
+	}
+	return []Notice{notice}
+}
+
+// recognizeASFNotice matches the standard header of the Apache Software Foundation sources,
+// "Licensed to the Apache Software Foundation (ASF) under one or more contributor license
+// agreements", which refers to the license without including its text. The header licenses
+// the file "under the Apache License, Version 2.0"; without that sentence the confidence
+// is lower.
+func recognizeASFNotice(text string) []Notice {
+	if !asfNoticeRe.MatchString(text) {
+		return nil
+	}
+	notice := Notice{License: "Apache-2.0", Confidence: 0.9}
+	if asfLicenseRe.MatchString(text) {
+		notice.Confidence = 1
+	}
+	return []Notice{notice}
+}
+
+// RecognizePublicDomainDedication matches the free-form dedication of the work to the public
+// domain, e.g. "I dedicate this work to the public domain" or "This file is in the public
+// domain". The standard dedications, e.g. CC0, contain similar phrases, so this must be only
+// checked if nothing else is matched.
+func RecognizePublicDomainDedication(text []byte) []Notice {
+	for _, re := range []*regexp.Regexp{publicDomainVerbRe, publicDomainStateRe} {
+		for _, match := range re.FindAllIndex(text, -1) {
+			// e.g. "This work is not released into the public domain"
+			start := match[0] - publicDomainNegationWindow
+			if start < 0 {
+				start = 0
+			}
+			if boundary := bytes.LastIndexAny(text[start:match[0]], ".;!?"); boundary >= 0 {
+				start += boundary + 1
+			}
+			if !publicDomainNegationRe.Match(text[start:match[1]]) {
+				return []Notice{{License: PublicDomain, Confidence: publicDomainConfidence}}
+			}
+		}
+	}
+	return nil
+}
+
+// apacheNoticeConfidence is the confidence of the Apache NOTICE file without the license file.
+// NOTICE only accompanies the license, so the license may be different or absent after all.
+const apacheNoticeConfidence = 0.7
+
+// "Apache License, Version 2.0", "Apache License 2.0"; the mere credits of The Apache Software
+// Foundation are not enough because NOTICE often lists the bundled Apache dependencies.
+var apacheNoticeRe = regexp.MustCompile("(?i)\\bapache\\s+license,?\\s+(?:version\\s+)?2\\.0\\b")
+
+// RecognizeApacheNoticeFile matches the NOTICE file of the Apache License, e.g. "Licensed under
+// the Apache License, Version 2.0", and reports Apache-2.0 with the reduced confidence. It must
+// be only checked if there are no license files and nothing else declares the license.
+func RecognizeApacheNoticeFile(text []byte) []Notice {
+	if apacheNoticeRe.Match(text) {
+		return []Notice{{License: "Apache-2.0", Confidence: apacheNoticeConfidence}}
+	}
+	return nil
+}
+
+// NonCommercial is the pseudo license of the custom notices which restrict the use to
+// non-commercial or research purposes, e.g. "for non-commercial research use only", which are
+// common in the academic code. Such works are not open source.
+const NonCommercial = "non-commercial"
+
+// nonCommercialConfidence is the confidence of the non-commercial notice recognized by the
+// phrases; the rest of the custom text is unknown.
+const nonCommercialConfidence = 0.8
+
+// RecognizeNonCommercialNotice matches the phrases which restrict the use of the work to
+// non-commercial, academic or research purposes, e.g. "solely for non-commercial research"
+// or "commercial use is prohibited". The standard non-commercial licenses, e.g. CC-BY-NC-4.0,
+// contain similar phrases, so this must be only checked if nothing else is matched.
+func RecognizeNonCommercialNotice(text []byte) []Notice {
+	for _, re := range []*regexp.Regexp{nonCommercialUseRe, researchOnlyRe, commercialProhibitionRe} {
+		for _, match := range re.FindAllIndex(text, -1) {
+			prefix := text[:match[0]]
+			if len(prefix) > 24 {
+				prefix = prefix[len(prefix)-24:]
+			}
+			if !commercialAlternativeRe.Match(prefix) {
+				return []Notice{{License: NonCommercial, Confidence: nonCommercialConfidence}}
+			}
+		}
+	}
+	return nil
+}
+
+// CommonsClause is the name of the Commons Clause License Condition v1.0 rider, which forbids
+// to sell the software under an otherwise permissive license, e.g. Apache-2.0. It is not
+// an SPDX identifier, since the rider is not a license on its own.
+const CommonsClause = "Commons-Clause"
+
+// RecognizeRiders returns the names of the restrictions which are appended to the license text,
+// e.g. CommonsClause. The base license is still matched as usual.
+func RecognizeRiders(text []byte) []string {
+	if commonsClauseGrantRe.Match(text) ||
+		(commonsClauseTitleRe.Match(text) && bytes.Contains(bytes.ToLower(text), []byte("sell"))) {
+		return []string{CommonsClause}
+	}
+	return nil
+}
+
+// The field-of-use restrictions returned by RecognizeFieldOfUseRestrictions.
+const (
+	// FieldOfUseNonMilitary forbids the military use, e.g. "may not be used for military purposes".
+	FieldOfUseNonMilitary = "non-military"
+	// FieldOfUseGoodNotEvil is the clause of the JSON license, "The Software shall be used for
+	// Good, not Evil."
+	FieldOfUseGoodNotEvil = "good-not-evil"
+)
+
+var (
+	// "non-military use only", "shall not be used for military or weapons purposes",
+	// "military use is prohibited"
+	nonMilitaryRe = regexp.MustCompile("(?i)\\bnon-?\\s?military\\s+(?:use|uses|usage|purposes?)\\b|" +
+		"\\b(?:not|never)\\s+(?:be\\s+)?used\\s+(?:for|in|by)\\s+(?:any\\s+)?(?:the\\s+)?" +
+		"(?:military|weapons?)\\b|\\bmilitary\\s+(?:use|usage|purposes|applications)\\s+" +
+		"(?:is|are)\\s+(?:prohibited|forbidden|not\\s+(?:permitted|allowed))")
+	goodNotEvilRe = regexp.MustCompile("(?i)\\bused\\s+for\\s+good,?\\s+not\\s+evil\\b")
+)
+
+// RecognizeFieldOfUseRestrictions returns the names of the restrictions of the field of use in
+// the license text, e.g. FieldOfUseNonMilitary. Such licenses are not open source even if
+// the rest of the text is permissive.
+func RecognizeFieldOfUseRestrictions(text []byte) []string {
+	var restrictions []string
+	if nonMilitaryRe.Match(text) {
+		restrictions = append(restrictions, FieldOfUseNonMilitary)
+	}
+	if goodNotEvilRe.Match(text) {
+		restrictions = append(restrictions, FieldOfUseGoodNotEvil)
+	}
+	return restrictions
+}
+
+// rubyTermsConfidence is the confidence of the licenses of the "same terms as Ruby" phrase.
+// The phrase is certain, but the terms of Ruby changed over time.
+const rubyTermsConfidence = 0.9
+
+var (
+	// "distributed under the same terms as Ruby itself" of the Ruby gems
+	rubyTermsRe = regexp.MustCompile("(?i)\\bsame\\s+(?:license\\s+|terms\\s+|conditions\\s+)+" +
+		"(?:as|of)\\s+ruby\\b")
+	// the licenses of the Ruby interpreter which the Ruby gems mean by "the same terms as Ruby":
+	// the Ruby License dual licensed with GPL-2.0
+	rubyTermsLicenses = []string{"Ruby", "GPL-2.0-only"}
+)
+
+// RecognizeRubyTerms matches the phrase of the Ruby gems "released under the same terms as Ruby
+// itself" and returns the dual license of Ruby. It is checked in the README texts.
+func RecognizeRubyTerms(text []byte) []Notice {
+	if !rubyTermsRe.Match(text) {
+		return nil
+	}
+	notices := make([]Notice, len(rubyTermsLicenses))
+	for i, license := range rubyTermsLicenses {
+		notices[i] = Notice{License: license, Confidence: rubyTermsConfidence}
+	}
+	return notices
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+var (
+	// the machine-readable license overrides in the order of precedence, see
+	// ExtractLicenseOverride
+	overrideFiles = []string{
+		".license.yml", ".license.yaml", ".licensee.yml", ".licensee.yaml",
+		".github/license.yml", ".github/license.yaml",
+	}
+	// the top level "license: BSD-3-Clause" or "spdx-id: BSD-3-Clause"
+	overrideLicenseRe = regexp.MustCompile("(?m)^(?:licen[cs]e|spdx[-_]id)[ \\t]*:[ \\t]*([^\\r\\n]*)")
+	// the optional prefix of the authoritative license files, see ExtractAuthoritativeLicense
+	spdxIdentifierPrefixRe = regexp.MustCompile("(?i)^spdx-license-identifier[ \\t]*:[ \\t]*")
+)
+
+// IsLicenseOverrideFile indicates whether the file is the machine-readable license override,
+// e.g. .license.yml in the root or license.yml in .github.
+func IsLicenseOverrideFile(file string) bool {
+	file = strings.ToLower(file)
+	for _, name := range overrideFiles {
+		if file == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractLicenseOverride returns the SPDX license expression declared in the first license
+// override file among the files, see IsLicenseOverrideFile, and the path to that file.
+// The expression is empty if there is no override.
+func ExtractLicenseOverride(files []string, fs filer.Filer) (string, string) {
+	found := map[string]string{}
+	for _, file := range files {
+		if IsLicenseOverrideFile(file) {
+			found[strings.ToLower(file)] = file
+		}
+	}
+	for _, name := range overrideFiles {
+		file, exists := found[name]
+		if !exists {
+			continue
+		}
+		text, err := fs.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		if match := overrideLicenseRe.FindStringSubmatch(string(text)); match != nil {
+			if expression := yamlScalar(match[1]); expression != "" {
+				return file, expression
+			}
+		}
+	}
+	return "", ""
+}
+
+// ExtractAuthoritativeLicense returns the SPDX license expression written in the first of
+// the authoritative license files which exists among the files, e.g. SPDX.txt or LICENSE-ID,
+// and the path to that file. The names are compared case-insensitively. The expression is
+// the first line which is not empty and not a "#" comment, optionally prefixed with
+// "SPDX-License-Identifier:". It is empty if there is no such file.
+func ExtractAuthoritativeLicense(files []string, names []string, fs filer.Filer) (string, string) {
+	found := map[string]string{}
+	for _, file := range files {
+		found[strings.ToLower(file)] = file
+	}
+	for _, name := range names {
+		file, exists := found[strings.ToLower(name)]
+		if !exists {
+			continue
+		}
+		text, err := fs.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimPrefix(string(text), "\ufeff"), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if expression := spdxIdentifierPrefixRe.ReplaceAllString(line, ""); expression != "" {
+				return file, expression
+			}
+			break
+		}
+	}
+	return "", ""
+}
@@ -14,6 +14,9 @@ var (
 	htmlHeaderRe = regexp.MustCompile("^h[2-6]$")
 	htmlEntityRe = regexp.MustCompile("&((#\\d+)|([a-zA-Z]+));")
 	marksRe      = regexp.MustCompile("[#$%*/\\\\|><~`=!?.,:;\"'\\])}-]")
+	// e.g. <a name="license"> or <h2 id="licensing">
+	licenseAnchorRe = regexp.MustCompile("(?i)^(licen[cs](e|es|ing)|copying|copyright)$")
+	anyHTMLHeaderRe = regexp.MustCompile("^h[1-6]$")
 )
 
 func parseHTMLEntity(entName []byte) []byte {
@@ -150,3 +153,60 @@ func HTML(htmlSource []byte) []byte {
 	}
 	return result.Bytes()
 }
+
+// HTMLLicenseSection converts the section of the HTML document which follows the license
+// anchor, e.g. <h2 id="license"> or <a name="license">, to plain text. The section ends
+// at the next header of the same or a higher level. It returns nil if there is no such anchor.
+func HTMLLicenseSection(htmlSource []byte) []byte {
+	doc := html.NewTokenizer(bytes.NewReader(htmlSource))
+	offset := 0
+	begin, level := -1, 0
+	// the start of the header which is currently open - anchors are often nested in headers
+	headerBegin, headerLevel := -1, 0
+	for token := doc.Next(); token != html.ErrorToken; token = doc.Next() {
+		pos := offset
+		offset += len(doc.Raw())
+		if token != html.StartTagToken && token != html.EndTagToken {
+			continue
+		}
+		tagName, hasAttr := doc.TagName()
+		isHeader := anyHTMLHeaderRe.Match(tagName)
+		if token == html.EndTagToken {
+			if isHeader {
+				headerBegin = -1
+			}
+			continue
+		}
+		if isHeader {
+			headerLevel = int(tagName[1] - '0')
+			if begin >= 0 && level == 0 {
+				// the anchor preceded its header
+				level = headerLevel
+				continue
+			}
+			if begin >= 0 && headerLevel <= level {
+				return HTML(htmlSource[begin:pos])
+			}
+			headerBegin = pos
+		}
+		if begin >= 0 || !hasAttr {
+			continue
+		}
+		for key, val, more := doc.TagAttr(); key != nil; key, val, more = doc.TagAttr() {
+			if (string(key) == "id" || string(key) == "name") && licenseAnchorRe.Match(val) {
+				begin = pos
+				if headerBegin >= 0 {
+					begin, level = headerBegin, headerLevel
+				}
+				break
+			}
+			if !more {
+				break
+			}
+		}
+	}
+	if begin < 0 {
+		return nil
+	}
+	return HTML(htmlSource[begin:])
+}
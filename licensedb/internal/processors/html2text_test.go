@@ -43,3 +43,26 @@ hello
 
 http://foo`, string(HTML([]byte(text))))
 }
+
+func TestHTMLLicenseSection(t *testing.T) {
+	page := []byte(`<html><body>
+<h1>Foo</h1>
+<p>Foo wraps the GPL-licensed tool bar.</p>
+<h2 id="install">Install</h2>
+<p>Run make.</p>
+<h2 id="license">License</h2>
+<p>Foo is released under the MIT license.</p>
+<h3>Third party</h3>
+<p>See NOTICE.</p>
+<h2>Contributing</h2>
+<p>Send patches under the Apache License.</p>
+</body></html>`)
+	assert.Equal(t, "License.\nFoo is released under the MIT license.\n"+
+		"Third party.\nSee NOTICE.\n", string(HTMLLicenseSection(page)))
+	page = []byte(`<h2>Usage</h2><p>Run it.</p>
+<a name="license"></a><h2>Legal</h2><p>MIT</p><h2>Authors</h2><p>Alice</p>`)
+	assert.Equal(t, "Legal.MIT", string(HTMLLicenseSection(page)))
+	page = []byte(`<h2><a name="licensing"></a>Licensing</h2><p>MIT</p><h1>Other</h1>`)
+	assert.Equal(t, "Licensing.MIT", string(HTMLLicenseSection(page)))
+	assert.Nil(t, HTMLLicenseSection([]byte("<h2>License</h2><p>MIT</p>")))
+}
@@ -0,0 +1,237 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	yamlKeyRe = regexp.MustCompile("^(\"[^\"]*\"|'[^']*'|[\\w.-]+)[ \\t]*:(?:[ \\t]+(.*))?$")
+	// the values which mean that the license is unknown in SPDX
+	spdxNoAssertions = map[string]bool{"NOASSERTION": true, "NONE": true}
+)
+
+// yamlLine is a single line of the YAML document: either the mapping key with the value or
+// the scalar list item.
+type yamlLine struct {
+	// indent is the column of the key or of the scalar, after "- " if the line is a list item
+	indent int
+	// item indicates that the line starts a list item
+	item bool
+	// key is empty for the scalar list item
+	key   string
+	value string
+}
+
+// splitYAMLLines parses the block style YAML which is enough for the SBOMs: the mappings,
+// the lists and the plain or quoted scalars. The comments and the blank lines are skipped.
+func splitYAMLLines(text string) []yamlLine {
+	var lines []yamlLine
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		rest := strings.TrimLeft(line, " ")
+		if rest == "" || rest[0] == '#' || rest == "---" {
+			continue
+		}
+		parsed := yamlLine{indent: len(line) - len(rest)}
+		if rest == "-" || strings.HasPrefix(rest, "- ") {
+			parsed.item = true
+			trimmed := strings.TrimLeft(rest[1:], " ")
+			parsed.indent += len(rest) - len(trimmed)
+			rest = trimmed
+		}
+		if match := yamlKeyRe.FindStringSubmatch(rest); match != nil {
+			parsed.key = unquoteYAMLValue(match[1])
+			rest = match[2]
+		}
+		parsed.value = unquoteYAMLValue(rest)
+		lines = append(lines, parsed)
+	}
+	return lines
+}
+
+// unquoteYAMLValue removes the quotes around the scalar or the trailing comment after it.
+func unquoteYAMLValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[1 : end+1]
+		}
+		return value
+	}
+	return strings.TrimSpace(yamlCommentRe.ReplaceAllString(value, ""))
+}
+
+// splitYAMLFlowList returns the items of the inline list, e.g. "[MIT, Apache-2.0]".
+func splitYAMLFlowList(value string) []string {
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(value[1:len(value)-1], ",") {
+		if item = unquoteYAMLValue(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseYAMLSBOM returns the licenses declared in the YAML software bill of materials: either
+// the SPDX document, see parseSPDXYAML, or any other with the list of the licenses, see
+// parseYAMLLicensesList.
+func parseYAMLSBOM(text string) []string {
+	lines := splitYAMLLines(text)
+	for _, line := range lines {
+		if line.indent == 0 && line.key == "spdxVersion" {
+			return parseSPDXYAML(lines)
+		}
+	}
+	return parseYAMLLicensesList(lines)
+}
+
+// spdxPackage is the package in the SPDX document.
+type spdxPackage struct {
+	id        string
+	declared  string
+	concluded string
+}
+
+// license returns the declared license of the package or the concluded one if the former is
+// unknown.
+func (pkg *spdxPackage) license() string {
+	for _, license := range []string{pkg.declared, pkg.concluded} {
+		if license != "" && !spdxNoAssertions[license] {
+			return license
+		}
+	}
+	return ""
+}
+
+// parseSPDXYAML returns the licenses of the packages which the SPDX document describes, e.g.
+//
+//	documentDescribes:
+//	  - SPDXRef-Package
+//	packages:
+//	  - name: foo
+//	    SPDXID: SPDXRef-Package
+//	    licenseDeclared: MIT OR Apache-2.0
+//
+// The other packages are the dependencies. If the document does not tell which packages
+// it describes, the first package is taken. The license of the document itself, dataLicense,
+// is always CC0-1.0 and is ignored.
+func parseSPDXYAML(lines []yamlLine) []string {
+	var packages []*spdxPackage
+	described := map[string]bool{}
+	var section string
+	// the fields of the current list item in the section at the same indent
+	itemIndent := -1
+	var pkg *spdxPackage
+	var relationship map[string]string
+	flushRelationship := func() {
+		if relationship != nil && relationship["spdxElementId"] == "SPDXRef-DOCUMENT" &&
+			relationship["relationshipType"] == "DESCRIBES" {
+			described[relationship["relatedSpdxElement"]] = true
+		}
+		relationship = nil
+	}
+	for _, line := range lines {
+		if line.indent == 0 && !line.item {
+			flushRelationship()
+			section, itemIndent, pkg = line.key, -1, nil
+			if section == "documentDescribes" && line.value != "" {
+				ids := splitYAMLFlowList(line.value)
+				if ids == nil {
+					ids = []string{line.value}
+				}
+				for _, id := range ids {
+					described[id] = true
+				}
+			}
+			continue
+		}
+		if line.item && (itemIndent < 0 || line.indent == itemIndent) {
+			itemIndent = line.indent
+			switch section {
+			case "packages":
+				pkg = &spdxPackage{}
+				packages = append(packages, pkg)
+			case "relationships":
+				flushRelationship()
+				relationship = map[string]string{}
+			}
+		}
+		if line.indent != itemIndent {
+			continue
+		}
+		switch section {
+		case "documentDescribes":
+			if line.key == "" {
+				described[line.value] = true
+			}
+		case "packages":
+			switch line.key {
+			case "SPDXID":
+				pkg.id = line.value
+			case "licenseDeclared":
+				pkg.declared = line.value
+			case "licenseConcluded":
+				pkg.concluded = line.value
+			}
+		case "relationships":
+			relationship[line.key] = line.value
+		}
+	}
+	flushRelationship()
+	var licenses []string
+	for _, pkg := range packages {
+		if license := pkg.license(); license != "" && described[pkg.id] {
+			licenses = append(licenses, license)
+		}
+	}
+	if len(described) == 0 && len(packages) > 0 {
+		if license := packages[0].license(); license != "" {
+			licenses = append(licenses, license)
+		}
+	}
+	return licenses
+}
+
+// parseYAMLLicensesList returns the items of the first "licenses" list in the document,
+// either the plain license identifiers or the mappings with the id, the name
+// or the expression, e.g. in the CycloneDX bill of materials:
+//
+//	metadata:
+//	  component:
+//	    licenses:
+//	      - license:
+//	          id: MIT
+//	      - expression: MIT OR Apache-2.0
+func parseYAMLLicensesList(lines []yamlLine) []string {
+	var licenses []string
+	for i, line := range lines {
+		if line.key != "licenses" {
+			continue
+		}
+		if line.value != "" {
+			if items := splitYAMLFlowList(line.value); items != nil {
+				return items
+			}
+			return []string{line.value}
+		}
+		for _, item := range lines[i+1:] {
+			if item.indent <= line.indent {
+				break
+			}
+			switch item.key {
+			case "":
+				licenses = append(licenses, item.value)
+			case "id", "name", "expression":
+				if item.value != "" {
+					licenses = append(licenses, item.value)
+				}
+			}
+		}
+		return licenses
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal/normalize"
+)
+
+// The sections of the license text which the matched text contains, see
+// InvestigateLicenseSections.
+const (
+	// SectionsFull is the grant with the conditions followed by the warranty disclaimer.
+	SectionsFull = "full"
+	// SectionsGrantOnly is the grant with the conditions without the warranty disclaimer.
+	SectionsGrantOnly = "grant-only"
+	// SectionsDisclaimerOnly is the warranty disclaimer alone, e.g. in the attribution blocks.
+	SectionsDisclaimerOnly = "disclaimer-only"
+)
+
+// sectionCoverageThreshold is the minimum share of the tokens of the license section which
+// must be present in the text to consider the section present.
+const sectionCoverageThreshold = 0.5
+
+// disclaimerRe finds the beginning of the warranty disclaimer in the normalized license text,
+// e.g. `the software is provided "as is"` in MIT or "disclaimer of warranty" in Apache-2.0.
+var disclaimerRe = regexp.MustCompile(
+	"(^|\\s)((?:this|the) [a-z ]{0,40}?(?:is|are) provided\\b|disclaimer of warrant|\"as is\")")
+
+// disclaimer is the warranty disclaimer in the normalized license text. It lasts till the end
+// of the text, e.g. the limitation of liability is a part of it.
+type disclaimer struct {
+	// start is the byte offset of the disclaimer in the text
+	start int
+	// offset is the number of the tokens before the disclaimer
+	offset int
+	// length is the number of the tokens in the disclaimer
+	length int
+}
+
+// findDisclaimer locates the warranty disclaimer in the normalized license text. The licenses
+// which start with the disclaimer do not have it as a separate section.
+func (db *database) findDisclaimer(normedText string) (disclaimer, bool) {
+	match := disclaimerRe.FindStringSubmatchIndex(normedText)
+	if match == nil {
+		return disclaimer{}, false
+	}
+	section := disclaimer{
+		start:  match[4],
+		offset: db.countTokens(normedText[:match[4]]),
+		length: db.countTokens(normedText[match[4]:]),
+	}
+	if section.offset == 0 || section.length == 0 {
+		return disclaimer{}, false
+	}
+	return section, true
+}
+
+// countTokens returns the number of the tokens in the normalized text.
+func (db *database) countTokens(normedText string) int {
+	count := 0
+	for _, line := range strings.Split(normedText, "\n") {
+		count += len(db.tokenizer.Tokenize(line))
+	}
+	return count
+}
+
+// QueryLicenseSections classifies the text as SectionsFull, SectionsGrantOnly or
+// SectionsDisclaimerOnly by the sections of each of the matched licenses it contains.
+// The licenses without the separate warranty disclaimer are not classified.
+func (db *database) QueryLicenseSections(text string, licenses []string) map[string]string {
+	normalizedModerate := normalize.LicenseText(text, normalize.Moderate)
+	sections := map[string]string{}
+	for _, key := range licenses {
+		section, exists := db.disclaimers[key]
+		if !exists {
+			continue
+		}
+		_, _, diff := db.diffLicenseText(normalizedModerate, db.licenseTexts[key])
+		// the number of the matched tokens of the license before the disclaimer and in it
+		var grant, disclaimer, position int
+		for _, op := range diff {
+			size := len([]rune(op.Text))
+			switch op.Type {
+			case diffmatchpatch.DiffEqual:
+				before := section.offset - position
+				if before < 0 {
+					before = 0
+				} else if before > size {
+					before = size
+				}
+				grant += before
+				disclaimer += size - before
+				position += size
+			case diffmatchpatch.DiffInsert:
+				position += size
+			}
+		}
+		hasGrant := float32(grant) >= sectionCoverageThreshold*float32(section.offset)
+		hasDisclaimer := float32(disclaimer) >= sectionCoverageThreshold*float32(section.length)
+		switch {
+		case hasGrant && hasDisclaimer:
+			sections[key] = SectionsFull
+		case hasGrant:
+			sections[key] = SectionsGrantOnly
+		case hasDisclaimer:
+			sections[key] = SectionsDisclaimerOnly
+		}
+	}
+	return sections
+}
+
+// QueryLicenseDisclaimer returns the licenses whose warranty disclaimer is the most similar to
+// the text, e.g. the BSD disclaimer copied alone. The similarity is the same as in
+// QueryLicenseText, so all the licenses which share the disclaimer are returned.
+func (db *database) QueryLicenseDisclaimer(text string) map[string]float32 {
+	normalizedModerate := normalize.LicenseText(text, normalize.Moderate)
+	size := db.countTokens(normalizedModerate)
+	// the disclaimer text -> the similarity, many licenses share the same disclaimer
+	similarities := map[string]float32{}
+	candidates := map[string]float32{}
+	dmp := diffmatchpatch.New()
+	for key, section := range db.disclaimers {
+		// the edit distance is at least the difference of the lengths
+		difference := section.length - size
+		if difference < 0 {
+			difference = -difference
+		}
+		if float32(difference) > (1-similarityThreshold)*float32(size) {
+			continue
+		}
+		disclaimerText := db.licenseTexts[key][section.start:]
+		similarity, exists := similarities[disclaimerText]
+		if !exists {
+			myRunes, _, diff := db.diffLicenseText(normalizedModerate, disclaimerText)
+			if len(myRunes) > 0 {
+				similarity = float32(1) - float32(dmp.DiffLevenshtein(diff))/float32(len(myRunes))
+			}
+			similarities[disclaimerText] = similarity
+		}
+		if similarity >= similarityThreshold {
+			candidates[key] = similarity
+		}
+	}
+	// the other disclaimers are similar only because they are the variations of the best one
+	var best float32
+	for _, similarity := range candidates {
+		if similarity > best {
+			best = similarity
+		}
+	}
+	for key, similarity := range candidates {
+		if similarity < best {
+			delete(candidates, key)
+		}
+	}
+	return candidates
+}
+
+// QueryLicenseGrant returns the licenses whose text before the warranty disclaimer is similar
+// to the text, e.g. MIT without the "as is" paragraph, since the LSH of QueryLicenseText
+// does not find the texts which lack a large section. The similarity is the same as in
+// QueryLicenseText.
+func (db *database) QueryLicenseGrant(text string) map[string]float32 {
+	normalizedModerate := normalize.LicenseText(text, normalize.Moderate)
+	size := db.countTokens(normalizedModerate)
+	candidates := map[string]float32{}
+	dmp := diffmatchpatch.New()
+	for key, section := range db.disclaimers {
+		// the edit distance is at least the difference of the lengths
+		difference := section.offset - size
+		if difference < 0 {
+			difference = -difference
+		}
+		if float32(difference) > (1-similarityThreshold)*float32(size) {
+			continue
+		}
+		myRunes, _, diff := db.diffLicenseText(normalizedModerate, db.licenseTexts[key][:section.start])
+		if len(myRunes) == 0 {
+			continue
+		}
+		similarity := float32(1) - float32(dmp.DiffLevenshtein(diff))/float32(len(myRunes))
+		if similarity >= similarityThreshold {
+			if license, exists := db.portions[key]; exists {
+				key = license
+			}
+			if candidates[key] < similarity {
+				candidates[key] = similarity
+			}
+		}
+	}
+	return candidates
+}
+
+// InvestigateLicenseSections classifies the text by the sections of each of the matched
+// licenses which it contains, see SectionsFull. The texts are compared like in
+// InvestigateLicenseTextTokenized.
+func InvestigateLicenseSections(text []byte, licenses []string, db *Database) map[string]string {
+	return db.QueryLicenseSections(string(text), licenses)
+}
+
+// InvestigateLicenseDisclaimer returns the licenses whose warranty disclaimer is the whole text.
+// Such texts are too short to match the full licenses, see SectionsDisclaimerOnly.
+func InvestigateLicenseDisclaimer(text []byte, db *Database) map[string]float32 {
+	return db.QueryLicenseDisclaimer(string(text))
+}
+
+// InvestigateLicenseGrant returns the licenses whose text without the warranty disclaimer is
+// similar to the text, see SectionsGrantOnly.
+func InvestigateLicenseGrant(text []byte, db *Database) map[string]float32 {
+	return db.QueryLicenseGrant(string(text))
+}
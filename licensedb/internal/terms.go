@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal/normalize"
+)
+
+// minAdditionalTermsTokens is the minimum number of the tokens after the end of the license
+// text to consider them the additional terms, so that e.g. the trailing signature is ignored.
+const minAdditionalTermsTokens = 5
+
+// maxAdditionalTermsParagraphs is the maximum number of the trailing paragraphs which
+// QueryLicenseTextBeforeTerms drops to find the license.
+const maxAdditionalTermsParagraphs = 3
+
+// similarity returns the similarity of the normalized text to the registered license like
+// QueryLicenseText does.
+func (db *database) similarity(normalizedModerate string, key string) float32 {
+	myRunes, _, diff := db.diffLicenseText(normalizedModerate, db.licenseTexts[key])
+	if len(myRunes) == 0 {
+		return 0
+	}
+	distance := diffmatchpatch.New().DiffLevenshtein(diff)
+	return float32(1) - float32(distance)/float32(len(myRunes))
+}
+
+// QueryAdditionalTerms returns the trailing paragraphs of the text which follow the end of
+// each of the matched licenses, e.g. the custom restriction appended to MIT. The paragraphs
+// are dropped from the end one by one while the rest of the text becomes more similar to
+// the license, and the dropped paragraphs are returned as is. The licenses which the text
+// ends with are not included.
+func (db *database) QueryAdditionalTerms(text string, licenses []string) map[string]string {
+	paragraphs := paragraphBreakRe.Split(strings.TrimSpace(text), -1)
+	terms := map[string]string{}
+	for _, key := range licenses {
+		if _, exists := db.licenseTexts[key]; !exists {
+			continue
+		}
+		best := db.similarity(normalize.LicenseText(text, normalize.Moderate), key)
+		end := len(paragraphs)
+		for end > 1 {
+			prefix := strings.Join(paragraphs[:end-1], "\n\n")
+			similarity := db.similarity(normalize.LicenseText(prefix, normalize.Moderate), key)
+			if similarity <= best {
+				break
+			}
+			best = similarity
+			end--
+		}
+		if end == len(paragraphs) {
+			continue
+		}
+		tail := strings.Join(paragraphs[end:], "\n\n")
+		if db.countTokens(normalize.LicenseText(tail, normalize.Moderate)) < minAdditionalTermsTokens {
+			continue
+		}
+		terms[key] = tail
+	}
+	return terms
+}
+
+// QueryLicenseTextBeforeTerms matches the text like QueryLicenseText with the trailing
+// paragraphs dropped one by one, at most maxAdditionalTermsParagraphs, since the appended
+// terms may make the whole text too different from the license to find it.
+func (db *database) QueryLicenseTextBeforeTerms(text string) map[string]float32 {
+	paragraphs := paragraphBreakRe.Split(strings.TrimSpace(text), -1)
+	for end := len(paragraphs) - 1; end > 0 && len(paragraphs)-end <= maxAdditionalTermsParagraphs; end-- {
+		if licenses := db.QueryLicenseText(strings.Join(paragraphs[:end], "\n\n")); len(licenses) > 0 {
+			return licenses
+		}
+	}
+	return map[string]float32{}
+}
+
+// InvestigateAdditionalTerms returns the terms which the text appends to each of the matched
+// licenses, see QueryAdditionalTerms. The texts are compared like in
+// InvestigateLicenseTextTokenized.
+func InvestigateAdditionalTerms(text []byte, licenses []string, db *Database) map[string]string {
+	return db.QueryAdditionalTerms(string(text), licenses)
+}
+
+// InvestigateLicenseTextBeforeTerms returns the licenses which the text appends the terms to
+// if it does not match any as a whole, see QueryLicenseTextBeforeTerms.
+func InvestigateLicenseTextBeforeTerms(text []byte, db *Database) map[string]float32 {
+	return db.QueryLicenseTextBeforeTerms(string(text))
+}
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"fmt"
+	paths "path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+var (
+	// THIRD_PARTY_NOTICES.txt, ThirdPartyNotices.txt, third-party-licenses.txt
+	thirdPartyFileRe = regexp.MustCompile(fmt.Sprintf(
+		"^third[-_ ]?party[-_ ]?(notices?|li[cs]en[cs]es?|credits)(%s)$",
+		strings.Replace(strings.Join(fileExtensions, "|"), ".", "\\.", -1)))
+	// "-----", "=====", "*****"
+	thirdPartySeparatorRe = regexp.MustCompile("^[-=*_~#]{3,}$")
+	// "%% Foo NOTICES AND INFORMATION BEGIN HERE" of Microsoft, "## Foo" of Markdown
+	thirdPartyHeaderRe = regexp.MustCompile("(?i)^(?:%%|#{1,6}\\s)\\s*(.*?)(?:\\s+notices\\s+and\\s+information" +
+		"(?:\\s+begin\\s+here)?)?$")
+	// "END OF Foo NOTICES AND INFORMATION"
+	thirdPartyFooterRe = regexp.MustCompile("(?i)^end\\s+of\\s+.*\\s+notices\\s+and\\s+information$")
+)
+
+const (
+	// maxThirdPartySections is the maximum number of the sections of the third party notices
+	// file which are investigated.
+	maxThirdPartySections = 256
+	// maxThirdPartyHeaderLines is the maximum number of the non-empty lines of the section
+	// which is the header of the next section, e.g. the name and the version of the component.
+	maxThirdPartyHeaderLines = 3
+)
+
+// ThirdPartyLicense is the license of a component in the aggregated third party notices file.
+type ThirdPartyLicense struct {
+	// Component is the header of the section, e.g. the name of the component, if any.
+	Component string
+	// License is the SPDX identifier of the best matched license.
+	License string
+	// Confidence is from 0 to 1, 1 means 100% confident.
+	Confidence float32
+}
+
+// IsThirdPartyNoticesFile indicates whether the file aggregates the licenses of the bundled
+// third party components, e.g. THIRD_PARTY_NOTICES.txt. Such files are not the license files.
+func IsThirdPartyNoticesFile(fileName string) bool {
+	return thirdPartyFileRe.MatchString(strings.ToLower(paths.Base(fileName)))
+}
+
+// ExtractThirdPartyNotices returns the texts of the third party notices files mapped from
+// the file paths, see IsThirdPartyNoticesFile.
+func ExtractThirdPartyNotices(files []string, fs filer.Filer) map[string][]byte {
+	notices := map[string][]byte{}
+	for _, file := range files {
+		if IsThirdPartyNoticesFile(file) {
+			text, err := fs.ReadFile(file)
+			if err == nil {
+				notices[file] = text
+			}
+		}
+	}
+	return notices
+}
+
+// InvestigateThirdPartyNotices splits the aggregated third party notices by the separator
+// lines, e.g. "-----", and by the headers, e.g. "## Foo", and matches each section against
+// the reference licenses. The short sections which match nothing are the headers of the next
+// sections. The components are returned in the order of the sections.
+func InvestigateThirdPartyNotices(text []byte, db *Database) []ThirdPartyLicense {
+	var components []ThirdPartyLicense
+	var header string
+	var section []string
+	sections := 0
+	flush := func() {
+		body := strings.TrimSpace(strings.Join(section, "\n"))
+		section = section[:0]
+		if body == "" || sections >= maxThirdPartySections {
+			return
+		}
+		sections++
+		lines := nonEmptyLines(body)
+		license, confidence := bestLicense(db.QueryLicenseText(body))
+		if license == "" {
+			if len(lines) <= maxThirdPartyHeaderLines {
+				header = strings.TrimSpace(strings.Trim(lines[0], "#=*-"))
+			} else {
+				header = ""
+			}
+			return
+		}
+		component := header
+		if component == "" {
+			component = strings.TrimSpace(strings.Trim(lines[0], "#=*-"))
+		}
+		header = ""
+		components = append(components, ThirdPartyLicense{
+			Component: component, License: license, Confidence: confidence})
+	}
+	for _, line := range strings.Split(strings.Replace(string(text), "\r\n", "\n", -1), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case thirdPartySeparatorRe.MatchString(trimmed):
+			flush()
+		case thirdPartyFooterRe.MatchString(trimmed):
+			continue
+		case thirdPartyHeaderRe.MatchString(trimmed):
+			flush()
+			header = thirdPartyHeaderRe.FindStringSubmatch(trimmed)[1]
+		default:
+			section = append(section, line)
+		}
+	}
+	flush()
+	return components
+}
+
+// nonEmptyLines returns the trimmed non-empty lines of the text.
+func nonEmptyLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// bestLicense returns the most confident license, the first by name if there are several.
+func bestLicense(licenses map[string]float32) (string, float32) {
+	names := make([]string, 0, len(licenses))
+	for name := range licenses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var best string
+	var confidence float32
+	for _, name := range names {
+		if licenses[name] > confidence {
+			best = name
+			confidence = licenses[name]
+		}
+	}
+	return best, confidence
+}
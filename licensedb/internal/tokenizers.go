@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Tokenizer splits the line of the normalized license text into the tokens which the fuzzy
+// matching compares. The reference licenses are indexed once per Tokenizer, so it must be
+// comparable, e.g. a struct without slices and maps or a pointer.
+type Tokenizer interface {
+	Tokenize(line string) []string
+}
+
+var (
+	// WhitespaceTokenizer splits the words by the spaces. It is the default.
+	WhitespaceTokenizer Tokenizer = whitespaceTokenizer{}
+	// CJKTokenizer splits the words by the spaces and additionally treats each Chinese,
+	// Japanese and Korean character as a separate token, since these scripts do not separate
+	// the words, e.g. in the Chinese half of MulanPSL-2.0.
+	CJKTokenizer Tokenizer = cjkTokenizer{}
+
+	tokenizedLicenseDBs struct {
+		sync.Mutex
+		databases map[Tokenizer]*database
+	}
+)
+
+type whitespaceTokenizer struct{}
+
+func (whitespaceTokenizer) Tokenize(line string) []string {
+	return strings.Split(line, " ")
+}
+
+type cjkTokenizer struct{}
+
+func (cjkTokenizer) Tokenize(line string) []string {
+	var tokens []string
+	for _, word := range strings.Split(line, " ") {
+		start := 0
+		for i, char := range word {
+			if !isCJK(char) {
+				continue
+			}
+			if start < i {
+				tokens = append(tokens, word[start:i])
+			}
+			start = i + len(string(char))
+			tokens = append(tokens, word[i:start])
+		}
+		if start < len(word) || len(word) == 0 {
+			tokens = append(tokens, word[start:])
+		}
+	}
+	return tokens
+}
+
+// cjkPortionSuffix is appended to the license name to register its CJK portion.
+const cjkPortionSuffix = "#cjk"
+
+// cjkPortion returns the lines of the normalized license text which contain Chinese, Japanese
+// or Korean characters if the text is bilingual, e.g. the Chinese half of MulanPSL-2.0.
+// Otherwise, it returns the empty string.
+func cjkPortion(text string) string {
+	lines := strings.Split(text, "\n")
+	var portion []string
+	for _, line := range lines {
+		if strings.IndexFunc(line, isCJK) >= 0 {
+			portion = append(portion, line)
+		}
+	}
+	if len(portion) < 10 || len(portion) > len(lines)*9/10 {
+		return ""
+	}
+	return strings.Join(portion, "\n")
+}
+
+func isCJK(char rune) bool {
+	return unicode.In(char, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// Database is the index of the reference licenses which the texts are matched against.
+type Database = database
+
+// NewDatabase indexes the reference licenses with the tokenizer, WhitespaceTokenizer if nil.
+// Unlike DefaultDatabase, the returned instance is not shared and it is not cached.
+func NewDatabase(tokenizer Tokenizer) *Database {
+	if tokenizer == nil {
+		tokenizer = WhitespaceTokenizer
+	}
+	return loadLicenses(tokenizer)
+}
+
+// DefaultDatabase returns the shared reference licenses indexed with the tokenizer, which are
+// loaded on the first use. The global database is used if the tokenizer is nil. It returns
+// ErrDefaultDatabaseDisabled if the default databases are disabled, see DisableDefaultDatabase.
+func DefaultDatabase(tokenizer Tokenizer) (*Database, error) {
+	if IsDefaultDatabaseDisabled() {
+		return nil, ErrDefaultDatabaseDisabled
+	}
+	if tokenizer == nil || tokenizer == WhitespaceTokenizer {
+		return globalLicenseDatabase(), nil
+	}
+	tokenizedLicenseDBs.Lock()
+	defer tokenizedLicenseDBs.Unlock()
+	if tokenizedLicenseDBs.databases == nil {
+		tokenizedLicenseDBs.databases = map[Tokenizer]*database{}
+	}
+	db := tokenizedLicenseDBs.databases[tokenizer]
+	if db == nil {
+		db = loadLicenses(tokenizer)
+		tokenizedLicenseDBs.databases[tokenizer] = db
+	}
+	return db, nil
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCJKTokenizer(t *testing.T) {
+	assert.Equal(t, []string{"授", "予", "版", "权", "许", "可"}, CJKTokenizer.Tokenize("授予版权许可"))
+	assert.Equal(t, []string{"mulan", "psl", "v2", "第", "2", "版", "，", "50%"},
+		CJKTokenizer.Tokenize("mulan psl v2 第2版， 50%"))
+	assert.Equal(t, WhitespaceTokenizer.Tokenize("grant of  license"),
+		CJKTokenizer.Tokenize("grant of  license"))
+}
+
+func TestCJKPortion(t *testing.T) {
+	assert.Equal(t, "", cjkPortion("mit license\n\npermission is hereby granted"))
+	db, err := DefaultDatabase(CJKTokenizer)
+	assert.Nil(t, err)
+	portion := db.licenseTexts["MulanPSL-2.0"+cjkPortionSuffix]
+	assert.Contains(t, portion, "木兰宽松许可证")
+	assert.NotContains(t, portion, "grant of copyright license")
+	assert.Equal(t, globalLicenseDatabase().Length(), db.Length())
+}
+
+func TestDefaultDatabaseDisabled(t *testing.T) {
+	DisableDefaultDatabase(true)
+	defer DisableDefaultDatabase(false)
+	_, err := DefaultDatabase(nil)
+	assert.Equal(t, ErrDefaultDatabaseDisabled, err)
+	_, err = DefaultDatabase(CJKTokenizer)
+	assert.Equal(t, ErrDefaultDatabaseDisabled, err)
+	_, err = InvestigateLicenseText([]byte("MIT License"))
+	assert.Equal(t, ErrDefaultDatabaseDisabled, err)
+	_, err = InvestigateLicenseTextUnordered([]byte("MIT License"))
+	assert.Equal(t, ErrDefaultDatabaseDisabled, err)
+	_, err = InvestigateLicenseTexts(map[string][]byte{"LICENSE": []byte("MIT License")})
+	assert.Equal(t, ErrDefaultDatabaseDisabled, err)
+	_, err = InvestigateReadmeTexts(map[string][]byte{"README": []byte("MIT License")}, nil)
+	assert.Equal(t, ErrDefaultDatabaseDisabled, err)
+	_, err = LicenseKey("MIT")
+	assert.Equal(t, ErrDefaultDatabaseDisabled, err)
+}
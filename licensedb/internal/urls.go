@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+)
+
+// wellKnownURLConfidence is assigned to the licenses which are referenced by a well-known
+// URL pattern rather than by the exact URL in urls.csv.
+const wellKnownURLConfidence = 0.8
+
+// wellKnownURL maps the URLs of a license steward to the SPDX license identifiers.
+type wellKnownURL struct {
+	re *regexp.Regexp
+	// converts the submatches of re to the SPDX license identifier
+	id func(match []string) string
+}
+
+var (
+	gnuLicenseNames = map[string]string{"gpl": "GPL", "lgpl": "LGPL", "agpl": "AGPL", "fdl": "GFDL"}
+	// the latest versions of the GNU licenses, e.g. for https://www.gnu.org/licenses/gpl.html
+	gnuLatestVersions = map[string]string{"gpl": "3.0", "lgpl": "3.0", "agpl": "3.0", "fdl": "1.3"}
+
+	// the "-standalone" GNU URLs are in urls.csv
+	wellKnownURLs = []wellKnownURL{
+		{
+			re: regexp.MustCompile(
+				"(?i)//(?:www\\.)?gnu\\.org/licenses/(?:old-licenses/)?(a?gpl|lgpl|fdl)(?:-(\\d\\.\\d))?(?:\\.[a-z]{2})?(?:\\.html|\\.txt)?(?:[^\\w/-]|$)"),
+			id: func(match []string) string {
+				version := match[2]
+				if version == "" {
+					version = gnuLatestVersions[strings.ToLower(match[1])]
+				}
+				return gnuLicenseNames[strings.ToLower(match[1])] + "-" + version
+			},
+		},
+		{
+			re: regexp.MustCompile("(?i)//(?:www\\.)?opensource\\.org/licenses?/([a-z0-9.+-]*[a-z0-9+])"),
+			id: func(match []string) string {
+				id := strings.TrimSuffix(strings.TrimSuffix(match[1], ".php"), ".html")
+				return strings.TrimSuffix(strings.TrimSuffix(id, "-license"), "-License")
+			},
+		},
+		{
+			re: regexp.MustCompile("(?i)//(?:www\\.)?apache\\.org/licenses/LICENSE-(\\d\\.\\d)"),
+			id: func(match []string) string {
+				return "Apache-" + match[1]
+			},
+		},
+		{
+			re: regexp.MustCompile("(?i)//(?:www\\.)?creativecommons\\.org/licenses/(by(?:-nc)?(?:-sa|-nd)?)/(\\d\\.\\d)"),
+			id: func(match []string) string {
+				return "CC-" + strings.ToUpper(match[1]) + "-" + match[2]
+			},
+		},
+		{
+			re: regexp.MustCompile("(?i)//(?:www\\.)?creativecommons\\.org/publicdomain/zero/(\\d\\.\\d)"),
+			id: func(match []string) string {
+				return "CC0-" + match[1]
+			},
+		},
+	}
+)
+
+// findWellKnownURLs returns the SPDX license identifiers referenced by the well-known URLs
+// in the text, e.g. "GPL-3.0" for https://www.gnu.org/licenses/gpl-3.0.html. The identifiers
+// are not checked against the database.
+func findWellKnownURLs(text string) []string {
+	var ids []string
+	for _, pattern := range wellKnownURLs {
+		for _, match := range pattern.re.FindAllStringSubmatch(text, -1) {
+			ids = append(ids, pattern.id(match))
+		}
+	}
+	return ids
+}
+
+// resolveURLLicense returns the license name of the SPDX identifier referenced by a well-known
+// URL. The URLs of the GNU licenses do not tell "-only" from "-or-later", so the deprecated
+// identifiers like GPL-3.0 are resolved to the current "-only" licenses.
+func (db *database) resolveURLLicense(id string) (string, bool) {
+	id = strings.ToLower(id)
+	key, exists := db.licenseIDs[id]
+	if !exists || !strings.HasPrefix(key, "deprecated_") {
+		return key, exists
+	}
+	if only, exists := db.licenseIDs[id+"-only"]; exists {
+		return only, true
+	}
+	return key, true
+}
+
+// scanForWellKnownURLs returns the licenses referenced by the well-known URLs in the text,
+// see findWellKnownURLs.
+func (db *database) scanForWellKnownURLs(text string) map[string]bool {
+	licenses := map[string]bool{}
+	for _, id := range findWellKnownURLs(text) {
+		if key, exists := db.resolveURLLicense(id); exists {
+			licenses[key] = true
+		}
+	}
+	return licenses
+}
@@ -0,0 +1,72 @@
+package licensedb
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+// maxLFSPointerSize is the size of the biggest git-lfs pointer file.
+const maxLFSPointerSize = 512
+
+// lfsPointerRe matches the git-lfs pointer file, see
+// https://github.com/git-lfs/git-lfs/blob/master/docs/spec.md
+var lfsPointerRe = regexp.MustCompile(
+	"^version https://git-lfs\\.github\\.com/spec/v1\\r?\\noid sha256:([0-9a-f]{64})\\r?\\nsize \\d+\\r?\\n?$")
+
+// lfsFiler replaces the contents of the git-lfs pointer files with the objects they point to
+// if the resolver is not nil, e.g. the original Filer implements filer.LFSResolver.
+// The unresolved pointers fail to read, so that they are never matched as licenses,
+// and are reported in the warnings.
+type lfsFiler struct {
+	filer.Filer
+	resolver filer.LFSResolver
+	warned   map[string]bool
+	warnings []string
+}
+
+func newLFSFiler(fs filer.Filer, resolver filer.LFSResolver) *lfsFiler {
+	return &lfsFiler{Filer: fs, resolver: resolver, warned: map[string]bool{}}
+}
+
+func (fs *lfsFiler) ReadFile(path string) ([]byte, error) {
+	content, err := fs.Filer.ReadFile(path)
+	if err != nil || len(content) > maxLFSPointerSize {
+		return content, err
+	}
+	return fs.resolve(path, content)
+}
+
+func (fs *lfsFiler) ReadFilePrefix(path string, size int) ([]byte, error) {
+	content, err := filer.ReadFilePrefix(fs.Filer, path, size)
+	if err != nil || len(content) > maxLFSPointerSize || len(content) >= size {
+		return content, err
+	}
+	content, err = fs.resolve(path, content)
+	if len(content) > size {
+		content = content[:size]
+	}
+	return content, err
+}
+
+// resolve replaces the contents of the git-lfs pointer file with the object it points to.
+func (fs *lfsFiler) resolve(path string, content []byte) ([]byte, error) {
+	match := lfsPointerRe.FindSubmatch(content)
+	if match == nil {
+		return content, nil
+	}
+	if fs.resolver != nil {
+		object, err := fs.resolver.ReadLFSObject(string(match[1]))
+		if err == nil {
+			return object, nil
+		}
+	}
+	if !fs.warned[path] {
+		fs.warned[path] = true
+		fs.warnings = append(fs.warnings, fmt.Sprintf(
+			"%s is a git-lfs pointer to an object which is not available", path))
+	}
+	return nil, errors.Errorf("%s is an unresolved git-lfs pointer", path)
+}
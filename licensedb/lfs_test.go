@@ -0,0 +1,45 @@
+package licensedb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type lfsMemoryFiler struct {
+	memoryFiler
+	objects map[string]string
+}
+
+func (fs lfsMemoryFiler) ReadLFSObject(oid string) ([]byte, error) {
+	if object, exists := fs.objects[oid]; exists {
+		return []byte(object), nil
+	}
+	return nil, errors.New("no such object")
+}
+
+func TestDetectLFSPointer(t *testing.T) {
+	const oid = "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+	fs := memoryFiler{
+		"LICENSE":   "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 1077\n",
+		"README.md": "# Foo\n\n## License\n\nFoo is released under the MIT license.\n",
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"LICENSE is a git-lfs pointer to an object which is not available"},
+		result.Warnings)
+	for _, match := range result.Matches {
+		assert.Equal(t, PlanReadme, match.Plan)
+	}
+	assert.Contains(t, result.Licenses(), "MIT")
+	_, err = DetectDetailedWithOptions(fs, Options{FailOnReadError: true})
+	assert.Nil(t, err)
+
+	result, err = DetectDetailed(lfsMemoryFiler{
+		memoryFiler: fs, objects: map[string]string{oid: referenceText(t, "ISC")}})
+	assert.Nil(t, err)
+	assert.Empty(t, result.Warnings)
+	assert.Equal(t, "ISC", bestLicense(result.Licenses()))
+	assert.Equal(t, PlanLicenseFiles, result.Matches[0].Plan)
+}
@@ -2,53 +2,560 @@ package licensedb
 
 import (
 	"errors"
+	"fmt"
+	"os"
 	paths "path"
+	"sort"
+	"strings"
+	"time"
 
 	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
 	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal"
 )
 
+// wellKnownDirectory is the directory with the website metadata, see RFC 8615.
+const wellKnownDirectory = ".well-known"
+
+// settingsBundleSuffix is the suffix of the iOS and macOS bundles, e.g. Settings.bundle, which
+// contain the acknowledgements of the third party components.
+const settingsBundleSuffix = ".bundle"
+
+// githubDirectory contains the GitHub metadata, e.g. the license override.
+const githubDirectory = ".github"
+
+// bundleManifestsDirectory contains the ClusterServiceVersion of the Kubernetes operator bundle.
+const bundleManifestsDirectory = "manifests"
+
 var (
 	// ErrNoLicenseFound is raised if no license files were found.
 	ErrNoLicenseFound = errors.New("no license file was found")
+
+	// directories with third party code which must not be scanned for license headers
+	skippedSourceDirectories = map[string]bool{
+		"vendor":       true,
+		"node_modules": true,
+		"third_party":  true,
+		"Godeps":       true,
+	}
 )
 
 // Detect returns the most probable reference licenses matched for the given
 // file tree. Each match has the confidence assigned, from 0 to 1, 1 means 100% confident.
 func Detect(fs filer.Filer) (map[string]float32, error) {
+	return DetectWithOptions(fs, Options{})
+}
+
+// DetectWithOptions is the same as Detect but allows to tune the detection, see Options.
+func DetectWithOptions(fs filer.Filer, options Options) (map[string]float32, error) {
+	result, err := detect(fs, options, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result.Licenses(), nil
+}
+
+// DetectWithStats is the same as Detect but additionally reports how much time was spent
+// in each detection plan, how many candidates each plan investigated and how many files
+// and bytes were read. The first call includes the loading of the license database
+// into the investigation time of the first plan.
+func DetectWithStats(fs filer.Filer) (map[string]float32, *Stats, error) {
+	stats := &Stats{}
+	result, err := detect(fs, Options{}, stats)
+	if err != nil {
+		return nil, stats, err
+	}
+	return result.Licenses(), stats, nil
+}
+
+// DetectDetailed is the same as Detect but reports each match separately together with
+// the file and the plan it originates from.
+func DetectDetailed(fs filer.Filer) (*Result, error) {
+	return detect(fs, Options{}, nil)
+}
+
+// DetectDetailedWithOptions is the same as DetectDetailed but allows to tune the detection,
+// see Options.
+func DetectDetailedWithOptions(fs filer.Filer, options Options) (*Result, error) {
+	return detect(fs, options, nil)
+}
+
+// DetectReadmeOnly infers the licenses from the README text alone, without scanning the
+// file tree, e.g. for a gist or a pasted README. The text is expected to be plain, so Markdown
+// and other markups should be rendered beforehand. fs is used to follow the mentions of
+// the license files in the text, it may be nil if there are no other files.
+func DetectReadmeOnly(text []byte, fs filer.Filer) (map[string]float32, error) {
+	db, err := defaultDatabase()
+	if err != nil {
+		return nil, err
+	}
+	return db.DetectReadmeOnly(text, fs), nil
+}
+
+// QueryAllScores returns the similarity of the license text to every license in the default
+// database, from 0 to 1, not only the matches above the threshold, e.g. for the research and
+// the threshold tuning. The best scores are the same as the confidences of the detection.
+// It compares the text with each license, so it is slow.
+func QueryAllScores(text string) (map[string]float32, error) {
+	db, err := defaultDatabase()
+	if err != nil {
+		return nil, err
+	}
+	return db.QueryAllScores(text), nil
+}
+
+// DetectPath is the same as Detect for the directory on the local file system.
+func DetectPath(path string) (map[string]float32, error) {
+	fs, err := filer.FromDirectory(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fs.Close()
+	return Detect(fs)
+}
+
+// DetectCWD is the same as DetectPath for the current working directory of the process.
+func DetectCWD() (map[string]float32, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return DetectPath(cwd)
+}
+
+func detect(fs filer.Filer, options Options, stats *Stats) (*Result, error) {
+	db, err := options.database()
+	if err != nil {
+		return nil, err
+	}
+	// the plans match against the same database, see Result.addText
+	options.Database = &Database{db: db}
+	if err := options.checkCorpusVersion(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resolver, _ := fs.(filer.LFSResolver)
+	fs = options.Retry.wrap(fs)
+	var strict *strictFiler
+	if options.FailOnReadError {
+		strict = newStrictFiler(fs)
+		fs = strict
+	}
+	// the unresolved git-lfs pointers are not the read errors
+	lfs := newLFSFiler(fs, resolver)
+	fs = stats.wrap(lfs)
+	limit := newLimiter(options.MaxConcurrency)
+	fs = limit.wrap(fs)
 	files, err := fs.ReadDir("")
 	if err != nil {
 		return nil, err
 	}
 	fileNames := []string{}
+	// .well-known is scanned for the README-like candidates only
+	var wellKnownNames []string
+	// manifests is scanned for the operator bundle manifests only
+	var bundleNames []string
+	// Settings.bundle and the like are scanned for the acknowledgements only
+	var settingsNames []string
+	// .github is scanned for the license override only
+	var githubNames []string
+	var warnings []string
 	for _, file := range files {
 		if !file.IsDir {
 			fileNames = append(fileNames, file.Name)
-		} else if internal.IsLicenseDirectory(file.Name) {
-			// "license" directory, let's look inside
+		} else if file.Name == wellKnownDirectory {
 			subfiles, err := fs.ReadDir(file.Name)
 			if err == nil {
 				for _, subfile := range subfiles {
 					if !subfile.IsDir {
-						fileNames = append(fileNames, paths.Join(file.Name, subfile.Name))
+						wellKnownNames = append(wellKnownNames, paths.Join(file.Name, subfile.Name))
+					}
+				}
+			}
+		} else if file.Name == bundleManifestsDirectory {
+			subfiles, err := fs.ReadDir(file.Name)
+			if err == nil {
+				for _, subfile := range subfiles {
+					if !subfile.IsDir && internal.IsBundleManifest(subfile.Name) {
+						bundleNames = append(bundleNames, paths.Join(file.Name, subfile.Name))
+					}
+				}
+			}
+		} else if file.Name == githubDirectory {
+			subfiles, err := fs.ReadDir(file.Name)
+			if err == nil {
+				for _, subfile := range subfiles {
+					path := paths.Join(file.Name, subfile.Name)
+					if !subfile.IsDir && internal.IsLicenseOverrideFile(path) {
+						githubNames = append(githubNames, path)
+					}
+				}
+			}
+		} else if strings.HasSuffix(file.Name, settingsBundleSuffix) {
+			subfiles, err := fs.ReadDir(file.Name)
+			if err == nil {
+				for _, subfile := range subfiles {
+					if !subfile.IsDir && internal.IsAcknowledgementsFile(subfile.Name) {
+						settingsNames = append(settingsNames, paths.Join(file.Name, subfile.Name))
 					}
 				}
 			}
+		} else if options.isLicenseDirectory(file.Name) {
+			// "license" directory, let's look inside
+			subfiles, truncated := listLicenseDirectory(fs, file.Name, options.maxDepth())
+			fileNames = append(fileNames, subfiles...)
+			if truncated && options.MaxDepth > 0 {
+				warnings = append(warnings, fmt.Sprintf(
+					"%s is nested too deep, the files below depth %d are skipped",
+					file.Name, options.maxDepth()))
+			}
 		}
 	}
-	candidates := internal.ExtractLicenseFiles(fileNames, fs)
-	licenses := internal.InvestigateLicenseTexts(candidates)
-	if len(licenses) > 0 {
-		return licenses, nil
+	grants := internal.ExtractPatentGrants(fileNames, fs)
+	result := &Result{Warnings: warnings}
+	for _, file := range sortedKeys(grants) {
+		result.PatentFiles = append(result.PatentFiles, PatentFile{
+			File: file, Summary: internal.PatentGrantSummary(grants[file])})
 	}
-	// Plan B: take the README, find the section about the license and apply NER
-	candidates = internal.ExtractReadmeFiles(fileNames, fs)
-	if len(candidates) == 0 {
-		return nil, ErrNoLicenseFound
+	notices := internal.ExtractThirdPartyNotices(fileNames, fs)
+	for _, file := range sortedKeys(notices) {
+		for _, component := range internal.InvestigateThirdPartyNotices(notices[file], db) {
+			result.ThirdPartyLicenses = append(result.ThirdPartyLicenses, ThirdPartyLicense{
+				File: file, Component: component.Component, License: component.License,
+				Confidence: component.Confidence})
+		}
+	}
+	acknowledgements := internal.ExtractAcknowledgements(append(fileNames, settingsNames...), fs)
+	for _, file := range sortedKeys(acknowledgements) {
+		for _, component := range internal.InvestigateAcknowledgements(acknowledgements[file], db) {
+			result.ThirdPartyLicenses = append(result.ThirdPartyLicenses, ThirdPartyLicense{
+				File: file, Component: component.Component, License: component.License,
+				Confidence: component.Confidence})
+		}
+	}
+	allowed := options.allowed()
+	runAllPlans := options.RunAllPlans || options.AnnotateOtherPlans
+	finish := func() (*Result, error) {
+		result.Warnings = append(result.Warnings, lfs.warnings...)
+		// the declared choices are not restricted yet
+		result.restrict(allowed)
+		if options.AnnotateOtherPlans {
+			result.annotateOtherPlans()
+		}
+		if options.CollapseVersions {
+			result.collapseVersions()
+		}
+		result.markMissingLicenseFiles()
+		result.markDualLicensing()
+		if options.FoldDuplicates {
+			result.foldDuplicates()
+		}
+		if options.StableKeys {
+			result.assignKeys(db)
+		}
+		if options.LabelConfidence {
+			result.labelConfidences(options.ConfidenceLabels)
+		}
+		if options.AttachMetadata {
+			result.attachMetadata(db)
+		}
+		result.sort()
+		if options.RankMatches {
+			RankMatches(result.Matches)
+		}
+		return result, nil
+	}
+	// the explicit override takes precedence over all the plans
+	overrideFile, expression := internal.ExtractAuthoritativeLicense(
+		fileNames, options.AuthoritativeFiles, fs)
+	if expression == "" {
+		overrideFile, expression = internal.ExtractLicenseOverride(append(fileNames, githubNames...), fs)
+	}
+	if expression != "" {
+		result.addDeclared(overrideFile, PlanOverride, expression, db)
+		result.restrict(allowed)
+		if len(result.Matches) > 0 && !runAllPlans {
+			return finish()
+		}
+	}
+	hashed := newHashingFiler(fs, options.KnownHashes)
+	candidates := internal.ExtractLicenseFiles(fileNames, hashed)
+	for file, text := range internal.ExtractCMakeLicenseFiles(fileNames, hashed) {
+		candidates[file] = text
+	}
+	if options.ScanCredits {
+		for file, text := range internal.ExtractCreditsFiles(fileNames, hashed) {
+			candidates[file] = text
+		}
+	}
+	if err := strict.Err(); err != nil {
+		return nil, err
+	}
+	extracted := time.Now()
+	licenseFiles := sortedKeys(candidates)
+	limit.investigate(result, len(licenseFiles), func(i int, part *Result) {
+		if license, exists := hashed.licenses[licenseFiles[i]]; exists {
+			part.Matches = append(part.Matches, Match{
+				License: license, Confidence: 1, File: licenseFiles[i], Plan: PlanLicenseFiles})
+			return
+		}
+		part.addText(licenseFiles[i], PlanLicenseFiles, 0, candidates[licenseFiles[i]], options)
+	})
+	stats.add(PlanLicenseFiles, len(candidates), start, extracted)
+	result.restrict(allowed)
+	result.warnConflictingLicenseFiles()
+	if len(result.Matches) > 0 {
+		result.PatentGrant = result.hasLicense("BSD") && len(grants) > 0
+		if options.WeightByProminence {
+			result.weightByProminence(PlanLicenseFiles)
+		}
+		if !runAllPlans {
+			result.addDeclaredChoices(
+				internal.ExtractDeclaredLicenses(append(fileNames, bundleNames...), fs), db)
+			if err := strict.Err(); err != nil {
+				return nil, err
+			}
+			return finish()
+		}
+	}
+	// Plan B: read the licenses declared in the manifests, e.g. Dockerfile or Chart.yaml
+	start = time.Now()
+	declarations := internal.ExtractDeclaredLicenses(append(fileNames, bundleNames...), fs)
+	extracted = time.Now()
+	for _, file := range sortedDeclarationKeys(declarations) {
+		for _, expression := range declarations[file] {
+			result.addDeclared(file, PlanManifests, expression, db)
+		}
+	}
+	stats.add(PlanManifests, len(declarations), start, extracted)
+	result.restrict(allowed)
+	if err := strict.Err(); err != nil {
+		return nil, err
 	}
-	licenses = internal.InvestigateReadmeTexts(candidates, fs)
-	if len(licenses) == 0 {
+	if len(result.Matches) > 0 && !runAllPlans {
+		return finish()
+	}
+	// Plan C: take the README, find the section about the license and apply NER
+	start = time.Now()
+	candidates = internal.ExtractReadmeFiles(append(fileNames, wellKnownNames...), fs)
+	if options.ScanCredits {
+		for file, text := range internal.ExtractCreditsFiles(fileNames, fs) {
+			candidates[file] = text
+		}
+	}
+	extracted = time.Now()
+	readmeFiles := sortedKeys(candidates)
+	readmeOptions := internal.ReadmeOptions{
+		Proximity: options.WeightReadmeProximity, Multilingual: options.MultilingualReadme}
+	limit.investigate(result, len(readmeFiles), func(i int, part *Result) {
+		file := readmeFiles[i]
+		part.addMatches(file, PlanReadme, internal.InvestigateReadmeTextWithOptions(
+			candidates[file], fs, db, readmeOptions))
+	})
+	stats.add(PlanReadme, len(candidates), start, extracted)
+	result.restrict(allowed)
+	if err := strict.Err(); err != nil {
+		return nil, err
+	}
+	if len(result.Matches) == 0 && len(licenseFiles) == 0 {
+		// the NOTICE file is the last resort because it may credit the Apache dependencies
+		result.addApacheNotices(internal.ExtractNoticeFiles(fileNames, fs))
+		result.restrict(allowed)
+	}
+	if len(result.Matches) > 0 && !runAllPlans {
+		return finish()
+	}
+	// Plan D: look for the license headers in the source files, see Options.ScanHeaders
+	var allFiles []string
+	if options.ScanHeaders || options.ScanBinaries {
+		// the walk of the whole tree is shared with Plan E
+		allFiles = listSourceFiles(fs, "")
+	}
+	if options.ScanHeaders {
+		start = time.Now()
+		extractComments := internal.ExtractHeaderComments
+		if options.FirstHeaderComment {
+			extractComments = internal.ExtractFirstHeaderComments
+		}
+		sourceFiles := allFiles
+		sources := map[string][]byte{}
+		// the matches of the banners which are already investigated by their keys
+		investigated := map[string][]Match{}
+		if options.SampleHeaders {
+			// the full scan only confirms the licenses of the sampled headers
+			sampleFiles := sampleSourceFiles(allFiles)
+			sources = internal.ExtractSourceFiles(sampleFiles, fs)
+			if err := strict.Err(); err != nil {
+				return nil, err
+			}
+			banners := internal.GroupHeaderComments(extractComments(sources))
+			sample := &Result{}
+			limit.investigate(sample, len(banners), func(i int, part *Result) {
+				part.addHeader(banners[i], options)
+			})
+			keys := map[string]string{}
+			for _, banner := range banners {
+				key := internal.HeaderBannerKey(banner.Text)
+				keys[banner.Files[0]] = key
+				investigated[key] = nil
+			}
+			for _, match := range sample.Matches {
+				investigated[keys[match.File]] = append(investigated[keys[match.File]], match)
+			}
+			sourceFiles = nil
+			if len(sample.Matches) > 0 {
+				sampled := map[string]bool{}
+				for _, file := range sampleFiles {
+					sampled[file] = true
+				}
+				for _, file := range allFiles {
+					if !sampled[file] {
+						sourceFiles = append(sourceFiles, file)
+					}
+				}
+			}
+		}
+		for file, source := range internal.ExtractSourceFiles(sourceFiles, fs) {
+			sources[file] = source
+		}
+		if err := strict.Err(); err != nil {
+			return nil, err
+		}
+		banners := internal.GroupHeaderComments(extractComments(sources))
+		extracted = time.Now()
+		limit.investigate(result, len(banners), func(i int, part *Result) {
+			banner := banners[i]
+			matches, exists := investigated[internal.HeaderBannerKey(banner.Text)]
+			if !exists {
+				part.addHeader(banner, options)
+				return
+			}
+			// the sampled banner has fewer files and maybe a different first one
+			for _, match := range matches {
+				match.File = banner.Files[0]
+				match.Occurrences = len(banner.Files)
+				part.Matches = append(part.Matches, match)
+			}
+		})
+		stats.add(PlanHeaders, len(banners), start, extracted)
+		result.restrict(allowed)
+	}
+	// Plan E: extract the strings from the binary files and match the embedded license texts
+	if options.ScanBinaries && (len(result.Matches) == 0 || runAllPlans) {
+		start = time.Now()
+		binaries := internal.ExtractBinaryStrings(allFiles, fs)
+		if err := strict.Err(); err != nil {
+			return nil, err
+		}
+		extracted = time.Now()
+		var binaryFiles []string
+		var texts [][]byte
+		for _, file := range sortedBinaryKeys(binaries) {
+			for _, text := range binaries[file] {
+				binaryFiles = append(binaryFiles, file)
+				texts = append(texts, text)
+			}
+		}
+		limit.investigate(result, len(texts), func(i int, part *Result) {
+			part.addText(binaryFiles[i], PlanBinaries, 0, texts[i], options)
+		})
+		stats.add(PlanBinaries, len(texts), start, extracted)
+		result.restrict(allowed)
+	}
+	if len(result.Matches) == 0 {
 		return nil, ErrNoLicenseFound
 	}
-	return licenses, nil
+	return finish()
+}
+
+// listLicenseDirectory recursively lists the files in the license directory down to maxDepth
+// levels, 1 means only the files directly inside. The hidden directories are not visited.
+// It also returns whether there are deeper directories which are skipped.
+func listLicenseDirectory(fs filer.Filer, dir string, maxDepth int) ([]string, bool) {
+	files, err := fs.ReadDir(dir)
+	if err != nil {
+		return nil, false
+	}
+	var result []string
+	truncated := false
+	for _, file := range files {
+		path := paths.Join(dir, file.Name)
+		if !file.IsDir {
+			result = append(result, path)
+		} else if strings.HasPrefix(file.Name, ".") {
+			continue
+		} else if maxDepth <= 1 {
+			truncated = true
+		} else {
+			subfiles, subtruncated := listLicenseDirectory(fs, path, maxDepth-1)
+			result = append(result, subfiles...)
+			truncated = truncated || subtruncated
+		}
+	}
+	return result, truncated
+}
+
+// listSourceFiles recursively lists all the files in the directory except the hidden
+// and the vendored ones.
+func listSourceFiles(fs filer.Filer, dir string) []string {
+	files, err := fs.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var result []string
+	for _, file := range files {
+		if strings.HasPrefix(file.Name, ".") {
+			continue
+		}
+		path := paths.Join(dir, file.Name)
+		if !file.IsDir {
+			result = append(result, path)
+		} else if !skippedSourceDirectories[file.Name] {
+			result = append(result, listSourceFiles(fs, path)...)
+		}
+	}
+	return result
+}
+
+// sampleSourceFiles returns the first source file in each directory.
+func sampleSourceFiles(files []string) []string {
+	sampled := map[string]bool{}
+	var sample []string
+	for _, file := range files {
+		dir := paths.Dir(file)
+		if !sampled[dir] && internal.SourceLanguage(file) != "" {
+			sampled[dir] = true
+			sample = append(sample, file)
+		}
+	}
+	return sample
+}
+
+// sortedKeys returns the keys of the candidates map in the lexicographic order.
+func sortedKeys(candidates map[string][]byte) []string {
+	keys := make([]string, 0, len(candidates))
+	for key := range candidates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedBinaryKeys returns the keys of the binary strings map in the lexicographic order.
+func sortedBinaryKeys(binaries map[string][][]byte) []string {
+	keys := make([]string, 0, len(binaries))
+	for key := range binaries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedDeclarationKeys returns the keys of the declarations map in the lexicographic order.
+func sortedDeclarationKeys(declarations map[string][]string) []string {
+	keys := make([]string, 0, len(declarations))
+	for key := range declarations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,2235 @@
+package licensedb
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal/assets"
+)
+
+// memoryFiler is a Filer over an in-memory map from file paths to their contents.
+// Directories are implied by the paths.
+type memoryFiler map[string]string
+
+func (fs memoryFiler) ReadFile(path string) ([]byte, error) {
+	content, exists := fs[path]
+	if !exists {
+		return nil, os.ErrNotExist
+	}
+	return []byte(content), nil
+}
+
+func (fs memoryFiler) ReadDir(dir string) ([]filer.File, error) {
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+	seen := map[string]bool{}
+	files := []filer.File{}
+	for key := range fs {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		parts := strings.SplitN(key[len(prefix):], "/", 2)
+		if seen[parts[0]] {
+			continue
+		}
+		seen[parts[0]] = true
+		files = append(files, filer.File{Name: parts[0], IsDir: len(parts) > 1})
+	}
+	if len(files) == 0 && dir != "" {
+		return nil, os.ErrNotExist
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+func (fs memoryFiler) Close() {}
+
+// referenceText returns the text of the reference license from the embedded database.
+func referenceText(t *testing.T, name string) string {
+	archive := tar.NewReader(bytes.NewReader(assets.MustAsset("licenses.tar")))
+	for header, err := archive.Next(); err != io.EOF; header, err = archive.Next() {
+		assert.Nil(t, err)
+		if path.Base(header.Name) == name+".txt" {
+			text, err := ioutil.ReadAll(archive)
+			assert.Nil(t, err)
+			return string(text)
+		}
+	}
+	t.Fatalf("license %s does not exist", name)
+	return ""
+}
+
+func TestDetectCMakeLicenseReference(t *testing.T) {
+	fs := memoryFiler{
+		"CMakeLists.txt": `cmake_minimum_required(VERSION 3.1)
+project(foo CXX)
+add_library(foo src/foo.cpp)
+# the license lives in the docs
+install(FILES "${PROJECT_SOURCE_DIR}/doc/foo-LICENSE.txt" README.txt DESTINATION share/doc/foo)
+`,
+		"README.txt":          "Foo is a library.",
+		"src/foo.cpp":         "int foo() { return 0; }",
+		"doc/foo-LICENSE.txt": referenceText(t, "MIT"),
+	}
+	licenses, err := Detect(fs)
+	assert.Nil(t, err)
+	assert.InDelta(t, 1, licenses["MIT"], 0.05)
+
+	delete(fs, "CMakeLists.txt")
+	fs["CMakeLists.txt"] = `set(CPACK_RESOURCE_FILE_LICENSE "${CMAKE_CURRENT_SOURCE_DIR}/doc/terms.txt")`
+	fs["doc/terms.txt"] = fs["doc/foo-LICENSE.txt"]
+	delete(fs, "doc/foo-LICENSE.txt")
+	licenses, err = Detect(fs)
+	assert.Nil(t, err)
+	assert.InDelta(t, 1, licenses["MIT"], 0.05)
+
+	// the upper case file names are not the keywords
+	fs = memoryFiler{
+		"CMakeLists.txt": "install(FILES README dist/COPYING DESTINATION share/doc/foo)\n",
+		"README":         "Foo is a library.",
+		"dist/COPYING":   referenceText(t, "MIT"),
+	}
+	licenses, err = Detect(fs)
+	assert.Nil(t, err)
+	assert.InDelta(t, 1, licenses["MIT"], 0.05)
+}
+
+func TestDetectPatentGrant(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE": referenceText(t, "BSD-3-Clause"),
+		"PATENTS": `Additional Grant of Patent Rights Version 2
+
+"Software" means the React software distributed by Facebook, Inc.
+
+Facebook, Inc. ("Facebook") hereby grants to each recipient of the Software
+("you") a perpetual, worldwide, royalty-free, non-exclusive, irrevocable
+(subject to the termination provision below) license under any Necessary
+Claims, to make, have made, use, sell, offer to sell, import, and otherwise
+transfer the Software.`,
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.True(t, result.PatentGrant)
+	assert.Equal(t, "BSD-3-Clause", result.Matches[0].License)
+	assert.Equal(t, "LICENSE", result.Matches[0].File)
+	assert.Equal(t, PlanLicenseFiles, result.Matches[0].Plan)
+	assert.Equal(t, result.Licenses(), mustDetect(t, fs))
+
+	fs["LICENSE"] = referenceText(t, "MIT")
+	result, err = DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.False(t, result.PatentGrant)
+
+	fs["LICENSE"] = referenceText(t, "BSD-3-Clause")
+	delete(fs, "PATENTS")
+	result, err = DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.False(t, result.PatentGrant)
+	assert.Nil(t, result.PatentFiles)
+}
+
+func TestDetectAdditionalPatentGrant(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE": referenceText(t, "Apache-2.0"),
+		"ADDITIONAL_GRANT": `Additional IP Rights Grant (Patents)
+
+"This implementation" means the copyrightable works distributed by Google as
+part of the Foo project.
+
+Google hereby grants to You a perpetual, worldwide, non-exclusive, no-charge,
+royalty-free, irrevocable (except as stated in this section) patent license to
+make, have made, use, offer to sell, sell, import, transfer and otherwise run,
+modify and propagate the contents of this implementation of Foo.
+`,
+		"PATENTS.md": "# Patents\n\nNothing to see here.\n",
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, "Apache-2.0", result.Matches[0].License)
+	assert.False(t, result.PatentGrant)
+	assert.Equal(t, []PatentFile{{File: "ADDITIONAL_GRANT", Summary: "Additional IP Rights Grant (Patents)"}},
+		result.PatentFiles)
+
+	fs["LICENSE"] = referenceText(t, "BSD-3-Clause")
+	fs["PATENT_GRANT.txt"] = "Grant of patent rights\n\nSee ADDITIONAL_GRANT.\n"
+	result, err = DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.True(t, result.PatentGrant)
+	assert.Equal(t, []PatentFile{
+		{File: "ADDITIONAL_GRANT", Summary: "Additional IP Rights Grant (Patents)"},
+		{File: "PATENT_GRANT.txt", Summary: "Grant of patent rights"},
+	}, result.PatentFiles)
+}
+
+func mustDetect(t *testing.T, fs filer.Filer) map[string]float32 {
+	licenses, err := Detect(fs)
+	assert.Nil(t, err)
+	return licenses
+}
+
+func mustDetectHeaders(t *testing.T, fs filer.Filer) map[string]float32 {
+	licenses, err := DetectWithOptions(fs, Options{ScanHeaders: true})
+	assert.Nil(t, err)
+	return licenses
+}
+
+func TestDetectReadmeRestructuredTextField(t *testing.T) {
+	fs := memoryFiler{
+		"README.rst": `Foo
+===
+
+:Author: John Doe
+:License: Apache-2.0
+:Version: 1.0
+
+Foo does nothing.
+`,
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{License: "Apache-2.0", Confidence: 1, File: "README.rst", Plan: PlanReadme}},
+		result.Matches)
+
+	fs["README.rst"] = "Foo\n===\n\n:license: The MIT License\n"
+	assert.Equal(t, map[string]float32{"MIT": 1}, mustDetect(t, fs))
+}
+
+const apacheHeader = `// Copyright %d The Foo Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package foo
+`
+
+func TestDetectHeaderBannersDeduplicated(t *testing.T) {
+	fs := memoryFiler{"README.md": "# Foo\n\nFoo does nothing.\n"}
+	for i := 0; i < 50; i++ {
+		fs[fmt.Sprintf("pkg%d/file%02d.go", i%5, i)] = fmt.Sprintf(apacheHeader, 2000+i%10)
+	}
+	fs["vendor/github.com/bar/bar.go"] = "// Licensed under the MIT license.\npackage bar\n"
+	_, err := DetectDetailed(fs)
+	assert.Equal(t, ErrNoLicenseFound, err)
+	result, err := DetectDetailedWithOptions(fs, Options{ScanHeaders: true})
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{
+		License: "Apache-2.0", Confidence: 1, File: "pkg0/file00.go", Plan: PlanHeaders,
+		Occurrences: 50, Language: "Go"}}, result.Matches)
+}
+
+func TestDetectHeaderAfterGeneratedBanner(t *testing.T) {
+	banner := &bytes.Buffer{}
+	banner.WriteString("// Code generated by foogen. DO NOT EDIT.\n// Generated from the following sources:\n")
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(banner, "//   schema/definitions/resource%02d.yaml\n", i)
+	}
+	banner.WriteString("\n//go:build !windows\n\n")
+	assert.True(t, banner.Len() > 1500)
+	result, err := DetectDetailedWithOptions(memoryFiler{"foo/zz_generated.go": banner.String() +
+		fmt.Sprintf(apacheHeader, 2020)}, Options{ScanHeaders: true})
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{
+		License: "Apache-2.0", Confidence: 1, File: "foo/zz_generated.go", Plan: PlanHeaders,
+		Occurrences: 1, Language: "Go"}}, result.Matches)
+}
+
+func TestDetectSampleHeaders(t *testing.T) {
+	newFiler := func(header string) glitchyFiler {
+		fs := glitchyFiler{memoryFiler: memoryFiler{}, attempts: map[string]int{}}
+		for _, dir := range []string{"cmd", "pkg/foo", "pkg/bar"} {
+			for _, name := range []string{"a", "b", "c"} {
+				fs.memoryFiler[dir+"/"+name+".go"] = header + "package " + name + "\n"
+			}
+		}
+		return fs
+	}
+	reads := func(fs glitchyFiler) int {
+		count := 0
+		for path, attempts := range fs.attempts {
+			if strings.HasSuffix(path, ".go") {
+				count += attempts
+			}
+		}
+		return count
+	}
+	fs := newFiler(fmt.Sprintf(apacheHeader, 2019))
+	stats := &Stats{}
+	result, err := detect(fs, Options{ScanHeaders: true, SampleHeaders: true}, stats)
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{
+		License: "Apache-2.0", Confidence: 1, File: "cmd/a.go", Plan: PlanHeaders,
+		Occurrences: 9, Language: "Go"}}, result.Matches)
+	// confirmed across all the files, the sampled ones are read once
+	assert.Equal(t, 9, reads(fs))
+	headerPlans := 0
+	for _, plan := range stats.Plans {
+		if plan.Name == PlanHeaders {
+			headerPlans++
+			assert.Equal(t, 1, plan.Candidates)
+		}
+	}
+	assert.Equal(t, 1, headerPlans)
+	assert.Equal(t, 9, stats.FilesRead)
+	licenses, err := DetectWithOptions(newFiler(fmt.Sprintf(apacheHeader, 2019)),
+		Options{ScanHeaders: true, SampleHeaders: true})
+	assert.Nil(t, err)
+	assert.Equal(t, float32(1), licenses["Apache-2.0"])
+	fs = newFiler("// Command foo prints the greeting.\n")
+	licenses, err = DetectWithOptions(fs, Options{ScanHeaders: true, SampleHeaders: true})
+	assert.Equal(t, ErrNoLicenseFound, err)
+	assert.Nil(t, licenses)
+	assert.Equal(t, 3, reads(fs))
+	fs = newFiler("// Command foo prints the greeting.\n")
+	_, err = DetectWithOptions(fs, Options{ScanHeaders: true})
+	assert.Equal(t, ErrNoLicenseFound, err)
+	assert.Equal(t, 9, reads(fs))
+}
+
+func TestDetectFoldDuplicates(t *testing.T) {
+	fs := memoryFiler{"README.md": "# Foo\n\n## License\n\nApache 2.0\n"}
+	for i, name := range []string{"bar", "baz", "qux"} {
+		fs[name+".go"] = fmt.Sprintf("// Package %s implements the part %d of foo.\n//\n", name, i) +
+			fmt.Sprintf(apacheHeader, 2000)
+	}
+	options := Options{RunAllPlans: true, ScanHeaders: true}
+	result, err := DetectDetailedWithOptions(fs, options)
+	assert.Nil(t, err)
+	options.FoldDuplicates = true
+	folded, err := DetectDetailedWithOptions(fs, options)
+	assert.Nil(t, err)
+	apache := func(matches []Match) []Match {
+		var selected []Match
+		for _, match := range matches {
+			if match.License == "Apache-2.0" {
+				selected = append(selected, match)
+			}
+		}
+		return selected
+	}
+	assert.Len(t, apache(result.Matches), 4)
+	assert.Len(t, apache(folded.Matches), 1)
+	assert.Equal(t, "Apache-2.0", folded.Matches[0].License)
+	assert.Equal(t, float32(1), folded.Matches[0].Confidence)
+	assert.Equal(t, 4, folded.Matches[0].Evidence)
+	assert.Equal(t, len(folded.Matches), len(folded.Licenses()))
+	assert.Equal(t, result.Licenses(), folded.Licenses())
+}
+
+func TestDetectLicenseZero(t *testing.T) {
+	prosperity := strings.Replace(strings.Replace(referenceText(t, "Prosperity-3.0.0"),
+		"<contributor>", "Example, Inc.", 1), "<source code>", "https://example.com/code", 1)
+	licenses := mustDetect(t, memoryFiler{"LICENSE.md": prosperity})
+	assert.InDelta(t, 1, licenses["Prosperity-3.0.0"], 0.05)
+	assert.True(t, licenses["Parity-7.0.0"] < licenses["Prosperity-3.0.0"])
+	assert.True(t, IsSourceAvailable("Prosperity-3.0.0"))
+	assert.True(t, IsSourceAvailable("Parity-7.0.0"))
+	assert.False(t, IsSourceAvailable("MIT"))
+
+	licenses = mustDetect(t, memoryFiler{"LICENSE": referenceText(t, "Parity-7.0.0")})
+	assert.InDelta(t, 1, licenses["Parity-7.0.0"], 0.05)
+	assert.NotContains(t, licenses, "Prosperity-3.0.0")
+}
+
+func TestDetectBase64License(t *testing.T) {
+	mit := referenceText(t, "MIT")
+	encoded := base64.StdEncoding.EncodeToString([]byte(mit))
+	// wrap the lines like base64(1) does
+	wrapped := &bytes.Buffer{}
+	for i := 0; i < len(encoded); i += 76 {
+		end := i + 76
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		wrapped.WriteString(encoded[i:end] + "\n")
+	}
+	licenses := mustDetect(t, memoryFiler{"LICENSE": wrapped.String()})
+	assert.InDelta(t, 1, licenses["MIT"], 0.05)
+
+	// not a license after decoding: the raw text is matched
+	binary := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0, 1, 2, 0xff}, 64))
+	_, err := Detect(memoryFiler{"LICENSE": binary})
+	assert.Equal(t, ErrNoLicenseFound, err)
+}
+
+const qtHeader = `/****************************************************************************
+**
+** Copyright (C) 2016 The Qt Company Ltd.
+** Contact: https://www.qt.io/licensing/
+**
+** This file is part of the QtCore module of the Qt Toolkit.
+**
+** $QT_BEGIN_LICENSE:%s$
+** Commercial License Usage
+** Licensees holding valid commercial Qt licenses may use this file in
+** accordance with the commercial license agreement provided with the
+** Software or, alternatively, in accordance with the terms contained in
+** a written agreement between you and The Qt Company. For licensing terms
+** and conditions see https://www.qt.io/terms-conditions. For further
+** information use the contact form at https://www.qt.io/contact-us.
+**
+%s
+**
+** $QT_END_LICENSE$
+**
+****************************************************************************/
+
+#include "qobject.h"
+`
+
+const qtLGPL3Notice = `** GNU Lesser General Public License Usage
+** Alternatively, this file may be used under the terms of the GNU Lesser
+** General Public License version 3 as published by the Free Software
+** Foundation and appearing in the file LICENSE.LGPL3 included in the
+** packaging of this file. Please review the following information to
+** ensure the GNU Lesser General Public License version 3 requirements
+** will be met: https://www.gnu.org/licenses/lgpl-3.0.html.
+**
+** GNU General Public License Usage
+** Alternatively, this file may be used under the terms of the GNU
+** General Public License version 2.0 or (at your option) the GNU General
+** Public license version 3 or any later version approved by the KDE Free
+** Qt Foundation. The licenses are as published by the Free Software
+** Foundation and appearing in the file LICENSE.GPL2 and LICENSE.GPL3
+** included in the packaging of this file. Please review the following
+** information to ensure the GNU General Public License requirements will
+** be met: https://www.gnu.org/licenses/gpl-2.0.html and
+** https://www.gnu.org/licenses/gpl-3.0.html.`
+
+const qtGPLExceptNotice = `** GNU General Public License Usage
+** Alternatively, this file may be used under the terms of the GNU
+** General Public License version 3 as published by the Free Software
+** Foundation with exceptions as appearing in the file LICENSE.GPL3-EXCEPT
+** included in the packaging of this file. Please review the following
+** information to ensure the GNU General Public License requirements will
+** be met: https://www.gnu.org/licenses/gpl-3.0.html.`
+
+const qt4LGPLNotice = `** GNU Lesser General Public License Usage
+** This file may be used under the terms of the GNU Lesser General Public
+** License version 2.1 as published by the Free Software Foundation and
+** appearing in the file LICENSE.LGPL included in the packaging of this
+** file. Please review the following information to ensure the GNU Lesser
+** General Public License version 2.1 requirements will be met:
+** http://www.gnu.org/licenses/old-licenses/lgpl-2.1.html.
+**
+** In addition, as a special exception, Nokia gives you certain additional
+** rights. These rights are described in the Nokia Qt LGPL Exception
+** version 1.1, included in the file LGPL_EXCEPTION.txt in this package.`
+
+func TestDetectQtHeader(t *testing.T) {
+	for _, tc := range []struct {
+		marker, notice, license, exception string
+	}{
+		{"LGPL", qtLGPL3Notice, "LGPL-3.0-only", ""},
+		{"GPL-EXCEPT", qtGPLExceptNotice, "GPL-3.0-only", "Qt-GPL-exception-1.0"},
+		{"LGPL", qt4LGPLNotice, "LGPL-2.1-only", "Nokia-Qt-exception-1.1"},
+	} {
+		fs := memoryFiler{"src/corelib/qobject.cpp": fmt.Sprintf(qtHeader, tc.marker, tc.notice)}
+		result, err := DetectDetailedWithOptions(fs, Options{ScanHeaders: true})
+		assert.Nil(t, err)
+		assert.Equal(t, []Match{{
+			License: tc.license, Confidence: 0.95, File: "src/corelib/qobject.cpp",
+			Plan: PlanHeaders, Exception: tc.exception, Occurrences: 1, Language: "C++"}},
+			result.Matches)
+	}
+}
+
+func TestDetectScanBinaries(t *testing.T) {
+	binary := &bytes.Buffer{}
+	binary.Write([]byte{0x7f, 'E', 'L', 'F', 2, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3, 0, 0x3e, 0})
+	for i := 0; i < 256; i++ {
+		binary.WriteByte(byte(i * 7))
+	}
+	binary.WriteString(referenceText(t, "MIT"))
+	binary.WriteByte(0)
+	binary.WriteString("GCC: (GNU) 9.3.0")
+	binary.Write([]byte{0, 0xff, 0xfe, 0x01})
+	fs := memoryFiler{
+		"lib/libfoo.so.1": binary.String(),
+		"include/foo.h":   "int foo(void);\n",
+	}
+	_, err := Detect(fs)
+	assert.Equal(t, ErrNoLicenseFound, err)
+	result, err := DetectDetailedWithOptions(fs, Options{ScanBinaries: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", result.Matches[0].License)
+	assert.InDelta(t, 1, result.Matches[0].Confidence, 0.01)
+	assert.Equal(t, "lib/libfoo.so.1", result.Matches[0].File)
+	assert.Equal(t, PlanBinaries, result.Matches[0].Plan)
+
+	// the other plans go first
+	fs["README.md"] = "# Foo\n\n## License\n\nFoo is released under the ISC license.\n"
+	licenses, err := DetectWithOptions(fs, Options{ScanBinaries: true})
+	assert.Nil(t, err)
+	assert.NotContains(t, licenses, "MIT")
+}
+
+func TestDetectScanCredits(t *testing.T) {
+	fs := memoryFiler{
+		"CREDITS": "Foo was written by Jane Doe and the contributors.\n\n" +
+			referenceText(t, "MIT"),
+		"main.go": "package main\n",
+	}
+	_, err := Detect(fs)
+	assert.Equal(t, ErrNoLicenseFound, err)
+	result, err := DetectDetailedWithOptions(fs, Options{ScanCredits: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", result.Matches[0].License)
+	assert.Equal(t, "CREDITS", result.Matches[0].File)
+	assert.Equal(t, PlanLicenseFiles, result.Matches[0].Plan)
+
+	// the mention of the license is recognized like in the README
+	fs = memoryFiler{
+		"CREDITS.md": "# Credits\n\nFoo is written by Jane Doe and released under the ISC license.\n",
+		"main.go":    "package main\n",
+	}
+	result, err = DetectDetailedWithOptions(fs, Options{ScanCredits: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "ISC", result.Matches[0].License)
+	assert.Equal(t, "CREDITS.md", result.Matches[0].File)
+	assert.Equal(t, PlanReadme, result.Matches[0].Plan)
+
+	// COPYRIGHT is always the license file
+	licenses, err := Detect(memoryFiler{"COPYRIGHT": referenceText(t, "MIT")})
+	assert.Nil(t, err)
+	assert.Contains(t, licenses, "MIT")
+}
+
+func TestDetectConflictingLicenseFiles(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE":     referenceText(t, "MIT"),
+		"LICENSE.txt": referenceText(t, "Apache-2.0"),
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"the license files disagree: LICENSE (MIT), LICENSE.txt (Apache-2.0)"},
+		result.Warnings)
+
+	// the same license
+	fs["LICENSE.txt"] = referenceText(t, "MIT")
+	result, err = DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Empty(t, result.Warnings)
+
+	// the dual licensing
+	result, err = DetectDetailed(memoryFiler{
+		"LICENSE-MIT":    referenceText(t, "MIT"),
+		"LICENSE-APACHE": referenceText(t, "Apache-2.0"),
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, result.Warnings)
+}
+
+func TestDetectApacheNoticeOnly(t *testing.T) {
+	fs := memoryFiler{
+		"NOTICE": "Apache Foo\nCopyright 2019 The Apache Software Foundation\n\n" +
+			"This product includes software developed at\nThe Apache Software Foundation (http://www.apache.org/).\n\n" +
+			"Licensed under the Apache License, Version 2.0.\n",
+		"main.go": "package main\n",
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{License: "Apache-2.0", Confidence: 0.7, File: "NOTICE",
+		Plan: PlanLicenseFiles}}, result.Matches)
+	assert.Equal(t, []string{"NOTICE refers to the Apache License but the LICENSE file is missing"},
+		result.Warnings)
+
+	// NOTICE is not needed next to the license file
+	fs["LICENSE"] = referenceText(t, "Apache-2.0")
+	result, err = DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Empty(t, result.Warnings)
+	for _, match := range result.Matches {
+		assert.Equal(t, "LICENSE", match.File)
+	}
+}
+
+func TestDetectApacheNoticeLastResort(t *testing.T) {
+	// the credits of the Apache dependencies do not make the project Apache-licensed
+	fs := memoryFiler{
+		"NOTICE": "This product includes software developed at\n" +
+			"The Apache Software Foundation (http://www.apache.org/).\n",
+		"main.go": "package main\n",
+	}
+	_, err := DetectDetailed(fs)
+	assert.Equal(t, ErrNoLicenseFound, err)
+
+	// README is consulted before NOTICE
+	fs["NOTICE"] += "\nLicensed under the Apache License, Version 2.0.\n"
+	fs["README.md"] = "# Foo\n\n## License\n\nFoo is released under the MIT License.\n"
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Empty(t, result.Warnings)
+	assert.Equal(t, "MIT", bestLicense(result.Licenses()))
+	assert.NotContains(t, result.Licenses(), "Apache-2.0")
+}
+
+func TestDetectMITNoAttribution(t *testing.T) {
+	licenses, err := Detect(memoryFiler{"LICENSE": referenceText(t, "MIT")})
+	assert.Nil(t, err)
+	assert.InDelta(t, 1, licenses["MIT"], 0.01)
+	assert.NotContains(t, licenses, "MIT-0")
+
+	licenses, err = Detect(memoryFiler{"LICENSE": referenceText(t, "MIT-0")})
+	assert.Nil(t, err)
+	assert.InDelta(t, 1, licenses["MIT-0"], 0.01)
+	assert.NotContains(t, licenses, "MIT")
+
+	// the clause is recognized across the comment markers
+	header := "// " + strings.Replace(strings.TrimSpace(referenceText(t, "MIT")), "\n", "\n// ", -1) +
+		"\n\npackage foo\n"
+	licenses, err = DetectWithOptions(memoryFiler{"foo.go": header}, Options{ScanHeaders: true})
+	assert.Nil(t, err)
+	assert.Contains(t, licenses, "MIT")
+	assert.NotContains(t, licenses, "MIT-0")
+}
+
+func TestDetectGPLVersionOnly(t *testing.T) {
+	text := referenceText(t, "GPL-2.0-only")
+	licenses, err := Detect(memoryFiler{"COPYING": text})
+	assert.Nil(t, err)
+	assert.Contains(t, licenses, "GPL-2.0-only")
+	assert.Contains(t, licenses, "GPL-2.0-or-later")
+
+	for _, statement := range []string{
+		"This program is licensed under the GNU GPL version 2 only.",
+		"Use version 2 of the GPL, not any later version.",
+	} {
+		licenses, err = Detect(memoryFiler{"COPYING": statement + "\n\n" + text})
+		assert.Nil(t, err)
+		assert.InDelta(t, 1, licenses["GPL-2.0-only"], 0.01, statement)
+		assert.NotContains(t, licenses, "GPL-2.0-or-later", statement)
+		assert.NotContains(t, licenses, "deprecated_GPL-2.0+", statement)
+	}
+	// the other versions are unrelated
+	for _, statement := range []string{
+		"The build requires Python v3 only.",
+		"This program is licensed under the GNU GPL version 3 only.",
+	} {
+		licenses, err = Detect(memoryFiler{"COPYING": statement + "\n\n" + text})
+		assert.Nil(t, err)
+		assert.Contains(t, licenses, "GPL-2.0-or-later", statement)
+	}
+}
+
+func TestDetectAllRightsReservedWithGrant(t *testing.T) {
+	mit := referenceText(t, "MIT")
+	grant := strings.TrimSpace(mit[strings.Index(mit, "Permission is hereby granted"):])
+	header := "// Copyright (c) 2021 Foo Corporation. All rights reserved.\n//\n// " +
+		strings.Replace(grant, "\n", "\n// ", -1) + "\n\npackage foo\n"
+	for _, fs := range []memoryFiler{
+		{"foo.go": header},
+		{"LICENSE": "Copyright (c) 2021 Foo Corporation. All rights reserved.\n\n" + grant},
+	} {
+		licenses, err := DetectWithOptions(fs, Options{ScanHeaders: true})
+		assert.Nil(t, err)
+		assert.Equal(t, "MIT", bestLicense(licenses))
+		assert.True(t, licenses["MIT"] > 0.95)
+		assert.NotContains(t, licenses, "commercial")
+		assert.NotContains(t, licenses, "non-commercial")
+	}
+}
+
+func TestDetectDualCommercialLicense(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE.opensource": referenceText(t, "GPL-3.0-only"),
+		"LICENSE.commercial": "Foo Commercial License\n\nThis license applies to the customers who " +
+			"purchased a commercial subscription of Foo from Foo Inc. The software may only be used " +
+			"in accordance with the terms of the order form signed by the customer.\n",
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.True(t, result.DualLicensed)
+	licenses := result.Licenses()
+	assert.Contains(t, licenses, "GPL-3.0-only")
+	assert.Equal(t, float32(1), licenses["commercial"])
+	for _, match := range result.Matches {
+		if match.License == "commercial" {
+			assert.Equal(t, "LICENSE.commercial", match.File)
+		} else {
+			assert.Equal(t, "LICENSE.opensource", match.File)
+		}
+	}
+
+	// the open source option alone
+	delete(fs, "LICENSE.commercial")
+	result, err = DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.False(t, result.DualLicensed)
+}
+
+func TestDetectLicenseDirectoryPatterns(t *testing.T) {
+	fs := memoryFiler{
+		"third_party_licenses/MIT.txt": referenceText(t, "MIT"),
+		"main.go":                      "package main\n",
+	}
+	_, err := Detect(fs)
+	assert.Equal(t, ErrNoLicenseFound, err)
+	result, err := DetectDetailedWithOptions(fs, Options{
+		LicenseDirectoryPatterns: []*regexp.Regexp{regexp.MustCompile("(?i)^third_party_licenses$")}})
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", result.Matches[0].License)
+	assert.Equal(t, "third_party_licenses/MIT.txt", result.Matches[0].File)
+	assert.Equal(t, PlanLicenseFiles, result.Matches[0].Plan)
+}
+
+func TestDetectLicenseDirectoryMaxDepth(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSES/MIT.txt":            referenceText(t, "MIT"),
+		"LICENSES/vendor/foo/LICENSE": referenceText(t, "ISC"),
+		"LICENSES/a/b/c/d/e/LICENSE":  referenceText(t, "Apache-2.0"),
+	}
+	files := func(result *Result) map[string]bool {
+		licensed := map[string]bool{}
+		for _, match := range result.Matches {
+			licensed[match.File] = true
+		}
+		return licensed
+	}
+	// only the direct files by default
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"LICENSES/MIT.txt": true}, files(result))
+	assert.Empty(t, result.Warnings)
+
+	result, err = DetectDetailedWithOptions(fs, Options{MaxDepth: 4})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"LICENSES/MIT.txt": true, "LICENSES/vendor/foo/LICENSE": true},
+		files(result))
+	assert.Equal(t, []string{"LICENSES is nested too deep, the files below depth 4 are skipped"},
+		result.Warnings)
+
+	result, err = DetectDetailedWithOptions(fs, Options{MaxDepth: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"LICENSES/MIT.txt": true}, files(result))
+	assert.Equal(t, []string{"LICENSES is nested too deep, the files below depth 1 are skipped"},
+		result.Warnings)
+
+	result, err = DetectDetailedWithOptions(fs, Options{MaxDepth: 6})
+	assert.Nil(t, err)
+	assert.Len(t, files(result), 3)
+	assert.Empty(t, result.Warnings)
+}
+
+func TestDetectFirstHeaderComment(t *testing.T) {
+	header := "// " + strings.Replace(strings.TrimSpace(referenceText(t, "MIT")), "\n", "\n// ", -1) +
+		"\n\n// Package key derives the license keys. The key of each license in the copyright\n" +
+		"// notices is stable, so that the licenses which are redistributed under the other\n" +
+		"// license names keep the same key, see the license list.\npackage key\n"
+	result, err := DetectDetailedWithOptions(memoryFiler{"key.go": header},
+		Options{ScanHeaders: true, FirstHeaderComment: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", result.Matches[0].License)
+	assert.InDelta(t, 1, result.Matches[0].Confidence, 0.01)
+	assert.Equal(t, PlanHeaders, result.Matches[0].Plan)
+}
+
+func TestDetectSwiftPackageHeader(t *testing.T) {
+	manifest := "// swift-tools-version:5.5\n\n" +
+		"// Copyright 2021 Foo Inc.\n" +
+		"//\n" +
+		"// Licensed under the Apache License, Version 2.0 (the \"License\");\n" +
+		"// you may not use this file except in compliance with the License.\n" +
+		"// You may obtain a copy of the License at\n" +
+		"//\n" +
+		"//     http://www.apache.org/licenses/LICENSE-2.0\n" +
+		"//\n" +
+		"// Unless required by applicable law or agreed to in writing, software\n" +
+		"// distributed under the License is distributed on an \"AS IS\" BASIS,\n" +
+		"// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.\n" +
+		"// See the License for the specific language governing permissions and\n" +
+		"// limitations under the License.\n\n" +
+		"import PackageDescription\n\nlet package = Package(name: \"Foo\")\n"
+	fs := memoryFiler{"Package.swift": manifest}
+	for _, options := range []Options{{ScanHeaders: true}, {ScanHeaders: true, FirstHeaderComment: true}} {
+		result, err := DetectDetailedWithOptions(fs, options)
+		assert.Nil(t, err)
+		assert.Len(t, result.Matches, 1)
+		assert.Equal(t, "Apache-2.0", result.Matches[0].License)
+		assert.Equal(t, "Package.swift", result.Matches[0].File)
+		assert.Equal(t, "Swift", result.Matches[0].Language)
+	}
+	// the header confirms the LICENSE which it refers to
+	fs["LICENSE"] = referenceText(t, "Apache-2.0")
+	result, err := DetectDetailedWithOptions(fs, Options{RunAllPlans: true, ScanHeaders: true})
+	assert.Nil(t, err)
+	plans := map[string]string{}
+	for _, match := range result.Matches {
+		if match.License == "Apache-2.0" {
+			plans[match.Plan] = match.File
+		}
+	}
+	assert.Equal(t, map[string]string{PlanLicenseFiles: "LICENSE", PlanHeaders: "Package.swift"}, plans)
+}
+
+func TestDetectWellKnownLicenseURL(t *testing.T) {
+	result, err := DetectDetailedWithOptions(memoryFiler{
+		"main.go": "// See https://www.gnu.org/licenses/gpl-3.0.html\n\npackage main\n"},
+		Options{ScanHeaders: true})
+	assert.Nil(t, err)
+	assert.Len(t, result.Matches, 1)
+	assert.Equal(t, "GPL-3.0-only", result.Matches[0].License)
+	assert.InDelta(t, 0.8, result.Matches[0].Confidence, 0.001)
+	assert.Equal(t, PlanHeaders, result.Matches[0].Plan)
+}
+
+func TestDetectLicenseOverride(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE":      referenceText(t, "BSD-2-Clause"),
+		".license.yml": "# the LICENSE misses the third clause, see the discussion in #42\nlicense: \"BSD-3-Clause\"\n",
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{License: "BSD-3-Clause", Confidence: 1, File: ".license.yml",
+		Plan: PlanOverride}}, result.Matches)
+
+	delete(fs, ".license.yml")
+	fs[".github/license.yml"] = "spdx-id: BSD-3-Clause\n"
+	licenses, err := Detect(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]float32{"BSD-3-Clause": 1}, licenses)
+
+	// the override without the license is ignored
+	fs[".github/license.yml"] = "reason: none\n"
+	licenses, err = Detect(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, "BSD-2-Clause", bestLicense(licenses))
+}
+
+func TestDetectAuthoritativeFiles(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE":    referenceText(t, "MIT"),
+		"LICENSE-ID": "Apache-2.0\n",
+	}
+	options := Options{AuthoritativeFiles: []string{"SPDX.txt", "license-id"}}
+	result, err := DetectDetailedWithOptions(fs, options)
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{License: "Apache-2.0", Confidence: 1, File: "LICENSE-ID",
+		Plan: PlanOverride}}, result.Matches)
+
+	fs["SPDX.txt"] = "# the single source of truth\nSPDX-License-Identifier: BSD-3-Clause\n"
+	licenses, err := DetectWithOptions(fs, options)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]float32{"BSD-3-Clause": 1}, licenses)
+
+	licenses, err = Detect(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", bestLicense(licenses))
+}
+
+func TestDetectHumansTxt(t *testing.T) {
+	fs := memoryFiler{
+		"humans.txt": `/* TEAM */
+Developer: Jane Doe
+Site: https://example.com
+
+/* SITE */
+Last update: 2018/05/01
+Standards: HTML5, CSS3
+License: MIT
+`,
+		"index.html": "<html></html>",
+	}
+	licenses := mustDetect(t, fs)
+	assert.Contains(t, licenses, "MIT")
+
+	fs = memoryFiler{
+		".well-known/license.txt": "This website is distributed under the MIT license.",
+	}
+	licenses = mustDetect(t, fs)
+	assert.Contains(t, licenses, "MIT")
+}
+
+func TestDetectUnicodeLicenses(t *testing.T) {
+	licenses := mustDetect(t, memoryFiler{"LICENSE": referenceText(t, "Unicode-DFS-2016")})
+	assert.InDelta(t, 1, licenses["Unicode-DFS-2016"], 0.001)
+	// the Terms of Use are only referenced by their URL
+	assert.NotContains(t, licenses, "Unicode-TOU")
+	licenses = mustDetect(t, memoryFiler{"LICENSE": referenceText(t, "Unicode-TOU")})
+	assert.InDelta(t, 1, licenses["Unicode-TOU"], 0.001)
+	assert.NotContains(t, licenses, "Unicode-DFS-2016")
+}
+
+func TestDetectRuntimeLicenses(t *testing.T) {
+	for _, name := range []string{"PHP-3.01", "PHP-3.0", "Ruby", "Python-2.0"} {
+		licenses := mustDetect(t, memoryFiler{"LICENSE": referenceText(t, name)})
+		assert.Equal(t, name, bestLicense(licenses), name)
+		assert.InDelta(t, 1, licenses[name], 0.001, name)
+	}
+	// PHP-3.01 differs from PHP-3.0 in a few words only
+	licenses := mustDetect(t, memoryFiler{"LICENSE": referenceText(t, "PHP-3.01")})
+	assert.True(t, licenses["PHP-3.0"] < licenses["PHP-3.01"])
+}
+
+func TestDetectSkipsMinifiedSources(t *testing.T) {
+	fs := memoryFiler{
+		"README.md": "# Foo\n\nFoo does nothing.\n",
+		"dist/foo.min.js": "/*\n" + referenceText(t, "MIT") + "*/\n" +
+			strings.Repeat("!function(e,t){return e.x=t,e}(this);", 40),
+		"src/foo.js": strings.Replace(fmt.Sprintf(apacheHeader, 2018), "package foo", "", 1),
+	}
+	options := Options{ScanHeaders: true}
+	result, err := DetectDetailedWithOptions(fs, options)
+	assert.Nil(t, err)
+	for _, match := range result.Matches {
+		assert.Equal(t, "src/foo.js", match.File)
+	}
+	delete(fs, "src/foo.js")
+	_, err = DetectWithOptions(fs, options)
+	assert.Equal(t, ErrNoLicenseFound, err)
+}
+
+func TestDetectOpenGovernmentLicence(t *testing.T) {
+	licenses := mustDetect(t, memoryFiler{"LICENSE": referenceText(t, "OGL-UK-3.0")})
+	assert.Equal(t, "OGL-UK-3.0", bestLicense(licenses))
+	assert.InDelta(t, 1, licenses["OGL-UK-3.0"], 0.001)
+	licenses = mustDetect(t, memoryFiler{"README.md": "# Data\n\n" +
+		"Contains public sector information licensed under the [Open Government Licence v3.0]" +
+		"(http://www.nationalarchives.gov.uk/doc/open-government-licence/version/3/).\n"})
+	assert.Equal(t, "OGL-UK-3.0", bestLicense(licenses))
+}
+
+func TestDetectOpenDataCommons(t *testing.T) {
+	for _, name := range []string{"PDDL-1.0", "ODC-By-1.0", "ODbL-1.0"} {
+		licenses := mustDetect(t, memoryFiler{"LICENSE": referenceText(t, name)})
+		assert.Equal(t, name, bestLicense(licenses), name)
+		assert.InDelta(t, 1, licenses[name], 0.01, name)
+	}
+	licenses := mustDetect(t, memoryFiler{"LICENSE": referenceText(t, "ODC-By-1.0")})
+	assert.True(t, licenses["ODbL-1.0"] < licenses["ODC-By-1.0"])
+	licenses = mustDetect(t, memoryFiler{"README.md": "# Data\n\n## License\n\n" +
+		"The data is released under the Open Data Commons Public Domain Dedication and License " +
+		"(http://opendatacommons.org/licenses/pddl/1.0/).\n"})
+	assert.Equal(t, "PDDL-1.0", bestLicense(licenses))
+}
+
+func TestDetectReadmeOnly(t *testing.T) {
+	readme := []byte("# Foo\n\nFoo does nothing.\n\n## License\n\nFoo is released under the BSD-3-Clause license.\n")
+	licenses, err := DetectReadmeOnly(readme, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "BSD-3-Clause", bestLicense(licenses))
+	readme = []byte("Foo\n\nSee docs/LICENSE.txt for the details.\n")
+	fs := memoryFiler{"docs/LICENSE.txt": referenceText(t, "MIT")}
+	licenses, err = DetectReadmeOnly(readme, fs)
+	assert.Nil(t, err)
+	assert.InDelta(t, 1, licenses["MIT"], 0.001)
+	licenses, err = DetectReadmeOnly(readme, nil)
+	assert.Nil(t, err)
+	assert.Len(t, licenses, 0)
+}
+
+func TestDetectCeCILL(t *testing.T) {
+	for _, name := range []string{"CECILL-2.1", "CECILL-2.0", "CECILL-B", "CECILL-C", "CECILL-1.1"} {
+		licenses := mustDetect(t, memoryFiler{"Licence_CeCILL-en.txt": referenceText(t, name)})
+		assert.Equal(t, name, bestLicense(licenses), name)
+		assert.InDelta(t, 1, licenses[name], 0.001, name)
+	}
+	// the version 1.1 must not match "2.1" by the repeated "1"
+	licenses := mustDetect(t, memoryFiler{"README.rst": "Foo\n===\n\n:License: CeCILL v2.1\n"})
+	assert.Equal(t, map[string]float32{"CECILL-2.1": 1}, licenses)
+	licenses = mustDetect(t, memoryFiler{"README.rst": "Foo\n===\n\n:License: CeCILL-B\n"})
+	assert.Equal(t, map[string]float32{"CECILL-B": 1}, licenses)
+}
+
+func TestDetectWeightByProminence(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE": referenceText(t, "MIT"),
+		"CMakeLists.txt": "project(foo C)\n" +
+			"install(FILES tests/fixtures/vendored/LICENSE DESTINATION share/doc)\n",
+		"tests/fixtures/vendored/LICENSE": referenceText(t, "Apache-2.0"),
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, "Apache-2.0", result.Matches[0].License)
+	result, err = DetectDetailedWithOptions(fs, Options{WeightByProminence: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", result.Matches[0].License)
+	assert.InDelta(t, 1, result.Matches[0].Confidence, 0.001)
+	licenses := result.Licenses()
+	assert.InDelta(t, nestedProminence, licenses["Apache-2.0"], 0.001)
+	assert.True(t, licenses["MIT"] > licenses["Apache-2.0"])
+}
+
+func TestDetectIBMAndCommonPublicLicenses(t *testing.T) {
+	// CPL-1.0 is the ancestor of EPL-1.0 and differs mostly in the names
+	for _, name := range []string{"CPL-1.0", "IPL-1.0", "EPL-1.0"} {
+		licenses := mustDetect(t, memoryFiler{"LICENSE": referenceText(t, name)})
+		assert.Equal(t, name, bestLicense(licenses), name)
+		assert.InDelta(t, 1, licenses[name], 0.001, name)
+		for other, confidence := range licenses {
+			if other != name {
+				assert.True(t, confidence < licenses[name], other)
+			}
+		}
+	}
+}
+
+func TestDetectHTMLReadmeLicenseSection(t *testing.T) {
+	fs := memoryFiler{"README.html": `<html><body>
+<h1>Foo</h1>
+<p>Foo embeds Bar, which is released under the GPL-3.0 license.</p>
+<h2 id="license">License</h2>
+<p>Foo is licensed under the MIT license.</p>
+<h2>Contributing</h2>
+<p>Pull requests are welcome.</p>
+</body></html>`}
+	licenses := mustDetect(t, fs)
+	assert.Equal(t, "MIT", bestLicense(licenses))
+	for name := range licenses {
+		assert.False(t, strings.HasPrefix(name, "GPL"), name)
+	}
+}
+
+const beerwareNotice = `/*
+ * ----------------------------------------------------------------------------
+ * "THE BEER-WARE LICENSE" (Revision 42):
+ * <phk@FreeBSD.ORG> wrote this file.  As long as you retain this notice you
+ * can do whatever you want with this stuff. If we meet some day, and you think
+ * this stuff is worth it, you can buy me a beer in return.   Poul-Henning Kamp
+ * ----------------------------------------------------------------------------
+ */
+`
+
+func TestDetectBeerware(t *testing.T) {
+	licenses := mustDetect(t, memoryFiler{"LICENSE": beerwareNotice})
+	assert.Equal(t, map[string]float32{"Beerware": 1}, licenses)
+	licenses = mustDetectHeaders(t, memoryFiler{
+		"README.md": "# Foo\n",
+		"foo.c":     beerwareNotice + "int main() { return 0; }\n",
+	})
+	assert.Equal(t, map[string]float32{"Beerware": 1}, licenses)
+	// the title is missing but the clause is verbatim
+	licenses = mustDetect(t, memoryFiler{"LICENSE": "As long as you retain this notice you can do " +
+		"whatever you want with this stuff. If we meet some day, and you think this stuff is " +
+		"worth it, you can buy me a beer in return."})
+	assert.Equal(t, map[string]float32{"Beerware": 0.9}, licenses)
+}
+
+const asfHeader = `/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+`
+
+func TestDetectASFHeader(t *testing.T) {
+	result, err := DetectDetailedWithOptions(memoryFiler{
+		"src/main/java/org/apache/foo/Foo.java": asfHeader + "package org.apache.foo;\n"},
+		Options{ScanHeaders: true})
+	assert.Nil(t, err)
+	assert.Len(t, result.Matches, 1)
+	assert.Equal(t, "Apache-2.0", result.Matches[0].License)
+	assert.Equal(t, float32(1), result.Matches[0].Confidence)
+	assert.Equal(t, PlanHeaders, result.Matches[0].Plan)
+	// the header reflowed by the formatter and without the URL
+	licenses := mustDetectHeaders(t, memoryFiler{"foo.py": "# Licensed to the Apache Software Foundation (ASF) " +
+		"under one or more contributor license agreements; and licensed to you under the Apache " +
+		"License, Version 2.0.\nimport foo\n"})
+	assert.Equal(t, map[string]float32{"Apache-2.0": 1}, licenses)
+}
+
+func TestDetectRunAllPlans(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE":   referenceText(t, "Apache-2.0"),
+		"README.md": "# Foo\n\nFoo is licensed under Apache 2.0.\n\nThe parts in `contrib/` are under the MIT license.\n",
+		"main.go":   "package main",
+	}
+	licenses := mustDetect(t, fs)
+	assert.NotContains(t, licenses, "MIT")
+	result, err := DetectDetailedWithOptions(fs, Options{RunAllPlans: true, ScanHeaders: true})
+	assert.Nil(t, err)
+	plans := map[string]string{}
+	for _, match := range result.Matches {
+		if _, exists := plans[match.License]; !exists {
+			plans[match.License] = match.Plan
+		}
+	}
+	assert.Equal(t, PlanLicenseFiles, plans["Apache-2.0"])
+	assert.Equal(t, PlanReadme, plans["MIT"])
+	licenses, err = DetectWithOptions(fs, Options{RunAllPlans: true})
+	assert.Nil(t, err)
+	assert.Contains(t, licenses, "Apache-2.0")
+	assert.Contains(t, licenses, "MIT")
+}
+
+func TestDetectAnnotateOtherPlans(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE":   referenceText(t, "Apache-2.0"),
+		"README.md": "# Foo\n\nFoo is licensed under Apache 2.0.\n\nThe parts in `contrib/` are under the MIT license.\n",
+		"main.go":   "package main",
+	}
+	result, err := DetectDetailedWithOptions(fs, Options{AnnotateOtherPlans: true})
+	assert.Nil(t, err)
+	assert.Equal(t, mustDetect(t, fs), result.Licenses())
+	for _, match := range result.Matches {
+		assert.Equal(t, PlanLicenseFiles, match.Plan)
+	}
+	annotated := map[string]string{}
+	for _, match := range result.Annotations {
+		assert.NotEqual(t, PlanLicenseFiles, match.Plan)
+		annotated[match.License] = match.Plan
+	}
+	assert.Equal(t, PlanReadme, annotated["MIT"])
+	assert.Equal(t, PlanReadme, annotated["Apache-2.0"])
+	licenses, err := DetectWithOptions(fs, Options{AnnotateOtherPlans: true})
+	assert.Nil(t, err)
+	assert.NotContains(t, licenses, "MIT")
+	delete(fs, "LICENSE")
+	result, err = DetectDetailedWithOptions(fs, Options{AnnotateOtherPlans: true})
+	assert.Nil(t, err)
+	assert.Contains(t, result.Licenses(), "MIT")
+	assert.Empty(t, result.Annotations)
+}
+
+func TestDetectCERNOpenHardwareLicences(t *testing.T) {
+	for _, name := range []string{
+		"CERN-OHL-P-2.0", "CERN-OHL-S-2.0", "CERN-OHL-W-2.0",
+		"W3C", "W3C-19980720", "W3C-20150513"} {
+		licenses := mustDetect(t, memoryFiler{"LICENSE": referenceText(t, name)})
+		assert.Equal(t, name, bestLicense(licenses), name)
+		assert.InDelta(t, 1, licenses[name], 0.01, name)
+	}
+}
+
+func TestDetectLaTeXProjectPublicLicense(t *testing.T) {
+	for _, name := range []string{"LPPL-1.0", "LPPL-1.1", "LPPL-1.2", "LPPL-1.3a", "LPPL-1.3c"} {
+		licenses := mustDetect(t, memoryFiler{"LICENSE": referenceText(t, name)})
+		assert.Equal(t, name, bestLicense(licenses), name)
+		assert.InDelta(t, 1, licenses[name], 0.01, name)
+	}
+	result, err := DetectDetailedWithOptions(memoryFiler{"foo.sty": `% Copyright (C) 2020 by Foo
+%
+% This work may be distributed and/or modified under the
+% conditions of the LaTeX Project Public License, either version 1.3c
+% of this license or (at your option) any later version.
+% The latest version of this license is in
+%   https://www.latex-project.org/lppl.txt
+% and version 1.3c or later is part of all distributions of LaTeX
+% version 2008 or later.
+\NeedsTeXFormat{LaTeX2e}
+`}, Options{ScanHeaders: true})
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{License: "LPPL-1.3c", Confidence: 0.95, File: "foo.sty",
+		Plan: PlanHeaders, Occurrences: 1, Language: "TeX"}}, result.Matches)
+}
+
+func TestDetectFontException(t *testing.T) {
+	result, err := DetectDetailed(memoryFiler{"COPYING": `GNU FreeFont
+
+Copyright (C) 2002-2012 Free Software Foundation, Inc.
+
+This font is free software: you can redistribute it and/or modify it under
+the terms of the GNU General Public License as published by the Free Software
+Foundation, either version 3 of the License, or (at your option) any later
+version.
+
+As a special exception, if you create a document which uses this font, and
+embed this font or unaltered portions of this font into the document, this
+font does not by itself cause the resulting document to be covered by the
+GNU General Public License. This exception does not however invalidate any
+other reasons why the document might be covered by the GNU General Public
+License.
+`})
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{License: "GPL-3.0-or-later", Confidence: 0.95, File: "COPYING",
+		Plan: PlanLicenseFiles, Exception: "Font-exception-2.0"}}, result.Matches)
+	licenses := mustDetect(t, memoryFiler{"LICENSE": "The fonts are licensed under GPLv2 with font exception."})
+	assert.Contains(t, licenses, "GPL-2.0-only")
+}
+
+func TestDetectThirdPartyNotices(t *testing.T) {
+	notices := "This project bundles the following third party components.\n\n" +
+		"------------------------------------------------------------\n\nfoo 1.2.3\n\n" +
+		"------------------------------------------------------------\n\n" + referenceText(t, "MIT") +
+		"\n------------------------------------------------------------\n\nbar 4.5\n\n" +
+		"------------------------------------------------------------\n\n" + referenceText(t, "BSD-3-Clause")
+	result, err := DetectDetailed(memoryFiler{
+		"LICENSE":                 referenceText(t, "Apache-2.0"),
+		"THIRD_PARTY_NOTICES.txt": notices,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "Apache-2.0", bestLicense(result.Licenses()))
+	assert.NotContains(t, result.Licenses(), "MIT")
+	assert.NotContains(t, result.Licenses(), "BSD-3-Clause")
+	assert.Len(t, result.ThirdPartyLicenses, 2)
+	for i, expected := range []ThirdPartyLicense{
+		{File: "THIRD_PARTY_NOTICES.txt", Component: "foo 1.2.3", License: "MIT"},
+		{File: "THIRD_PARTY_NOTICES.txt", Component: "bar 4.5", License: "BSD-3-Clause"},
+	} {
+		actual := result.ThirdPartyLicenses[i]
+		assert.InDelta(t, 1, actual.Confidence, 0.05, expected.License)
+		actual.Confidence = 0
+		assert.Equal(t, expected, actual)
+	}
+	// the aggregate file is not the license of the project
+	_, err = DetectDetailed(memoryFiler{"third-party-licenses.txt": notices})
+	assert.Equal(t, ErrNoLicenseFound, err)
+}
+
+func TestDetectAcknowledgementsPlist(t *testing.T) {
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+	plist := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>PreferenceSpecifiers</key>
+	<array>
+		<dict>
+			<key>FooterText</key>
+			<string>This application makes use of the following third party libraries:</string>
+			<key>Title</key>
+			<string>Acknowledgements</string>
+			<key>Type</key>
+			<string>PSGroupSpecifier</string>
+		</dict>
+		<dict>
+			<key>FooterText</key>
+			<string>` + escape(referenceText(t, "MIT")) + `</string>
+			<key>License</key>
+			<string>MIT</string>
+			<key>Title</key>
+			<string>Alamofire</string>
+			<key>Type</key>
+			<string>PSGroupSpecifier</string>
+		</dict>
+		<dict>
+			<key>FooterText</key>
+			<string>Copyright 2019 Google LLC. See the LICENSE file.</string>
+			<key>License</key>
+			<string>Apache-2.0</string>
+			<key>Title</key>
+			<string>GoogleUtilities</string>
+			<key>Type</key>
+			<string>PSGroupSpecifier</string>
+		</dict>
+		<dict>
+			<key>FooterText</key>
+			<string>Generated by CocoaPods - https://cocoapods.org</string>
+			<key>Title</key>
+			<string></string>
+			<key>Type</key>
+			<string>PSGroupSpecifier</string>
+		</dict>
+	</array>
+	<key>StringsTable</key>
+	<string>Acknowledgements</string>
+</dict>
+</plist>
+`
+	result, err := DetectDetailed(memoryFiler{
+		"LICENSE":                                referenceText(t, "ISC"),
+		"Settings.bundle/Root.plist":             "<plist version=\"1.0\"><dict/></plist>",
+		"Settings.bundle/Acknowledgements.plist": plist,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "ISC", bestLicense(result.Licenses()))
+	assert.NotContains(t, result.Licenses(), "MIT")
+	assert.Len(t, result.ThirdPartyLicenses, 2)
+	for i, expected := range []ThirdPartyLicense{
+		{File: "Settings.bundle/Acknowledgements.plist", Component: "Alamofire", License: "MIT"},
+		{File: "Settings.bundle/Acknowledgements.plist", Component: "GoogleUtilities", License: "Apache-2.0"},
+	} {
+		actual := result.ThirdPartyLicenses[i]
+		assert.InDelta(t, 1, actual.Confidence, 0.05, expected.License)
+		actual.Confidence = 0
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestDetectLicensePreamble(t *testing.T) {
+	const mit = `Copyright (c) David Heinemeier Hansson
+
+Permission is hereby granted, free of charge, to any person obtaining
+a copy of this software and associated documentation files (the
+"Software"), to deal in the Software without restriction, including
+without limitation the rights to use, copy, modify, merge, publish,
+distribute, sublicense, and/or sell copies of the Software, and to
+permit persons to whom the Software is furnished to do so, subject to
+the following conditions:
+
+The above copyright notice and this permission notice shall be
+included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+`
+	const preamble = `Ruby on Rails is a web application framework written by David Heinemeier Hansson and
+the contributors of the project, who distribute it to everyone under the following terms.
+
+`
+	expected := mustDetect(t, memoryFiler{"MIT-LICENSE": mit})
+	licenses := mustDetect(t, memoryFiler{"MIT-LICENSE": preamble + mit})
+	assert.Equal(t, "MIT", bestLicense(licenses))
+	assert.Equal(t, expected["MIT"], licenses["MIT"])
+}
+
+func TestDetectBSDClauseCount(t *testing.T) {
+	const grant = `Copyright (c) 2015-present, Foo, Inc. All rights reserved.
+
+Redistribution and use in source and binary forms, with or without modification,
+are permitted provided that the following conditions are met:
+
+ * Redistributions of source code must retain the above copyright notice, this
+   list of conditions and the following disclaimer.
+
+ * Redistributions in binary form must reproduce the above copyright notice,
+   this list of conditions and the following disclaimer in the documentation
+   and/or other materials provided with the distribution.
+`
+	const nonEndorsement = `
+ * Neither the name Foo nor the names of its contributors may be used to
+   endorse or promote products derived from this software without specific
+   prior written permission.
+`
+	const disclaimer = `
+THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+`
+	// the title names no variant, the clauses do
+	for _, file := range []string{"LICENSE", "LICENSE.md"} {
+		licenses := mustDetect(t, memoryFiler{file: "BSD License\n\n" + grant + nonEndorsement + disclaimer})
+		assert.Equal(t, "BSD-3-Clause", bestLicense(licenses), file)
+	}
+	licenses := mustDetect(t, memoryFiler{"LICENSE": "BSD License\n\n" + grant + disclaimer})
+	assert.Equal(t, "BSD-2-Clause", bestLicense(licenses))
+	// the wrong title does not win over the clauses
+	licenses = mustDetect(t, memoryFiler{"LICENSE": "BSD 3-Clause License\n\n" + grant + disclaimer})
+	assert.Equal(t, "BSD-2-Clause", bestLicense(licenses))
+	// neither does the README
+	result, err := DetectDetailedWithOptions(memoryFiler{
+		"LICENSE":   "BSD License\n\n" + grant + nonEndorsement + disclaimer,
+		"README.md": "# Foo\n\n## License\n\nFoo is BSD licensed.\n",
+	}, Options{RunAllPlans: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "BSD-3-Clause", result.Matches[0].License)
+	assert.Equal(t, PlanLicenseFiles, result.Matches[0].Plan)
+}
+
+func TestDetectZlibAcknowledgement(t *testing.T) {
+	licenses := mustDetect(t, memoryFiler{"LICENSE": referenceText(t, "zlib-acknowledgement")})
+	assert.Equal(t, "zlib-acknowledgement", bestLicense(licenses))
+	assert.InDelta(t, 1, licenses["zlib-acknowledgement"], 0.01)
+	assert.True(t, licenses["Zlib"] < licenses["zlib-acknowledgement"])
+	licenses = mustDetect(t, memoryFiler{"LICENSE": referenceText(t, "Zlib")})
+	assert.Equal(t, "Zlib", bestLicense(licenses))
+	assert.True(t, licenses["zlib-acknowledgement"] < licenses["Zlib"])
+}
+
+func TestDetectDockerfileLabel(t *testing.T) {
+	fs := memoryFiler{
+		"Dockerfile": `FROM alpine:3.8
+LABEL org.opencontainers.image.title="foo" \
+      org.opencontainers.image.licenses="MIT OR Apache-2.0"
+RUN apk add --no-cache git
+`,
+		"README.md": "# Foo\n",
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	licenses := result.Licenses()
+	assert.Len(t, licenses, 2)
+	assert.Equal(t, float32(1), licenses["MIT"])
+	assert.Equal(t, float32(1), licenses["Apache-2.0"])
+	for _, match := range result.Matches {
+		assert.Equal(t, "Dockerfile", match.File)
+		assert.Equal(t, PlanManifests, match.Plan)
+	}
+
+	result, err = DetectDetailed(memoryFiler{"Dockerfile": "FROM scratch\n" +
+		"LABEL org.opencontainers.image.licenses=\"GPL-2.0-only WITH Classpath-exception-2.0\"\n"})
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{License: "GPL-2.0-only", Confidence: 1, File: "Dockerfile",
+		Plan: PlanManifests, Exception: "Classpath-exception-2.0"}}, result.Matches)
+
+	fs["LICENSE"] = referenceText(t, "BSD-3-Clause")
+	licenses = mustDetect(t, fs)
+	assert.Equal(t, "BSD-3-Clause", bestLicense(licenses))
+	assert.NotContains(t, licenses, "MIT")
+}
+
+func TestDetectPubspecLicense(t *testing.T) {
+	fs := memoryFiler{
+		"pubspec.yaml": `name: foo
+description: The Flutter plugin.
+version: 1.0.0
+license: BSD-3-Clause
+
+environment:
+  sdk: ">=2.12.0 <3.0.0"
+`,
+		"lib/foo.dart": "library foo;\n",
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{License: "BSD-3-Clause", Confidence: 1, File: "pubspec.yaml",
+		Plan: PlanManifests}}, result.Matches)
+
+	// the license file which pub.dev requires confirms the declared license
+	fs["LICENSE"] = referenceText(t, "BSD-3-Clause")
+	result, err = DetectDetailedWithOptions(fs, Options{RunAllPlans: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "BSD-3-Clause", bestLicense(result.Licenses()))
+	for _, match := range result.Matches {
+		if match.Plan == PlanManifests {
+			assert.False(t, match.MissingLicenseFile)
+		}
+	}
+
+	fs["pubspec.yaml"] = "name: foo\nlicense: LICENSE\n"
+	result, err = DetectDetailedWithOptions(fs, Options{RunAllPlans: true})
+	assert.Nil(t, err)
+	for _, match := range result.Matches {
+		assert.Equal(t, "LICENSE", match.File)
+	}
+}
+
+func TestDetectRubyLicense(t *testing.T) {
+	fs := memoryFiler{
+		"foo.gemspec": `Gem::Specification.new do |spec|
+  spec.name          = "foo"
+  spec.version       = Foo::VERSION
+  spec.license       = "Ruby"
+  spec.files         = Dir["lib/**/*.rb"]
+end
+`,
+		"lib/foo.rb": "module Foo\nend\n",
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{License: "Ruby", Confidence: 1, File: "foo.gemspec", Plan: PlanManifests}},
+		result.Matches)
+
+	licenses := mustDetect(t, memoryFiler{
+		"README.md": "# Foo\n\n## License\n\nFoo is released under the same terms as Ruby itself.\n"})
+	assert.Len(t, licenses, 2)
+	assert.Contains(t, licenses, "Ruby")
+	assert.Contains(t, licenses, "GPL-2.0-only")
+}
+
+func TestDetectHelmChartAnnotation(t *testing.T) {
+	fs := memoryFiler{
+		"Chart.yaml": `apiVersion: v2
+name: foo
+version: 1.2.0
+annotations:
+  artifacthub.io/license: Apache-2.0
+`,
+		"values.yaml":           "replicaCount: 1\n",
+		"templates/deploy.yaml": "kind: Deployment\n",
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{License: "Apache-2.0", Confidence: 1, File: "Chart.yaml", Plan: PlanManifests}},
+		result.Matches)
+
+	fs = memoryFiler{
+		"bundle.Dockerfile": "FROM scratch\n",
+		"manifests/foo.v0.1.0.clusterserviceversion.yaml": `apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: foo.v0.1.0
+  annotations:
+    artifacthub.io/license: MIT
+`,
+		"manifests/foo.crd.yaml": "artifacthub.io/license: GPL-3.0-only\n",
+	}
+	result, err = DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]float32{"MIT": 1}, result.Licenses())
+	assert.Equal(t, "manifests/foo.v0.1.0.clusterserviceversion.yaml", result.Matches[0].File)
+}
+
+func TestDetectAllowlist(t *testing.T) {
+	fs := memoryFiler{"LICENSE": referenceText(t, "BSD-3-Clause")}
+	options := Options{Allowlist: []string{"MIT", "Apache-2.0"}}
+	_, err := DetectWithOptions(fs, options)
+	assert.Equal(t, ErrNoLicenseFound, err)
+
+	// the next plan finds the allowed license
+	fs["README.md"] = "# Foo\n\n## License\n\nMIT\n"
+	result, err := DetectDetailedWithOptions(fs, options)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]float32{"MIT": 1}, result.Licenses())
+	assert.Equal(t, PlanReadme, result.Matches[0].Plan)
+
+	licenses, err := DetectWithOptions(fs, Options{Allowlist: []string{"bsd-3-clause"}})
+	assert.Nil(t, err)
+	assert.Len(t, licenses, 1)
+	assert.Equal(t, float32(1), licenses["BSD-3-Clause"])
+}
+
+func TestDetectCJKTokenizer(t *testing.T) {
+	// the Chinese half of MulanPSL-2.0 wrapped at a different width than the reference
+	fs := memoryFiler{"LICENSE": `您对“软件”的复制、使用、修改及分发受木兰宽松许可证，第2版
+（“本许可证”）的如下条款的约束：
+
+0. 定义
+
+“软件”是指由“贡献”构成的许可在“本许可证”下的程序和相关
+文档的集合。
+
+“贡献”是指由任一“贡献者”许可在“本许可证”下的受版权法保
+护的作品。
+
+“贡献者”是指将受版权法保护的作品许可在“本许可证”下的自然
+人或“法人实体”。
+
+“法人实体”是指提交贡献的机构及其“关联实体”。
+
+“关联实体”是指，对“本许可证”下的行为方而言，控制、受控制
+或与其共同受控制的机构，此处的控制是指有受控方或共同受控方至
+少50%直接或间接的投票权、资金或其他有价证券。
+
+1. 授予版权许可
+
+每个“贡献者”根据“本许可证”授予您永久性的、全球性的、免费
+的、非独占的、不可撤销的版权许可，您可以复制、使用、修改、分
+发其“贡献”，不论修改与否。
+
+2. 授予专利许可
+
+每个“贡献者”根据“本许可证”授予您永久性的、全球性的、免费
+的、非独占的、不可撤销的（根据本条规定撤销除外）专利许可，供
+您制造、委托制造、使用、许诺销售、销售、进口其“贡献”或以其
+它方式转移其“贡献”。前述专利许可仅限于“贡献者”现在或将来
+拥有或控制的其“贡献”本身或其“贡献”与许可“贡献”时的“软
+件”结合而将必然会侵犯的专利权利要求，不包括对“贡献”的修改
+或包含“贡献”的其他结合。如果您或您的“关联实体”直接或间接
+地，就“软件”或其中的“贡献”对任何人发起专利侵权诉讼（包括
+反诉或交叉诉讼）或其他专利维权行动，指控其侵犯专利权，则“本
+许可证”授予您对“软件”的专利许可自您提起诉讼或发起维权行动
+之日终止。
+
+3. 无商标许可
+
+“本许可证”不提供对“贡献者”的商品名称、商标、服务标志或产
+品名称的商标许可，但您为满足第4条规定的声明义务而必须使用除
+外。
+
+4. 分发限制
+
+您可以在任何媒介中将“软件”以源程序形式或可执行形式重新分发
+，不论修改与否，但您必须向接收者提供“本许可证”的副本，并保
+留“软件”中的版权、商标、专利及免责声明。
+
+5. 免责声明与责任限制
+
+“软件”及其中的“贡献”在提供时不带任何明示或默示的担保。在
+任何情况下，“贡献者”或版权所有者不对任何人因使用“软件”或
+其中的“贡献”而引发的任何直接或间接损失承担责任，不论因何种
+原因导致或者基于何种法律理论，即使其曾被建议有此种损失的可能
+性。
+
+6. 语言
+“本许可证”以中英文双语表述，中英文版本具有同等法律效力。如
+果中英文版本存在任何冲突不一致，以中文版为准。
+`}
+	_, err := DetectWithOptions(fs, Options{})
+	assert.Equal(t, ErrNoLicenseFound, err)
+	licenses, err := DetectWithOptions(fs, Options{Tokenizer: CJKTokenizer})
+	assert.Nil(t, err)
+	assert.True(t, licenses["MulanPSL-2.0"] > 0.75)
+
+	// the Latin texts are matched the same
+	fs = memoryFiler{"LICENSE": referenceText(t, "Apache-2.0")}
+	licenses, err = DetectWithOptions(fs, Options{Tokenizer: CJKTokenizer})
+	assert.Nil(t, err)
+	assert.Equal(t, mustDetect(t, fs), licenses)
+}
+
+func TestDetectDeclaredChoice(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE":    referenceText(t, "MIT"),
+		"Dockerfile": "FROM scratch\nLABEL org.opencontainers.image.licenses=\"MIT OR Apache-2.0\"\n",
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	licenses := result.Licenses()
+	assert.Equal(t, float32(1), licenses["MIT"])
+	assert.Equal(t, float32(1), licenses["Apache-2.0"])
+	var declared []Match
+	for _, match := range result.Matches {
+		if match.Plan == PlanManifests {
+			declared = append(declared, match)
+		} else {
+			assert.False(t, match.MissingLicenseFile)
+		}
+	}
+	assert.Equal(t, []Match{
+		{License: "Apache-2.0", Confidence: 1, File: "Dockerfile", Plan: PlanManifests,
+			MissingLicenseFile: true},
+		{License: "MIT", Confidence: 1, File: "Dockerfile", Plan: PlanManifests},
+	}, declared)
+
+	runAll, err := DetectDetailedWithOptions(fs, Options{RunAllPlans: true})
+	assert.Nil(t, err)
+	assert.Equal(t, result.Matches, runAll.Matches)
+
+	// the choice which the license files do not support is not reconciled
+	fs["LICENSE"] = referenceText(t, "BSD-3-Clause")
+	licenses = mustDetect(t, fs)
+	assert.NotContains(t, licenses, "MIT")
+	assert.NotContains(t, licenses, "Apache-2.0")
+
+	// neither is the conjunction
+	fs = memoryFiler{
+		"LICENSE":    referenceText(t, "MIT"),
+		"Dockerfile": "FROM scratch\nLABEL org.opencontainers.image.licenses=\"MIT AND Apache-2.0\"\n",
+	}
+	assert.NotContains(t, mustDetect(t, fs), "Apache-2.0")
+}
+
+func TestDetectYAMLSBOM(t *testing.T) {
+	fs := memoryFiler{
+		"foo.spdx.yaml": `spdxVersion: SPDX-2.3
+dataLicense: CC0-1.0
+SPDXID: SPDXRef-DOCUMENT
+name: foo
+documentDescribes:
+  - SPDXRef-Package
+packages:
+  - name: foo
+    SPDXID: SPDXRef-Package
+    versionInfo: 1.0.0
+    licenseConcluded: NOASSERTION
+    licenseDeclared: Apache-2.0
+  - name: left-pad
+    SPDXID: SPDXRef-Package-left-pad
+    licenseDeclared: WTFPL
+`,
+		"main.go": "package main\n",
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{License: "Apache-2.0", Confidence: 1, File: "foo.spdx.yaml", Plan: PlanManifests}},
+		result.Matches)
+}
+
+func TestDetectIvyLicense(t *testing.T) {
+	fs := memoryFiler{
+		"ivy.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<ivy-module version="2.0">
+  <info organisation="org.example" module="foo" revision="1.0">
+    <license name="Apache License, Version 2.0" url="http://www.apache.org/licenses/LICENSE-2.0"/>
+    <description>Foo does bar.</description>
+  </info>
+  <dependencies>
+    <dependency org="junit" name="junit" rev="4.12"/>
+  </dependencies>
+</ivy-module>
+`,
+		"build.xml": "<project name=\"foo\" default=\"jar\"/>\n",
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]float32{"Apache-2.0": 1}, result.Licenses())
+	for _, match := range result.Matches {
+		assert.Equal(t, "ivy.xml", match.File)
+		assert.Equal(t, PlanManifests, match.Plan)
+	}
+}
+
+func TestDetectVersionUncertain(t *testing.T) {
+	fs := memoryFiler{"README.md": "# Foo\n\n## License\n\nGPL\n"}
+	// the versions are reported as is by default
+	licenses := mustDetect(t, fs)
+	assert.Contains(t, licenses, "GPL-2.0-only")
+	assert.Contains(t, licenses, "GPL-3.0-or-later")
+	assert.NotContains(t, licenses, "GPL")
+	options := Options{CollapseVersions: true}
+	result, err := DetectDetailedWithOptions(fs, options)
+	assert.Nil(t, err)
+	assert.Len(t, result.Matches, 1)
+	match := result.Matches[0]
+	assert.Equal(t, "GPL", match.License)
+	assert.True(t, match.VersionUncertain)
+	assert.Equal(t, PlanReadme, match.Plan)
+	assert.Contains(t, match.Versions, "GPL-2.0-only")
+	assert.Contains(t, match.Versions, "GPL-3.0-or-later")
+	assert.NotContains(t, match.Versions, "LGPL-2.1-only")
+	licenses, err = DetectWithOptions(fs, options)
+	assert.Nil(t, err)
+	assert.Equal(t, result.Licenses(), licenses)
+
+	// the text of the license has the single version
+	fs = memoryFiler{"LICENSE": referenceText(t, "GPL-3.0-only")}
+	result, err = DetectDetailedWithOptions(fs, options)
+	assert.Nil(t, err)
+	for _, match := range result.Matches {
+		assert.False(t, match.VersionUncertain)
+		assert.Nil(t, match.Versions)
+	}
+	assert.Contains(t, result.Licenses(), "GPL-3.0-only")
+}
+
+func TestDetectExplainNormalization(t *testing.T) {
+	text := referenceText(t, "MIT")
+	for i := 0; i < 30; i++ {
+		text = fmt.Sprintf("Copyright (c) %d Contributor %d <contributor%d@example.com>\n", 1990+i, i, i) + text
+	}
+	fs := memoryFiler{"LICENSE": text}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Empty(t, result.Matches[0].Normalization)
+	result, err = DetectDetailedWithOptions(fs, Options{ExplainNormalization: true})
+	assert.Nil(t, err)
+	match := result.Matches[0]
+	assert.Equal(t, "MIT", match.License)
+	steps := map[string]NormalizationStep{}
+	for _, step := range match.Normalization {
+		assert.Equal(t, match.Confidence, step.After)
+		steps[step.Step] = step
+	}
+	assert.Len(t, steps, 10)
+	assert.True(t, steps["copyright lines"].Before < match.Confidence)
+	assert.Equal(t, match.Confidence, steps["bullets"].Before)
+}
+
+func TestDetectCanonicalText(t *testing.T) {
+	text := referenceText(t, "MIT")
+	spaced := "\n  " + strings.NewReplacer(" ", " \t ", "\n", "  \r\n").Replace(text)
+	var canonical []string
+	for _, fs := range []memoryFiler{{"LICENSE": text}, {"LICENSE.txt": spaced}} {
+		result, err := DetectDetailedWithOptions(fs, Options{CanonicalText: true})
+		assert.Nil(t, err)
+		assert.Equal(t, "MIT", result.Matches[0].License)
+		canonical = append(canonical, result.Matches[0].CanonicalText)
+	}
+	assert.NotEmpty(t, canonical[0])
+	assert.Equal(t, canonical[0], canonical[1])
+	assert.Equal(t, strings.ToLower(canonical[0]), canonical[0])
+	assert.NotContains(t, canonical[0], "\n")
+	result, err := DetectDetailed(memoryFiler{"LICENSE": text})
+	assert.Nil(t, err)
+	assert.Empty(t, result.Matches[0].CanonicalText)
+}
+
+func TestDetectTruncation(t *testing.T) {
+	text := referenceText(t, "Apache-2.0")
+	fs := memoryFiler{"LICENSE": text[:len(text)/2]}
+	_, err := DetectDetailed(fs)
+	assert.Equal(t, ErrNoLicenseFound, err)
+	result, err := DetectDetailedWithOptions(fs, Options{DetectTruncation: true})
+	assert.Nil(t, err)
+	match := result.Matches[0]
+	assert.Equal(t, "Apache-2.0", match.License)
+	assert.True(t, match.Truncated)
+	assert.InDelta(t, 0.5, match.Completeness, 0.1)
+	assert.InDelta(t, 0.5, match.Confidence, 0.1)
+	// the copy which lacks only the end matches as usual
+	fs = memoryFiler{"LICENSE": text[:len(text)*9/10]}
+	result, err = DetectDetailedWithOptions(fs, Options{DetectTruncation: true})
+	assert.Nil(t, err)
+	match = result.Matches[0]
+	assert.Equal(t, "Apache-2.0", match.License)
+	assert.True(t, match.Truncated)
+	assert.True(t, match.Confidence > 0.75)
+	result, err = DetectDetailedWithOptions(memoryFiler{"LICENSE": text}, Options{DetectTruncation: true})
+	assert.Nil(t, err)
+	for _, match := range result.Matches {
+		assert.False(t, match.Truncated, match.License)
+		assert.Zero(t, match.Completeness)
+	}
+}
+
+func TestDetectClassifySections(t *testing.T) {
+	fs := memoryFiler{"LICENSE": `THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+`}
+	detected, _ := Detect(fs)
+	assert.NotContains(t, detected, "BSD-3-Clause")
+	result, err := DetectDetailedWithOptions(fs, Options{ClassifySections: true})
+	assert.Nil(t, err)
+	licenses := map[string]Match{}
+	for _, match := range result.Matches {
+		assert.Equal(t, SectionsDisclaimerOnly, match.Sections, match.License)
+		licenses[match.License] = match
+	}
+	assert.Contains(t, licenses, "BSD-3-Clause")
+	assert.Equal(t, float32(1), licenses["BSD-3-Clause"].Confidence)
+	assert.NotContains(t, licenses, "Zend-2.0")
+
+	result, err = DetectDetailedWithOptions(
+		memoryFiler{"LICENSE": referenceText(t, "BSD-3-Clause")}, Options{ClassifySections: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "BSD-3-Clause", result.Matches[0].License)
+	assert.Equal(t, SectionsFull, result.Matches[0].Sections)
+
+	text := referenceText(t, "MIT")
+	text = text[:strings.Index(text, "THE SOFTWARE IS PROVIDED")]
+	result, err = DetectDetailedWithOptions(memoryFiler{"LICENSE": text}, Options{ClassifySections: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", result.Matches[0].License)
+	assert.Equal(t, SectionsGrantOnly, result.Matches[0].Sections)
+}
+
+func TestDetectUnorderedMatching(t *testing.T) {
+	// the warranty disclaimer goes first and the permission goes last
+	fs := memoryFiler{"LICENSE": `MIT License
+
+Copyright (c) 2018 Foo Authors
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+`}
+	licenses, err := Detect(fs)
+	assert.Nil(t, err)
+	assert.NotContains(t, licenses, "MIT")
+	licenses, err = DetectWithOptions(fs, Options{UnorderedMatching: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", bestLicense(licenses))
+	assert.True(t, licenses["MIT"] > 0.9)
+	// the ordered texts are matched the same way
+	fs["LICENSE"] = referenceText(t, "MIT")
+	licenses, err = DetectWithOptions(fs, Options{UnorderedMatching: true})
+	assert.Nil(t, err)
+	assert.Equal(t, mustDetect(t, fs)["MIT"], licenses["MIT"])
+}
+
+func TestDetectLicenseBadge(t *testing.T) {
+	for badge, expected := range map[string]string{
+		"[![License: MIT](https://img.shields.io/badge/License-MIT-yellow.svg)](https://opensource.org/licenses/MIT)": "MIT",
+		"![license](https://img.shields.io/badge/license-Apache--2.0-blue.svg)":                                       "Apache-2.0",
+		"<img src=\"https://img.shields.io/badge/licence-BSD__3__Clause-green\">":                                     "BSD-3-Clause",
+		"![License](https://badgen.net/badge/license/MPL-2.0/blue)":                                                   "MPL-2.0",
+	} {
+		fs := memoryFiler{"README.md": "# Foo\n\n" + badge + "\n\nFoo does things.\n"}
+		licenses, err := Detect(fs)
+		assert.Nil(t, err, badge)
+		assert.Equal(t, expected, bestLicense(licenses), badge)
+	}
+	_, err := Detect(memoryFiler{
+		"README.md": "# Foo\n\n![build](https://img.shields.io/badge/build-passing-green.svg)\n"})
+	assert.Equal(t, ErrNoLicenseFound, err)
+}
+
+func TestDetectPublicDomainDedication(t *testing.T) {
+	for _, phrase := range []string{
+		"I dedicate this work to the public domain.",
+		"The author has placed this code into the public domain.",
+		"This software is hereby released into the public domain by its author.",
+		"This file is in the public domain, do whatever you want with it.",
+		"All the contents of this repository are part of the public domain.",
+	} {
+		licenses, err := Detect(memoryFiler{"LICENSE": phrase + "\n"})
+		assert.Nil(t, err, phrase)
+		assert.Equal(t, map[string]float32{"public-domain": 0.75}, licenses, phrase)
+		licenses, err = Detect(memoryFiler{"README.md": "# Foo\n\nFoo parses things.\n\n" + phrase + "\n"})
+		assert.Nil(t, err, phrase)
+		assert.Equal(t, map[string]float32{"public-domain": 0.75}, licenses, phrase)
+	}
+	for _, phrase := range []string{
+		"The data set is not in the public domain.",
+		"Foo is not released into the public domain.",
+		"The fonts were never placed in the public domain.",
+		"This code isn't in the public domain.",
+	} {
+		_, err := Detect(memoryFiler{"README.md": "# Foo\n\n" + phrase + "\n"})
+		assert.Equal(t, ErrNoLicenseFound, err, phrase)
+	}
+	licenses := mustDetect(t, memoryFiler{"LICENSE": referenceText(t, "Unlicense")})
+	assert.Equal(t, "Unlicense", bestLicense(licenses))
+	assert.NotContains(t, licenses, "public-domain")
+}
+
+func TestDetectNonCommercialNotice(t *testing.T) {
+	notice := `Copyright (c) 2019 The Regents of the University. All rights reserved.
+
+Permission to use, copy and modify this software and its documentation for
+educational, research and not-for-profit purposes, without fee and without a
+signed licensing agreement, is hereby granted, provided that the above
+copyright notice and this paragraph appear in all copies. The software is
+provided for non-commercial research use only. Commercial use is prohibited
+without the written permission of the authors.
+`
+	licenses, err := Detect(memoryFiler{"LICENSE.txt": notice})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]float32{"non-commercial": 0.8}, licenses)
+	for _, phrase := range []string{
+		"The code is released for academic research purposes only.",
+		"This software may not be used for commercial purposes.",
+		"You may use the models for non-commercial purposes.",
+	} {
+		licenses, err = Detect(memoryFiler{"README.md": "# Foo\n\n## License\n\n" + phrase + "\n"})
+		assert.Nil(t, err, phrase)
+		assert.Equal(t, map[string]float32{"non-commercial": 0.8}, licenses, phrase)
+	}
+	_, err = Detect(memoryFiler{"README.md": "# Foo\n\nFree for commercial and non-commercial use.\n"})
+	assert.Equal(t, ErrNoLicenseFound, err)
+	licenses = mustDetect(t, memoryFiler{"LICENSE": referenceText(t, "CC-BY-NC-4.0")})
+	assert.Equal(t, "CC-BY-NC-4.0", bestLicense(licenses))
+	assert.NotContains(t, licenses, "non-commercial")
+}
+
+func TestDetectZendLicense(t *testing.T) {
+	// as distributed in Zend/LICENSE of the PHP sources
+	zend := `--------------------------------------------------------------------
+                The Zend Engine License, Version 2.00
+Copyright (c) 1999-2006 Zend Technologies Ltd. All rights reserved.
+--------------------------------------------------------------------
+
+Redistribution and use in source and binary forms, with or without
+modification, is permitted provided that the following conditions
+are met:
+
+  1. Redistributions of source code must retain the above copyright
+     notice, this list of conditions and the following disclaimer.
+
+  2. Redistributions in binary form must reproduce the above
+     copyright notice, this list of conditions and the following
+     disclaimer in the documentation and/or other materials provided
+     with the distribution.
+
+  3. The names "Zend" and "Zend Engine" must not be used to endorse
+     or promote products derived from this software without prior
+     permission from Zend Technologies Ltd. For written permission,
+     please contact license@zend.com.
+
+  4. Zend Technologies Ltd. may publish revised and/or new versions
+     of the license from time to time. Each version will be given a
+     distinguishing version number.
+     Once covered code has been published under a particular version
+     of the license, you may always continue to use it under the
+     terms of that version. You may also choose to use such covered
+     code under the terms of any subsequent version of the license
+     published by Zend Technologies Ltd. No one other than Zend
+     Technologies Ltd. has the right to modify the terms applicable
+     to covered code created under this License.
+
+  5. Redistributions of any form whatsoever must retain the following
+     acknowledgment:
+     "This product includes the Zend Engine, freely available at
+     http://www.zend.com"
+
+  6. All advertising materials mentioning features or use of this
+     software must display the following acknowledgment:
+     "The Zend Engine is freely available at http://www.zend.com"
+
+THIS SOFTWARE IS PROVIDED BY ZEND TECHNOLOGIES LTD. ` + "``AS IS''" + ` AND
+ANY EXPRESSED OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO,
+THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A
+PARTICULAR PURPOSE ARE DISCLAIMED.  IN NO EVENT SHALL ZEND
+TECHNOLOGIES LTD. BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF
+USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED
+AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT
+LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN
+ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+POSSIBILITY OF SUCH DAMAGE.
+--------------------------------------------------------------------
+`
+	licenses := mustDetect(t, memoryFiler{"LICENSE": zend})
+	assert.Equal(t, "Zend-2.0", bestLicense(licenses))
+	assert.True(t, licenses["Zend-2.0"] > 0.9)
+	for name, confidence := range licenses {
+		if strings.HasPrefix(name, "BSD") {
+			assert.True(t, confidence < licenses["Zend-2.0"]-0.1, name)
+		}
+	}
+	// the PHP License of the PHP frameworks is not confused with the Zend Engine License
+	licenses = mustDetect(t, memoryFiler{"LICENSE": referenceText(t, "PHP-3.01")})
+	assert.Equal(t, "PHP-3.01", bestLicense(licenses))
+	assert.NotContains(t, licenses, "Zend-2.0")
+}
+
+func TestDetectSmartQuotes(t *testing.T) {
+	mit := referenceText(t, "MIT")
+	smart := strings.NewReplacer(
+		`"Software"`, "“Software”", `"AS IS"`, "‘‘AS IS’’", "files", "ﬁles", " ", "\u00a0",
+		"é", "e\u0301").Replace(mit)
+	assert.NotEqual(t, mit, smart)
+	licenses := mustDetect(t, memoryFiler{"LICENSE": smart})
+	assert.Equal(t, mustDetect(t, memoryFiler{"LICENSE": mit})["MIT"], licenses["MIT"])
+	assert.Equal(t, "MIT", bestLicense(licenses))
+}
+
+func TestDetectCWD(t *testing.T) {
+	root, err := ioutil.TempDir("", "licensedb-cwd-")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+	assert.Nil(t, ioutil.WriteFile(path.Join(root, "LICENSE"), []byte(referenceText(t, "MIT")), 0644))
+	cwd, err := os.Getwd()
+	assert.Nil(t, err)
+	assert.Nil(t, os.Chdir(root))
+	defer os.Chdir(cwd)
+	licenses, err := DetectCWD()
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", bestLicense(licenses))
+	other, err := DetectPath(root)
+	assert.Nil(t, err)
+	assert.Equal(t, licenses, other)
+	_, err = DetectPath(path.Join(root, "missing"))
+	assert.NotNil(t, err)
+}
+
+func TestDetectSymlinkedLicense(t *testing.T) {
+	root, err := ioutil.TempDir("", "licensedb-symlink-")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+	assert.Nil(t, os.Mkdir(path.Join(root, "LICENSES"), 0755))
+	assert.Nil(t, ioutil.WriteFile(path.Join(root, "LICENSES", "MIT.txt"),
+		[]byte(referenceText(t, "MIT")), 0644))
+	assert.Nil(t, os.Symlink(path.Join("LICENSES", "MIT.txt"), path.Join(root, "LICENSE")))
+	licenses, err := DetectPath(root)
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", bestLicense(licenses))
+	// the symlink checked out as a plain file with the target path
+	result, err := DetectDetailed(memoryFiler{
+		"LICENSE":          "LICENSES/MIT.txt\n",
+		"LICENSES/MIT.txt": referenceText(t, "MIT"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", result.Matches[0].License)
+	assert.Equal(t, "LICENSES/MIT.txt", result.Matches[0].File)
+	// the file name identifies the license if the text is unrecognizable
+	result, err = DetectDetailed(memoryFiler{
+		"LICENSE":          "LICENSES/MIT.txt",
+		"LICENSES/MIT.txt": "Copyright (c) 2020 Foo\n",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{License: "MIT", Confidence: 1, File: "LICENSES/MIT.txt",
+		Plan: PlanLicenseFiles}}, result.Matches)
+}
+
+func TestDetectRareOSILicenses(t *testing.T) {
+	for _, name := range []string{
+		"BlueOak-1.0.0", "Entessa", "Frameworx-1.0", "Motosoto", "Nokia", "Watcom-1.0"} {
+		licenses := mustDetect(t, memoryFiler{"LICENSE": referenceText(t, name)})
+		assert.Equal(t, name, bestLicense(licenses), name)
+		assert.InDelta(t, 1, licenses[name], 0.01, name)
+	}
+}
+
+const commonsClause = `“Commons Clause” License Condition v1.0
+
+The Software is provided to you by the Licensor under the License, as defined below, subject to the following condition.
+
+Without limiting other conditions in the License, the grant of rights under the License will not include, and the License does not grant to you, the right to Sell the Software.
+
+For purposes of the foregoing, “Sell” means practicing any or all of the rights granted to you under the License to provide to third parties, for a fee or other consideration (including without limitation fees for hosting or consulting/ support services related to the Software), a product or service whose value derives, entirely or substantially, from the functionality of the Software. Any license notice or attribution required by the License must also include this Commons Clause License Condition notice.
+
+Software: Foo
+
+License: Apache 2.0
+
+Licensor: Foo, Inc.
+`
+
+func TestDetectCommonsClause(t *testing.T) {
+	fs := memoryFiler{"LICENSE": commonsClause + "\n\n" + referenceText(t, "Apache-2.0")}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, "Apache-2.0", result.Matches[0].License)
+	assert.True(t, result.Matches[0].Confidence > 0.9)
+	for _, match := range result.Matches {
+		assert.Equal(t, "Commons-Clause", match.Rider)
+	}
+	result, err = DetectDetailed(memoryFiler{"LICENSE": referenceText(t, "Apache-2.0")})
+	assert.Nil(t, err)
+	assert.Empty(t, result.Matches[0].Rider)
+}
+
+func TestDetectFieldOfUseRestrictions(t *testing.T) {
+	fs := memoryFiler{"LICENSE": referenceText(t, "MIT") +
+		"\nThe Software may not be used for military purposes or in the development of weapons.\n"}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", result.Matches[0].License)
+	assert.True(t, result.Matches[0].Confidence > 0.9)
+	for _, match := range result.Matches {
+		assert.Equal(t, []string{"non-military"}, match.FieldOfUse)
+	}
+	result, err = DetectDetailed(memoryFiler{"LICENSE": referenceText(t, "JSON")})
+	assert.Nil(t, err)
+	assert.Equal(t, "JSON", result.Matches[0].License)
+	assert.Equal(t, []string{"good-not-evil"}, result.Matches[0].FieldOfUse)
+	result, err = DetectDetailed(memoryFiler{"LICENSE": referenceText(t, "MIT")})
+	assert.Nil(t, err)
+	assert.Empty(t, result.Matches[0].FieldOfUse)
+}
+
+func TestDetectReadmeProximity(t *testing.T) {
+	const unrelated = "# Foo\n\nCopyright (c) 2015 Foo authors.\n\n" +
+		"Foo grew into a fast, small and well documented compiler with many users across the " +
+		"world, both in academia and in the industry. It was started by the Scheme group at MIT " +
+		"in 2015 and grew into a fast, small and well documented compiler with many users across " +
+		"the world, both in academia and in the industry, and with the bindings for several " +
+		"languages.\n\n"
+	options := Options{WeightReadmeProximity: true}
+	contextual, err := DetectWithOptions(memoryFiler{
+		"README.md": "# Foo\n\nFoo is licensed under the MIT License.\n"}, options)
+	assert.Nil(t, err)
+	assert.Equal(t, float32(1), contextual["MIT"])
+	licenses, err := DetectWithOptions(memoryFiler{
+		"README.md": unrelated + "Foo is licensed under the MIT License.\n"}, options)
+	assert.Nil(t, err)
+	assert.Equal(t, contextual, licenses)
+	fs := memoryFiler{"README.md": unrelated + "The licensing terms are in the COPYING file.\n"}
+	licenses = mustDetect(t, fs)
+	assert.Equal(t, float32(1), licenses["MIT"])
+	_, err = DetectWithOptions(fs, options)
+	assert.Equal(t, ErrNoLicenseFound, err)
+}
+
+func TestDetectHistoricalPermissionNotice(t *testing.T) {
+	const notice = `Copyright (c) 1995 Acme Corp.
+
+Permission to use, copy, modify, and distribute this software and its
+documentation for any purpose and without fee is hereby granted, provided
+that the above copyright notice appear in all copies and that both that
+copyright notice and this permission notice appear in supporting
+documentation, and that the name of Acme Corp. not be used in advertising
+or publicity pertaining to distribution of the software without specific,
+written prior permission.
+
+ACME CORP. DISCLAIMS ALL WARRANTIES WITH REGARD TO THIS SOFTWARE,
+INCLUDING ALL IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS, IN NO
+EVENT SHALL ACME CORP. BE LIABLE FOR ANY SPECIAL, INDIRECT OR
+CONSEQUENTIAL DAMAGES OR ANY DAMAGES WHATSOEVER RESULTING FROM LOSS OF
+USE, DATA OR PROFITS, WHETHER IN AN ACTION OF CONTRACT, NEGLIGENCE OR
+OTHER TORTIOUS ACTION, ARISING OUT OF OR IN CONNECTION WITH THE USE OR
+PERFORMANCE OF THIS SOFTWARE.
+`
+	licenses := mustDetect(t, memoryFiler{"LICENSE": notice})
+	assert.Equal(t, "HPND", bestLicense(licenses))
+	assert.NotContains(t, licenses, "MIT")
+	assert.NotContains(t, licenses, "0BSD")
+	licenses = mustDetectHeaders(t, memoryFiler{"main.c": "/*\n" + notice + "*/\n\nint main() {}\n"})
+	assert.Equal(t, "HPND", bestLicense(licenses))
+	// the X Consortium license is MIT without the HPND advertising clause
+	licenses = mustDetect(t, memoryFiler{"LICENSE": referenceText(t, "X11")})
+	assert.Equal(t, "X11", bestLicense(licenses))
+}
+
+func TestDetectAdditionalTerms(t *testing.T) {
+	const restriction = "Additional restriction: the Software may not be used by any company " +
+		"with more than 500 employees without a separate written agreement."
+	fs := memoryFiler{"LICENSE": referenceText(t, "MIT") + "\n" + restriction + "\n"}
+	options := Options{DetectAdditionalTerms: true}
+	result, err := DetectDetailedWithOptions(fs, options)
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", result.Matches[0].License)
+	assert.True(t, result.Matches[0].Confidence > 0.9)
+	assert.True(t, result.Matches[0].HasAdditionalTerms)
+	assert.Equal(t, restriction, result.Matches[0].AdditionalTerms)
+	// the terms are not reported without the option
+	if result, err := DetectDetailed(fs); err == nil {
+		assert.False(t, result.Matches[0].HasAdditionalTerms)
+	}
+	// the trailing signature is not the additional terms
+	fs["LICENSE"] = referenceText(t, "MIT") + "\nJohn Doe\n"
+	result, err = DetectDetailedWithOptions(fs, options)
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", result.Matches[0].License)
+	assert.False(t, result.Matches[0].HasAdditionalTerms)
+	assert.Empty(t, result.Matches[0].AdditionalTerms)
+}
+
+func TestDetectMultilingualReadme(t *testing.T) {
+	fs := memoryFiler{"README.md": "# Foo\n\nFoo is a fast parser of the configuration files.\n\n" +
+		"## Usage\n\nRun foo.\n\n# Foo (日本語)\n\nFooは設定ファイルの高速なパーサーです。\n\n" +
+		"## ライセンス\n\nMITライセンスの下で公開されています。\n"}
+	licenses, err := DetectWithOptions(fs, Options{MultilingualReadme: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", bestLicense(licenses))
+	assert.Equal(t, float32(1), licenses["MIT"])
+	_, err = Detect(fs)
+	assert.Equal(t, ErrNoLicenseFound, err)
+}
+
+func TestQueryAllScores(t *testing.T) {
+	corpus := 0
+	archive := tar.NewReader(bytes.NewReader(assets.MustAsset("licenses.tar")))
+	for header, err := archive.Next(); err != io.EOF; header, err = archive.Next() {
+		assert.Nil(t, err)
+		if strings.HasSuffix(header.Name, ".txt") {
+			corpus++
+		}
+	}
+	text := referenceText(t, "MIT")
+	scores, err := QueryAllScores(text)
+	assert.Nil(t, err)
+	assert.Len(t, scores, corpus)
+	licenses := mustDetect(t, memoryFiler{"LICENSE": text})
+	assert.Equal(t, bestLicense(licenses), bestLicense(scores))
+	assert.Equal(t, licenses[bestLicense(licenses)], scores[bestLicense(scores)])
+	for name, confidence := range licenses {
+		assert.Equal(t, confidence, scores[name], name)
+	}
+	for _, score := range scores {
+		assert.True(t, score >= 0 && score <= 1)
+	}
+	assert.True(t, scores["GPL-3.0-only"] < 0.5)
+}
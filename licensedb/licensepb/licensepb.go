@@ -0,0 +1,47 @@
+// Package licensepb contains the protocol buffers messages of the license detection results,
+// defined in licensedb.proto, to wrap the detector in a gRPC service. licensedb.pb.go is
+// generated by protoc-gen-go of github.com/golang/protobuf.
+package licensepb
+
+//go:generate protoc --go_out=. licensedb.proto
+
+import (
+	"strings"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb"
+)
+
+// FromResult converts the result of licensedb.DetectDetailed to the protocol buffers message.
+func FromResult(result *licensedb.Result) *Result {
+	message := &Result{PatentGrant: result.PatentGrant}
+	for _, match := range result.Matches {
+		spdxID := strings.TrimPrefix(match.License, "deprecated_")
+		if match.VersionUncertain {
+			// the family is not an SPDX identifier
+			spdxID = ""
+		}
+		message.Matches = append(message.Matches, &Match{
+			License:            match.License,
+			Confidence:         match.Confidence,
+			Source:             match.File,
+			SpdxId:             spdxID,
+			Plan:               match.Plan,
+			Exception:          match.Exception,
+			Occurrences:        int32(match.Occurrences),
+			Language:           match.Language,
+			Rider:              match.Rider,
+			VersionUncertain:   match.VersionUncertain,
+			Versions:           match.Versions,
+			Key:                match.Key,
+			MissingLicenseFile: match.MissingLicenseFile,
+			Evidence:           int32(match.Evidence),
+		})
+	}
+	return message
+}
+
+// FromLicenses converts the result of licensedb.Detect to the protocol buffers message.
+// The matches are not attributed to the files and are ordered like licensedb.Sorted.
+func FromLicenses(licenses map[string]float32) *Result {
+	return FromResult(&licensedb.Result{Matches: licensedb.Sorted(licenses)})
+}
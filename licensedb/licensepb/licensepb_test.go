@@ -0,0 +1,54 @@
+package licensepb
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	result := &licensedb.Result{
+		Matches: []licensedb.Match{
+			{License: "deprecated_GPL-3.0", Confidence: 0.95, File: "src/main.cpp",
+				Plan: licensedb.PlanHeaders, Exception: "Qt-GPL-exception-1.0", Occurrences: 12,
+				Language: "C++"},
+			{License: "MIT", Confidence: 1, File: "LICENSE", Plan: licensedb.PlanLicenseFiles,
+				Rider: "Commons-Clause", Key: "c3a9d2a2-7a1c-5b1e-8d4a-0b5f0e6f2c11"},
+			{License: "GPL", Confidence: 0.6, File: "README.md", Plan: licensedb.PlanReadme,
+				VersionUncertain: true, Versions: []string{"GPL-2.0-only", "GPL-3.0-only"}},
+			{License: "Apache-2.0", Confidence: 1, File: "Dockerfile", Plan: licensedb.PlanManifests,
+				MissingLicenseFile: true, Evidence: 3},
+		},
+		PatentGrant: true,
+	}
+	message := FromResult(result)
+	assert.Equal(t, "GPL-3.0", message.Matches[0].SpdxId)
+	assert.Equal(t, "src/main.cpp", message.Matches[0].Source)
+	assert.Equal(t, "", message.Matches[2].SpdxId)
+	data, err := proto.Marshal(message)
+	assert.Nil(t, err)
+	decoded := &Result{}
+	assert.Nil(t, proto.Unmarshal(data, decoded))
+	assert.True(t, proto.Equal(message, decoded))
+	assert.NotNil(t, proto.Unmarshal(data[:len(data)-3], &Result{}))
+
+	message = FromLicenses(map[string]float32{"Apache-2.0": 0.98})
+	data, err = proto.Marshal(message)
+	assert.Nil(t, err)
+	decoded = &Result{}
+	assert.Nil(t, proto.Unmarshal(data, decoded))
+	assert.True(t, proto.Equal(&Match{License: "Apache-2.0", Confidence: 0.98, SpdxId: "Apache-2.0"},
+		decoded.Matches[0]))
+	assert.False(t, decoded.PatentGrant)
+}
+
+func TestUnmarshalSkipsUnknownFields(t *testing.T) {
+	// field 15 varint 1, field 1 "MIT", field 15 fixed64
+	data := []byte{15<<3 | 0, 1, 1<<3 | 2, 3, 'M', 'I', 'T', 15<<3 | 1, 0, 0, 0, 0, 0, 0, 0, 0}
+	match := &Match{}
+	assert.Nil(t, proto.Unmarshal(data, match))
+	assert.Equal(t, "MIT", match.License)
+	assert.Equal(t, int32(0), match.Evidence)
+}
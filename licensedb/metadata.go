@@ -0,0 +1,126 @@
+package licensedb
+
+import (
+	"strings"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal"
+)
+
+// spdxReferencePrefix is the prefix of the reference URLs of the SPDX licenses.
+const spdxReferencePrefix = "https://spdx.org/licenses/"
+
+var (
+	// the licenses whose "isOsiApproved" is true in the SPDX license list
+	osiApprovedLicenses = setOf(
+		"0BSD", "AAL", "AFL-1.1", "AFL-1.2", "AFL-2.0", "AFL-2.1", "AFL-3.0", "AGPL-3.0",
+		"AGPL-3.0-only", "AGPL-3.0-or-later", "APL-1.0", "APSL-1.0", "APSL-1.1", "APSL-1.2",
+		"APSL-2.0", "Apache-1.1", "Apache-2.0", "Artistic-1.0", "Artistic-1.0-Perl",
+		"Artistic-1.0-cl8", "Artistic-2.0", "BSD-1-Clause", "BSD-2-Clause", "BSD-2-Clause-Patent",
+		"BSD-3-Clause", "BSD-3-Clause-LBNL", "BSL-1.0", "BlueOak-1.0.0", "CAL-1.0",
+		"CAL-1.0-Combined-Work-Exception", "CATOSL-1.1", "CDDL-1.0", "CECILL-2.1", "CERN-OHL-P-2.0",
+		"CERN-OHL-S-2.0", "CERN-OHL-W-2.0", "CNRI-Python", "CPAL-1.0", "CPL-1.0", "CUA-OPL-1.0",
+		"ECL-1.0", "ECL-2.0", "EFL-1.0", "EFL-2.0", "EPL-1.0", "EPL-2.0", "EUDatagrid", "EUPL-1.1",
+		"EUPL-1.2", "Entessa", "Fair", "Frameworx-1.0", "GPL-2.0", "GPL-2.0+", "GPL-2.0-only",
+		"GPL-2.0-or-later", "GPL-3.0", "GPL-3.0+", "GPL-3.0-only", "GPL-3.0-or-later",
+		"GPL-3.0-with-GCC-exception", "HPND", "IPA", "IPL-1.0", "ISC", "Intel", "LGPL-2.0",
+		"LGPL-2.0+", "LGPL-2.0-only", "LGPL-2.0-or-later", "LGPL-2.1", "LGPL-2.1+", "LGPL-2.1-only",
+		"LGPL-2.1-or-later", "LGPL-3.0", "LGPL-3.0+", "LGPL-3.0-only", "LGPL-3.0-or-later",
+		"LPL-1.0", "LPL-1.02", "LPPL-1.3c", "LiLiQ-P-1.1", "LiLiQ-R-1.1", "LiLiQ-Rplus-1.1", "MIT",
+		"MIT-0", "MPL-1.0", "MPL-1.1", "MPL-2.0", "MPL-2.0-no-copyleft-exception", "MS-PL", "MS-RL",
+		"MirOS", "Motosoto", "MulanPSL-2.0", "Multics", "NASA-1.3", "NCSA", "NGPL", "NPOSL-3.0",
+		"NTP", "Naumen", "Nokia", "OCLC-2.0", "OFL-1.1", "OGTSL", "OLDAP-2.8", "OSET-PL-2.1",
+		"OSL-1.0", "OSL-2.0", "OSL-2.1", "OSL-3.0", "PHP-3.0", "PHP-3.01", "PostgreSQL",
+		"Python-2.0", "QPL-1.0", "RPL-1.1", "RPL-1.5", "RPSL-1.0", "RSCPL", "SISSL", "SPL-1.0",
+		"SimPL-2.0", "Sleepycat", "UCL-1.0", "UPL-1.0", "Unicode-DFS-2016", "Unlicense", "VSL-1.0",
+		"W3C", "Watcom-1.0", "Xnet", "ZPL-2.0", "ZPL-2.1", "Zlib", "jabberpl", "wxWindows",
+	)
+
+	// the licenses whose "isFsfLibre" is true in the SPDX license list
+	fsfLibreLicenses = setOf(
+		"AFL-1.1", "AFL-1.2", "AFL-2.0", "AFL-2.1", "AFL-3.0", "AGPL-1.0", "AGPL-3.0",
+		"AGPL-3.0-only", "AGPL-3.0-or-later", "APSL-2.0", "Apache-1.0", "Apache-1.1", "Apache-2.0",
+		"Artistic-2.0", "BSD-2-Clause", "BSD-2-Clause-FreeBSD", "BSD-3-Clause", "BSD-3-Clause-Clear",
+		"BSD-4-Clause", "BSL-1.0", "BitTorrent-1.1", "CC-BY-4.0", "CC-BY-SA-4.0", "CC0-1.0",
+		"CDDL-1.0", "CECILL-2.0", "CECILL-B", "CECILL-C", "CPAL-1.0", "CPL-1.0", "ClArtistic",
+		"Condor-1.1", "ECL-2.0", "EFL-2.0", "EPL-1.0", "EPL-2.0", "EUDatagrid", "EUPL-1.1",
+		"EUPL-1.2", "FSFAP", "FTL", "GFDL-1.1", "GFDL-1.1-only", "GFDL-1.1-or-later", "GFDL-1.2",
+		"GFDL-1.2-only", "GFDL-1.2-or-later", "GFDL-1.3", "GFDL-1.3-only", "GFDL-1.3-or-later",
+		"GPL-2.0", "GPL-2.0+", "GPL-2.0-only", "GPL-2.0-or-later", "GPL-3.0", "GPL-3.0+",
+		"GPL-3.0-only", "GPL-3.0-or-later", "HPND", "IJG", "IPA", "IPL-1.0", "ISC", "Imlib2",
+		"Intel", "LGPL-2.1", "LGPL-2.1+", "LGPL-2.1-only", "LGPL-2.1-or-later", "LGPL-3.0",
+		"LGPL-3.0+", "LGPL-3.0-only", "LGPL-3.0-or-later", "LPL-1.02", "LPPL-1.2", "LPPL-1.3a",
+		"MIT", "MPL-1.1", "MPL-2.0", "MS-PL", "MS-RL", "NCSA", "NOSL", "NPL-1.0", "NPL-1.1",
+		"Nokia", "ODbL-1.0", "OFL-1.0", "OFL-1.1", "OLDAP-2.3", "OLDAP-2.7", "OSL-1.0", "OSL-1.1",
+		"OSL-2.0", "OSL-2.1", "OSL-3.0", "OpenSSL", "PHP-3.01", "Python-2.0", "QPL-1.0", "RPSL-1.0",
+		"Ruby", "SGI-B-2.0", "SISSL", "SMLNJ", "SPL-1.0", "Sleepycat", "StandardML-NJ", "UPL-1.0",
+		"Unlicense", "Vim", "W3C", "WTFPL", "X11", "XFree86-1.1", "YPL-1.1", "ZPL-2.0", "ZPL-2.1",
+		"Zend-2.0", "Zimbra-1.3", "Zlib", "eCos-2.0", "gnuplot", "iMatix", "xinetd",
+	)
+)
+
+// setOf returns the set of the strings.
+func setOf(items ...string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+// Metadata is the information about the license from the SPDX license list, see SPDXMetadata.
+type Metadata struct {
+	// ID is the SPDX identifier of the license, e.g. "GPL-3.0-only".
+	ID string `json:"id"`
+	// Name is the full name of the license, e.g. "GNU General Public License v3.0 only".
+	Name string `json:"name"`
+	// OSIApproved indicates that the Open Source Initiative approved the license.
+	OSIApproved bool `json:"osi_approved"`
+	// FSFLibre indicates that the Free Software Foundation considers the license free.
+	FSFLibre bool `json:"fsf_libre"`
+	// Deprecated indicates that SPDX deprecated the identifier, e.g. "GPL-2.0" in favor
+	// of "GPL-2.0-only".
+	Deprecated bool `json:"deprecated"`
+	// Reference is the URL of the license in the SPDX license list.
+	Reference string `json:"reference"`
+}
+
+// SPDXMetadata returns the SPDX metadata of the license by its identifier, which is
+// case-insensitive. It returns false if the license is not in the default database, e.g.
+// the version families like "GPL" or the pseudo licenses like "public-domain".
+func SPDXMetadata(name string) (Metadata, bool, error) {
+	db, err := defaultDatabase()
+	if err != nil {
+		return Metadata{}, false, err
+	}
+	metadata, exists := db.SPDXMetadata(name)
+	return metadata, exists, nil
+}
+
+// lookupMetadata is SPDXMetadata in the given database.
+func lookupMetadata(name string, db *internal.Database) (Metadata, bool) {
+	key, fullName, exists := db.LookupLicense(name)
+	if !exists {
+		return Metadata{}, false
+	}
+	id := strings.TrimPrefix(key, "deprecated_")
+	return Metadata{
+		ID:          id,
+		Name:        fullName,
+		OSIApproved: osiApprovedLicenses[id],
+		FSFLibre:    fsfLibreLicenses[id],
+		Deprecated:  id != key,
+		Reference:   spdxReferencePrefix + id + ".html",
+	}, true
+}
+
+// attachMetadata sets Metadata of the matches and the annotations of the licenses which are
+// in the database.
+func (result *Result) attachMetadata(db *internal.Database) {
+	for _, matches := range [][]Match{result.Matches, result.Annotations} {
+		for i := range matches {
+			if metadata, exists := lookupMetadata(matches[i].License, db); exists {
+				matches[i].Metadata = &metadata
+			}
+		}
+	}
+}
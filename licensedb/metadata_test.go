@@ -0,0 +1,36 @@
+package licensedb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSPDXMetadata(t *testing.T) {
+	metadata, exists, err := SPDXMetadata("GPL-3.0-only")
+	assert.Nil(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, Metadata{
+		ID: "GPL-3.0-only", Name: "GNU General Public License v3.0 only", OSIApproved: true,
+		FSFLibre: true, Reference: "https://spdx.org/licenses/GPL-3.0-only.html"}, metadata)
+	metadata, exists, _ = SPDXMetadata("gpl-2.0")
+	assert.True(t, exists)
+	assert.Equal(t, "GPL-2.0", metadata.ID)
+	assert.True(t, metadata.Deprecated)
+	metadata, exists, _ = SPDXMetadata("WTFPL")
+	assert.True(t, exists)
+	assert.False(t, metadata.OSIApproved)
+	assert.True(t, metadata.FSFLibre)
+	_, exists, _ = SPDXMetadata("GPL")
+	assert.False(t, exists)
+
+	fs := memoryFiler{"LICENSE": referenceText(t, "MIT")}
+	result, err := DetectDetailedWithOptions(fs, Options{AttachMetadata: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", result.Matches[0].License)
+	assert.Equal(t, "MIT License", result.Matches[0].Metadata.Name)
+	assert.True(t, result.Matches[0].Metadata.OSIApproved)
+	result, err = DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Nil(t, result.Matches[0].Metadata)
+}
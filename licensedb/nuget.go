@@ -0,0 +1,92 @@
+package licensedb
+
+import (
+	"encoding/xml"
+	paths "path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+// nugetPackagesFile lists the NuGet packages which the .NET project references.
+const nugetPackagesFile = "packages.config"
+
+// nugetPackage is the <package> element of packages.config.
+type nugetPackage struct {
+	ID      string `xml:"id,attr"`
+	Version string `xml:"version,attr"`
+}
+
+// DetectNuGetPackages detects the licenses of the NuGet packages listed in packages.config of
+// the .NET project in the package cache, e.g. ~/.nuget/packages or the "packages" directory of
+// the solution. Each package is detected like Detect in its directory in the cache which has
+// the *.nuspec, so that both the declared license and the license files count. The result maps
+// "<id>/<version>" to the licenses. The packages which are not in the cache or which have
+// no license are not included.
+func DetectNuGetPackages(fs filer.Filer, cache string) (map[string]map[string]float32, error) {
+	content, err := fs.ReadFile(nugetPackagesFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", nugetPackagesFile)
+	}
+	packages, err := parseNuGetPackages(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse %s", nugetPackagesFile)
+	}
+	cacheFs, err := filer.FromDirectory(cache)
+	if err != nil {
+		return nil, err
+	}
+	defer cacheFs.Close()
+	result := map[string]map[string]float32{}
+	for _, pkg := range packages {
+		dir := findNuGetPackage(cacheFs, pkg)
+		if dir == "" {
+			continue
+		}
+		licenses, err := Detect(filer.NestFiler(cacheFs, dir))
+		if err == nil {
+			result[pkg.ID+"/"+pkg.Version] = licenses
+		}
+	}
+	return result, nil
+}
+
+// parseNuGetPackages returns the packages listed in packages.config.
+func parseNuGetPackages(content []byte) ([]nugetPackage, error) {
+	var config struct {
+		Packages []nugetPackage `xml:"package"`
+	}
+	if err := xml.Unmarshal(content, &config); err != nil {
+		return nil, err
+	}
+	var packages []nugetPackage
+	for _, pkg := range config.Packages {
+		if pkg.ID != "" && pkg.Version != "" {
+			packages = append(packages, pkg)
+		}
+	}
+	return packages, nil
+}
+
+// findNuGetPackage returns the directory of the package in the cache which contains its
+// *.nuspec, or the empty string if there is none. The global packages folder has the lower
+// case "<id>/<version>" directories, and the "packages" directory of the solution has
+// the "<id>.<version>" ones.
+func findNuGetPackage(fs filer.Filer, pkg nugetPackage) string {
+	for _, dir := range []string{
+		paths.Join(strings.ToLower(pkg.ID), strings.ToLower(pkg.Version)),
+		pkg.ID + "." + pkg.Version,
+	} {
+		files, err := fs.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			if !file.IsDir && strings.HasSuffix(strings.ToLower(file.Name), ".nuspec") {
+				return dir
+			}
+		}
+	}
+	return ""
+}
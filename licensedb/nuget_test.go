@@ -0,0 +1,41 @@
+package licensedb
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectNuGetPackages(t *testing.T) {
+	cache, err := ioutil.TempDir("", "licensedb-nuget-")
+	assert.Nil(t, err)
+	defer os.RemoveAll(cache)
+	dir := path.Join(cache, "newtonsoft.json", "13.0.1")
+	assert.Nil(t, os.MkdirAll(dir, 0755))
+	assert.Nil(t, ioutil.WriteFile(path.Join(dir, "newtonsoft.json.nuspec"), []byte(`<?xml version="1.0"?>
+<package xmlns="http://schemas.microsoft.com/packaging/2013/05/nuspec.xsd">
+  <metadata>
+    <id>Newtonsoft.Json</id>
+    <version>13.0.1</version>
+    <license type="expression">MIT</license>
+    <licenseUrl>https://aka.ms/deprecateLicenseUrl</licenseUrl>
+  </metadata>
+</package>
+`), 0644))
+	fs := memoryFiler{"packages.config": `<?xml version="1.0" encoding="utf-8"?>
+<packages>
+  <package id="Newtonsoft.Json" version="13.0.1" targetFramework="net472" />
+</packages>
+`}
+	packages, err := DetectNuGetPackages(fs, cache)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]map[string]float32{"Newtonsoft.Json/13.0.1": {"MIT": 1}}, packages)
+
+	_, err = DetectNuGetPackages(fs, path.Join(cache, "missing"))
+	assert.NotNil(t, err)
+	_, err = DetectNuGetPackages(memoryFiler{}, cache)
+	assert.NotNil(t, err)
+}
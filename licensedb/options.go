@@ -0,0 +1,292 @@
+package licensedb
+
+import (
+	paths "path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal"
+)
+
+// Options tune the license detection in DetectWithOptions and DetectDetailedWithOptions.
+type Options struct {
+	// FailOnReadError aborts the detection if any listed file or directory cannot be read.
+	// By default, such files are skipped and the detection silently uses the rest.
+	FailOnReadError bool
+	// WeightByProminence scales the confidences of the license files by their depth in
+	// the tree, so that the root LICENSE outweighs e.g. the license of a test fixture
+	// referenced from CMake. See prominence.
+	WeightByProminence bool
+	// RunAllPlans runs all the detection plans and merges their matches instead of stopping
+	// at the first plan which finds any, e.g. to notice the README which says that some parts
+	// are licensed differently from LICENSE. Each match keeps the plan it originates from.
+	RunAllPlans bool
+	// WeightReadmeProximity discounts the license names which the README mentions far from
+	// the words "license", "licensed" and "licensing", e.g. "MIT" in the sentence about
+	// the university, so that only the mentions in the licensing context drive the result.
+	WeightReadmeProximity bool
+	// MultilingualReadme looks for the license mentions in all the language sections of
+	// the README, e.g. "MITライセンス" in the Japanese section, rather than only in the English
+	// ones. The Chinese, Japanese and Korean words are separated from the license names.
+	MultilingualReadme bool
+	// MaxConcurrency is the maximum number of the candidate texts which are investigated at
+	// the same time, across all the plans. 0 and 1 mean the sequential investigation.
+	// The files are always read one at a time.
+	MaxConcurrency int
+	// UnorderedMatching compares the license files and the header comments with the reference
+	// licenses regardless of the order of the paragraphs and the clauses, e.g. if the warranty
+	// disclaimer is moved to the top. The texts still must contain the same clauses.
+	UnorderedMatching bool
+	// Retry repeats the reads which fail with transient errors, e.g. of the remote Filer.
+	// The non-retryable errors fail immediately. By default, nothing is retried.
+	Retry RetryPolicy
+	// StableKeys sets Match.Key of the detailed results, e.g. to store the matches by the keys
+	// which survive the changes of the SPDX identifiers. See LicenseKey.
+	StableKeys bool
+	// Tokenizer splits the license texts into the words for the fuzzy matching, e.g.
+	// CJKTokenizer for the Chinese license texts. It is WhitespaceTokenizer if nil.
+	// The reference licenses are indexed anew the first time each Tokenizer is used.
+	// It is ignored if Database is set, which has its own tokenizer.
+	Tokenizer Tokenizer
+	// Database is the explicit instance of the reference licenses to match against, see
+	// NewDatabase. The package-global database of Tokenizer is used if nil, which is loaded
+	// on the first use and kept forever. See also DisableDefaultDatabase.
+	Database *Database
+	// Allowlist restricts the matches to these SPDX license identifiers, e.g. the licenses
+	// approved by the policy. The plan which finds only the other licenses is considered
+	// to find nothing, so the detection continues with the next plan and returns
+	// ErrNoLicenseFound if none of the allowed licenses is found. The case and
+	// the "deprecated_" prefix are ignored. By default, all the licenses are allowed.
+	Allowlist []string
+	// FoldDuplicates keeps only the most confident match of each license in the detailed
+	// results, e.g. when RunAllPlans finds MIT in the README and in many header comments.
+	// Match.Evidence counts the folded matches.
+	FoldDuplicates bool
+	// CollapseVersions replaces the matches of several versions of the same license in a file
+	// which are equally likely, e.g. GPL-2.0-only and GPL-3.0-only of the README which says
+	// just "GPL", with the single match of the family, e.g. "GPL", which carries
+	// Match.VersionUncertain and Match.Versions. The family names are not SPDX identifiers,
+	// so the versions are reported as is by default.
+	CollapseVersions bool
+	// SampleHeaders looks for the license headers in the first source file of each directory
+	// before scanning all the source files, which happens only if the sampled headers contain
+	// any license. It speeds up the detection in the big trees without the license headers
+	// at the risk of missing the headers which are absent in the sampled files. It only
+	// applies with ScanHeaders.
+	SampleHeaders bool
+	// AnnotateOtherPlans runs all the detection plans like RunAllPlans, but only the first
+	// plan which finds any license is authoritative: its matches remain in Result.Matches,
+	// and the matches of the other plans are moved to Result.Annotations. Thus Detect
+	// returns the same licenses as without the option.
+	AnnotateOtherPlans bool
+	// ExplainNormalization sets Match.Normalization of the fuzzy matches, e.g. to tell whether
+	// the copyright lines stripping affects the confidence while tuning the corpus. Each text
+	// is investigated once more per normalization step, so it is slow.
+	ExplainNormalization bool
+	// CanonicalText sets Match.CanonicalText of the fuzzy matches to the normalized text of
+	// the file which is compared with the reference licenses, e.g. to hash it and find
+	// the duplicate license files across the projects.
+	CanonicalText bool
+	// DetectTruncation sets Match.Truncated of the license files and the header comments which
+	// are the cut-off copies of the license texts, e.g. the LICENSE file copied halfway.
+	// Such copies are reported even if they are too short to match otherwise.
+	DetectTruncation bool
+	// ClassifySections sets Match.Sections of the license files and the header comments, e.g.
+	// to tell the full license from the warranty disclaimer copied alone into the attribution
+	// block. Such disclaimers are reported even if they are too short to match otherwise.
+	ClassifySections bool
+	// DetectAdditionalTerms sets Match.HasAdditionalTerms and Match.AdditionalTerms of
+	// the license files and the header comments which append the paragraphs to the end of
+	// the license text, e.g. the custom restriction after MIT. Each text is compared with
+	// the matched licenses once more per trailing paragraph, so it is slow.
+	DetectAdditionalTerms bool
+	// ScanHeaders looks for the license headers in the comments at the top of the source files
+	// after the license files, the manifests and the README find nothing. Only the leading
+	// bytes of each file are read. It is opt-in because the headers often belong to
+	// the vendored or the generated code rather than to the project itself.
+	ScanHeaders bool
+	// FirstHeaderComment takes only the first comment block of each source file as its header
+	// instead of the block which mentions the license terms most often, or all the blocks
+	// joined if none does. It suits the trees whose files always start with the license
+	// banner followed by the doc comments which discuss the licensing, e.g. of this package.
+	// It only applies with ScanHeaders.
+	FirstHeaderComment bool
+	// ScanBinaries matches the license texts embedded in the compiled binaries and libraries,
+	// e.g. libfoo.so or foo.dll, after all the other plans find nothing. The long runs of
+	// the printable characters are extracted like the strings utility does. It is best-effort:
+	// the number and the size of the scanned files are limited to bound the memory use.
+	ScanBinaries bool
+	// ScanCredits investigates the CREDITS files, e.g. CREDITS.md, like the license files and,
+	// if they match nothing, like the README files. It is opt-in because such files often
+	// carry the licenses of the dependencies rather than of the project itself.
+	ScanCredits bool
+	// LabelConfidence sets Match.ConfidenceLabel of the detailed results to the category of
+	// the confidence by ConfidenceLabels, e.g. "Exact", "High", "Likely" or "Uncertain".
+	LabelConfidence bool
+	// ConfidenceLabels are the categories of LabelConfidence. DefaultConfidenceLabels are
+	// used if nil.
+	ConfidenceLabels []ConfidenceLabel
+	// AttachMetadata sets Match.Metadata of the detailed results to the SPDX metadata of
+	// the license, e.g. whether it is OSI-approved, for the compliance records.
+	AttachMetadata bool
+	// RankMatches sets Match.Rank of the detailed results, see RankMatches, e.g. to paginate
+	// the matches or to render them in the stable order. The ranks are assigned after
+	// the thresholds and the other post-processing are applied.
+	RankMatches bool
+	// CorpusVersion pins the detection to the embedded corpus of the reference licenses of
+	// the version, see CorpusVersion, e.g. to notice the library upgrades which change
+	// the corpus in the CI gates. The detection fails with ErrCorpusVersionUnavailable if
+	// the version is not embedded instead of silently matching against the different corpus.
+	// It does not pin the matching algorithms, which may change regardless. Empty means
+	// the default corpus.
+	CorpusVersion string
+	// LicenseDirectoryPatterns match the names of the additional root directories which are
+	// entered to look for the license files, like "licenses" and "legal" are, e.g.
+	// regexp.MustCompile("(?i)^third_party_licenses$"). The names are matched as is.
+	LicenseDirectoryPatterns []*regexp.Regexp
+	// MaxDepth enables the recursion into the license directories, e.g. LICENSES/vendor/foo,
+	// and bounds it so that the pathological or the symlink-laden trees do not blow up.
+	// 1 means only the files directly inside are taken. If it is set, the detailed results
+	// carry the warning when the deeper directories are skipped. DefaultMaxDepth is used
+	// if it is 0, so by default the nested directories are not entered.
+	MaxDepth int
+	// KnownHashes map the hex SHA-1, SHA-256 or Git blob hashes of the raw contents of the known
+	// license files to their SPDX license identifiers, e.g. the precomputed hashes of the texts
+	// approved by the legal team. The hashes are case insensitive. The license files whose
+	// hashes are known are identified with the confidence 1 without the fuzzy matching.
+	KnownHashes map[string]string
+	// AuthoritativeFiles are the paths of the files which contain just the SPDX license
+	// expression of the project, e.g. "SPDX.txt" or "LICENSE-ID" mandated by the organization
+	// as the single source of truth. The first of them which exists overrides all the plans
+	// like .license.yml does and takes precedence over it. The names are case-insensitive.
+	AuthoritativeFiles []string
+}
+
+// DefaultMaxDepth is the default Options.MaxDepth: only the files directly inside
+// the license directories are taken.
+const DefaultMaxDepth = 1
+
+// maxDepth returns MaxDepth or DefaultMaxDepth if it is not set.
+func (options Options) maxDepth() int {
+	if options.MaxDepth <= 0 {
+		return DefaultMaxDepth
+	}
+	return options.MaxDepth
+}
+
+// isLicenseDirectory indicates whether the root directory is likely to contain licenses,
+// either by default or by LicenseDirectoryPatterns.
+func (options Options) isLicenseDirectory(name string) bool {
+	if internal.IsLicenseDirectory(name) {
+		return true
+	}
+	for _, pattern := range options.LicenseDirectoryPatterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed returns the lower case allowed license identifiers, or nil if all are allowed.
+func (options Options) allowed() map[string]bool {
+	if len(options.Allowlist) == 0 {
+		return nil
+	}
+	allowed := map[string]bool{}
+	for _, name := range options.Allowlist {
+		allowed[strings.ToLower(strings.TrimPrefix(name, "deprecated_"))] = true
+	}
+	return allowed
+}
+
+// Tokenizer splits the line of the normalized license text into the tokens which the fuzzy
+// matching compares, see Options.Tokenizer. It must be comparable, e.g. a struct without
+// slices and maps or a pointer.
+type Tokenizer = internal.Tokenizer
+
+var (
+	// WhitespaceTokenizer splits the words by the spaces. It is the default Tokenizer.
+	WhitespaceTokenizer = internal.WhitespaceTokenizer
+	// CJKTokenizer is the same as WhitespaceTokenizer but also splits the Chinese, Japanese
+	// and Korean characters, which are not separated by the spaces. It recognizes the CJK
+	// portions of the bilingual licenses, e.g. MulanPSL-2.0.
+	CJKTokenizer = internal.CJKTokenizer
+)
+
+const (
+	// topLevelProminence is the weight of the license files in the top level directories,
+	// e.g. LICENSES/MIT.txt. The root files have the weight 1.
+	topLevelProminence = 0.95
+	// nestedProminence is the weight of the deeper license files.
+	nestedProminence = 0.85
+)
+
+// prominence returns the weight of the license file by its depth in the tree.
+func prominence(file string) float32 {
+	switch strings.Count(paths.Clean(file), "/") {
+	case 0:
+		return 1
+	case 1:
+		return topLevelProminence
+	default:
+		return nestedProminence
+	}
+}
+
+// strictFiler remembers the first failure to read a file or a directory which was listed
+// in the parent directory. The failures to read other paths are expected: e.g. the short
+// license files and the README mentions are probed whether they point to other files.
+type strictFiler struct {
+	filer.Filer
+	listed map[string]bool
+	err    error
+}
+
+func newStrictFiler(fs filer.Filer) *strictFiler {
+	return &strictFiler{Filer: fs, listed: map[string]bool{}}
+}
+
+func (fs *strictFiler) ReadFile(path string) ([]byte, error) {
+	content, err := fs.Filer.ReadFile(path)
+	if err != nil {
+		fs.fail(path, err)
+	}
+	return content, err
+}
+
+func (fs *strictFiler) ReadFilePrefix(path string, size int) ([]byte, error) {
+	content, err := filer.ReadFilePrefix(fs.Filer, path, size)
+	if err != nil {
+		fs.fail(path, err)
+	}
+	return content, err
+}
+
+func (fs *strictFiler) ReadDir(path string) ([]filer.File, error) {
+	files, err := fs.Filer.ReadDir(path)
+	if err != nil {
+		fs.fail(path, err)
+		return files, err
+	}
+	for _, file := range files {
+		fs.listed[paths.Join(path, file.Name)] = true
+	}
+	return files, err
+}
+
+func (fs *strictFiler) fail(path string, err error) {
+	if fs.err == nil && fs.listed[path] {
+		fs.err = errors.Wrapf(err, "aborted the detection because %s cannot be read", path)
+	}
+}
+
+// Err returns the first read error. It is safe to call on nil.
+func (fs *strictFiler) Err() error {
+	if fs == nil {
+		return nil
+	}
+	return fs.err
+}
@@ -0,0 +1,47 @@
+package licensedb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyFiler fails to read the specified file.
+type flakyFiler struct {
+	memoryFiler
+	broken string
+}
+
+func (fs flakyFiler) ReadFile(path string) ([]byte, error) {
+	if path == fs.broken {
+		return nil, errors.New("input/output error")
+	}
+	return fs.memoryFiler.ReadFile(path)
+}
+
+func TestDetectFailOnReadError(t *testing.T) {
+	fs := flakyFiler{
+		memoryFiler: memoryFiler{
+			"LICENSE":     referenceText(t, "MIT"),
+			"COPYING":     referenceText(t, "GPL-3.0-only"),
+			"README.md":   "# Project",
+			"src/main.go": "package main",
+		},
+		broken: "COPYING",
+	}
+	licenses, err := Detect(fs)
+	assert.Nil(t, err)
+	assert.Contains(t, licenses, "MIT")
+	licenses, err = DetectWithOptions(fs, Options{FailOnReadError: true})
+	assert.Nil(t, licenses)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "COPYING")
+	assert.Contains(t, err.Error(), "input/output error")
+	// the probes of the missing files are not read errors
+	fs.broken = ""
+	fs.memoryFiler["COPYING"] = "LICENSE.md"
+	licenses, err = DetectWithOptions(fs, Options{FailOnReadError: true})
+	assert.Nil(t, err)
+	assert.Contains(t, licenses, "MIT")
+}
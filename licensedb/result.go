@@ -0,0 +1,748 @@
+package licensedb
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal"
+)
+
+// Match is a reference license matched in a particular file.
+type Match struct {
+	// License is the SPDX identifier of the matched license.
+	License string `json:"license"`
+	// Confidence is from 0 to 1, 1 means 100% confident.
+	Confidence float32 `json:"confidence"`
+	// File is the path to the file which contains the evidence.
+	File string `json:"file"`
+	// Plan is the detection plan which found the match, e.g. PlanLicenseFiles.
+	Plan string `json:"plan"`
+	// Exception is the SPDX identifier of the exception to the license, e.g.
+	// "Qt-GPL-exception-1.0" in "GPL-3.0-only WITH Qt-GPL-exception-1.0".
+	Exception string `json:"exception,omitempty"`
+	// Occurrences is the number of source files which share the same header comment
+	// as File, including File itself. It is only set by the PlanHeaders plan, which counts
+	// the repeated banner once and reports the repetitions as the confirmation.
+	Occurrences int `json:"occurrences,omitempty"`
+	// Language is the programming language of File, e.g. "Go". It is only set by the
+	// PlanHeaders plan.
+	Language string `json:"language,omitempty"`
+	// Rider is the restriction appended to the license in File, e.g. "Commons-Clause".
+	// Such a license is source-available rather than open source.
+	Rider string `json:"rider,omitempty"`
+	// FieldOfUse are the restrictions of the field of use in File, e.g. "non-military" or
+	// "good-not-evil" of JSON. The license is restricted-field-of-use, that is, not open
+	// source, if there are any.
+	FieldOfUse []string `json:"field_of_use,omitempty"`
+	// VersionUncertain indicates that the text matches several versions of the same license
+	// equally well, e.g. "licensed under the GPL". License is the family name then, e.g. "GPL",
+	// and Versions are the candidates, e.g. "GPL-2.0-only" and "GPL-3.0-only". It is only set
+	// with Options.CollapseVersions.
+	VersionUncertain bool `json:"version_uncertain,omitempty"`
+	// Versions are the sorted candidate licenses of the family if VersionUncertain is true.
+	Versions []string `json:"versions,omitempty"`
+	// MissingLicenseFile indicates that the license is declared in the manifest but none
+	// of the license files supports it, e.g. Apache-2.0 in "MIT OR Apache-2.0" if the project
+	// ships only the text of MIT. It is only set by the PlanManifests plan.
+	MissingLicenseFile bool `json:"missing_license_file,omitempty"`
+	// Evidence is the number of the matches of License which are folded into this one,
+	// including itself. It is only set with Options.FoldDuplicates.
+	Evidence int `json:"evidence,omitempty"`
+	// Key is the stable key of License, see LicenseKey. It is only set with Options.StableKeys.
+	Key string `json:"key,omitempty"`
+	// Normalization are the confidences of License with each normalization step skipped.
+	// It is only set with Options.ExplainNormalization for the fuzzy matches of the texts.
+	Normalization []NormalizationStep `json:"normalization,omitempty"`
+	// Truncated indicates that File is a cut-off copy of the text of License, e.g. the LICENSE
+	// file copied halfway. It is only set with Options.DetectTruncation for the fuzzy matches.
+	Truncated bool `json:"truncated,omitempty"`
+	// Completeness is the share of the text of License which File contains, from 0 to 1.
+	// It is only set if Truncated is true.
+	Completeness float32 `json:"completeness,omitempty"`
+	// Sections tells which sections of the text of License are in File: SectionsFull,
+	// SectionsGrantOnly or SectionsDisclaimerOnly. It is only set with Options.ClassifySections
+	// for the fuzzy matches of the licenses which have a separate warranty disclaimer.
+	Sections string `json:"sections,omitempty"`
+	// ConfidenceLabel is the category of Confidence, e.g. "High", see LabelConfidence.
+	// It is only set with Options.LabelConfidence.
+	ConfidenceLabel string `json:"confidence_label,omitempty"`
+	// Metadata is the SPDX metadata of License, see SPDXMetadata. It is only set with
+	// Options.AttachMetadata for the licenses which are in the database.
+	Metadata *Metadata `json:"metadata,omitempty"`
+	// Rank is the 1-based position of Confidence among the distinct confidences of the matches,
+	// 1 is the best, see RankMatches. It is only set with Options.RankMatches.
+	Rank int `json:"rank,omitempty"`
+	// CanonicalText is the normalized text of File which is compared with the reference
+	// licenses, see Options.CanonicalText: lower case, without the copyright lines and with
+	// the whitespace folded. It is only set for the fuzzy matches of the texts.
+	CanonicalText string `json:"canonical_text,omitempty"`
+	// HasAdditionalTerms indicates that File appends the text to the end of License, e.g.
+	// the custom "additional restrictions", which may change the terms materially although
+	// License still matches. It is only set with Options.DetectAdditionalTerms for the fuzzy
+	// matches.
+	HasAdditionalTerms bool `json:"has_additional_terms,omitempty"`
+	// AdditionalTerms are the trailing paragraphs of File after License as is. They are only
+	// set if HasAdditionalTerms is true.
+	AdditionalTerms string `json:"additional_terms,omitempty"`
+}
+
+// The values of Match.Sections.
+const (
+	// SectionsFull means that the file contains the grant, the conditions and the disclaimer.
+	SectionsFull = internal.SectionsFull
+	// SectionsGrantOnly means that the file lacks the warranty disclaimer.
+	SectionsGrantOnly = internal.SectionsGrantOnly
+	// SectionsDisclaimerOnly means that the file contains only the warranty disclaimer, e.g.
+	// the third party attribution block.
+	SectionsDisclaimerOnly = internal.SectionsDisclaimerOnly
+)
+
+// NormalizationStep is the influence of a single step of the normalization of the text
+// on the confidence of the match, see Options.ExplainNormalization.
+type NormalizationStep struct {
+	// Step is the name of the step, e.g. "copyright lines" or "whitespace".
+	Step string `json:"step"`
+	// Before is the confidence if the step is not applied, 0 if the license is not matched then.
+	Before float32 `json:"before"`
+	// After is the confidence with all the steps applied, that is, Match.Confidence.
+	After float32 `json:"after"`
+}
+
+// LicenseKey returns the stable key of the license in the UUID format. It is derived from
+// the text of the reference license rather than from its SPDX identifier, so that it does not
+// change when SPDX renames the license, e.g. "GPL-2.0" and "GPL-2.0-only" share the same key.
+// The names which do not have the reference text, e.g. the version families, are keyed
+// by the name.
+func LicenseKey(license string) (string, error) {
+	db, err := defaultDatabase()
+	if err != nil {
+		return "", err
+	}
+	return db.LicenseKey(license), nil
+}
+
+// ConfidenceLabel names the confidences starting from Threshold, e.g. "High" from 0.9,
+// for the consumers who prefer the categories over the numbers.
+type ConfidenceLabel struct {
+	// Label is the name of the category.
+	Label string
+	// Threshold is the minimum confidence of the category.
+	Threshold float32
+}
+
+// DefaultConfidenceLabels are the categories of Options.LabelConfidence by default.
+var DefaultConfidenceLabels = []ConfidenceLabel{
+	{Label: "Exact", Threshold: 0.99},
+	{Label: "High", Threshold: 0.9},
+	{Label: "Likely", Threshold: 0.75},
+	{Label: "Uncertain", Threshold: 0},
+}
+
+// LabelConfidence returns the label with the highest threshold which the confidence reaches,
+// or an empty string if it is below all the thresholds. The labels may be in any order.
+func LabelConfidence(confidence float32, labels []ConfidenceLabel) string {
+	label := ""
+	var best float32
+	for _, candidate := range labels {
+		if confidence >= candidate.Threshold && (label == "" || candidate.Threshold > best) {
+			label = candidate.Label
+			best = candidate.Threshold
+		}
+	}
+	return label
+}
+
+// versionTieTolerance is the maximum difference of the confidences of the license versions
+// which are considered equally likely.
+const versionTieTolerance = 0.01
+
+// licenseVersionRe splits the license name into the family and the version, e.g.
+// "GPL-2.0-or-later" into "GPL" and "2.0".
+var licenseVersionRe = regexp.MustCompile("^(?:deprecated_)?(.+?)-v?(\\d+(?:\\.\\d+)*)(?:\\+|-only|-or-later)?$")
+
+// spdxDisjunctionRe finds the OR operator in the SPDX license expression.
+var spdxDisjunctionRe = regexp.MustCompile("(?i)(^|[\\s(])or($|[\\s)])")
+
+// genericLicenseFileRe matches the names of the license files which do not tell the license,
+// e.g. LICENSE.txt, unlike LICENSE-MIT or COPYING.LESSER.
+var genericLicenseFileRe = regexp.MustCompile("(?i)^(li[cs]en[cs]e|copying|copyright)(\\.(md|rst|html|txt))?$")
+
+// Result is the detailed outcome of the license detection returned by DetectDetailed.
+type Result struct {
+	// Matches are sorted by confidence in descending order, then by license name, file path,
+	// plan and exception, so that the same tree always yields the same order.
+	Matches []Match `json:"matches"`
+	// PatentGrant indicates that a PATENTS file with an additional patent grant accompanies
+	// a BSD license, e.g. the "BSD + Patents" combination used by Facebook.
+	PatentGrant bool `json:"patent_grant,omitempty"`
+	// PatentFiles are the PATENTS, ADDITIONAL_GRANT and PATENT_GRANT files with the additional
+	// patent grants, which modify the terms of any license, sorted by the file path.
+	PatentFiles []PatentFile `json:"patent_files,omitempty"`
+	// ThirdPartyLicenses are the licenses of the bundled components listed in the aggregated
+	// third party notices files, e.g. THIRD_PARTY_NOTICES.txt, in the order of the files and
+	// the sections, followed by those in the acknowledgements property lists of the iOS and
+	// macOS apps, e.g. Settings.bundle/Acknowledgements.plist. They are not the licenses of
+	// the project and do not count in Licenses.
+	ThirdPartyLicenses []ThirdPartyLicense `json:"third_party_licenses,omitempty"`
+	// DualLicensed indicates that the project is offered both under the open source license
+	// and under the commercial license, e.g. in LICENSE.opensource and LICENSE.commercial.
+	// The latter is matched as the "commercial" pseudo license.
+	DualLicensed bool `json:"dual_licensed,omitempty"`
+	// Warnings describe the problems which may have affected the detection, e.g. the license
+	// file which is a git-lfs pointer to an unavailable object. They are sorted alphabetically.
+	Warnings []string `json:"warnings,omitempty"`
+	// Annotations are the matches of the plans which ran after the authoritative one,
+	// e.g. what the README says if the license files are found. They are informational
+	// and do not count in Licenses. They are sorted like Matches. They are only set with
+	// Options.AnnotateOtherPlans.
+	Annotations []Match `json:"annotations,omitempty"`
+}
+
+// PatentFile is the file with the additional patent grant, see Result.PatentFiles.
+type PatentFile struct {
+	// File is the path to the file.
+	File string `json:"file"`
+	// Summary is the title of the grant, e.g. "Additional Grant of Patent Rights Version 2".
+	Summary string `json:"summary,omitempty"`
+}
+
+// ThirdPartyLicense is the license of a bundled component, see Result.ThirdPartyLicenses.
+type ThirdPartyLicense struct {
+	// File is the path to the third party notices file or the acknowledgements.
+	File string `json:"file"`
+	// Component is the header of the section of the component, e.g. its name, if any.
+	Component string `json:"component,omitempty"`
+	// License is the SPDX identifier of the license in the section.
+	License string `json:"license"`
+	// Confidence is from 0 to 1, 1 means 100% confident.
+	Confidence float32 `json:"confidence"`
+}
+
+// Licenses returns the maximum confidence per license among all the matches.
+// This is what Detect returns.
+func (result *Result) Licenses() map[string]float32 {
+	licenses := map[string]float32{}
+	for _, match := range result.Matches {
+		if licenses[match.License] < match.Confidence {
+			licenses[match.License] = match.Confidence
+		}
+	}
+	return licenses
+}
+
+// LicensesByKey is the same as Licenses but the licenses are identified by LicenseKey.
+// Match.Key is taken if it is set, e.g. with Options.StableKeys, and the rest of the keys
+// are looked up in the default database.
+func (result *Result) LicensesByKey() (map[string]float32, error) {
+	licenses := map[string]float32{}
+	var db *Database
+	for _, match := range result.Matches {
+		key := match.Key
+		if key == "" {
+			if db == nil {
+				var err error
+				if db, err = defaultDatabase(); err != nil {
+					return nil, err
+				}
+			}
+			key = db.LicenseKey(match.License)
+		}
+		if licenses[key] < match.Confidence {
+			licenses[key] = match.Confidence
+		}
+	}
+	return licenses, nil
+}
+
+// assignKeys sets Key of each match.
+func (result *Result) assignKeys(db *internal.Database) {
+	for i := range result.Matches {
+		result.Matches[i].Key = db.LicenseKey(result.Matches[i].License)
+	}
+}
+
+// labelConfidences sets ConfidenceLabel of the matches and the annotations.
+func (result *Result) labelConfidences(labels []ConfidenceLabel) {
+	if labels == nil {
+		labels = DefaultConfidenceLabels
+	}
+	for _, matches := range [][]Match{result.Matches, result.Annotations} {
+		for i := range matches {
+			matches[i].ConfidenceLabel = LabelConfidence(matches[i].Confidence, labels)
+		}
+	}
+}
+
+// addMatches appends the matches of the investigated file.
+func (result *Result) addMatches(file, plan string, licenses map[string]float32) {
+	for name, confidence := range licenses {
+		result.Matches = append(result.Matches, Match{
+			License: name, Confidence: confidence, File: file, Plan: plan})
+	}
+}
+
+// addDeclared appends the matches of the declared SPDX license expression with the exceptions
+// after WITH.
+func (result *Result) addDeclared(file, plan, expression string, db *internal.Database) {
+	exceptions := internal.InvestigateDeclaredExceptions(expression, db)
+	for name, confidence := range internal.InvestigateDeclaredLicense(expression, db) {
+		result.Matches = append(result.Matches, Match{
+			License: name, Confidence: confidence, File: file, Plan: plan, Exception: exceptions[name]})
+	}
+}
+
+// addText investigates the license file or the header comment shared by `occurrences` source
+// files and appends the matches. The well-known notices take precedence over the fuzzy matching,
+// and the free-form non-commercial notices and public domain dedications are the last resort.
+// The fuzzy matching follows Options.UnorderedMatching against Options.Database, which the caller
+// must set, see detect. The truncated copies which are too short to match fuzzily are reported
+// with Options.DetectTruncation, and their confidence is scaled by the completeness. The lone warranty disclaimers are matched
+// with Options.ClassifySections. The appended terms are extracted with
+// Options.DetectAdditionalTerms.
+func (result *Result) addText(file, plan string, occurrences int, text []byte, options Options) {
+	addNotices := func(notices []internal.Notice) {
+		for _, notice := range notices {
+			result.Matches = append(result.Matches, Match{
+				License: notice.License, Confidence: notice.Confidence, File: file, Plan: plan,
+				Exception: notice.Exception, Occurrences: occurrences})
+		}
+	}
+	if notices := internal.RecognizeNotices(text); len(notices) > 0 {
+		addNotices(notices)
+		return
+	}
+	db := options.Database.db
+	licenses := internal.InvestigateLicenseTextTokenized(text, db, options.UnorderedMatching)
+	if options.ClassifySections {
+		// the lone disclaimers are too short to match the full licenses
+		for name, confidence := range internal.InvestigateLicenseDisclaimer(text, db) {
+			if licenses[name] < confidence {
+				licenses[name] = confidence
+			}
+		}
+		if len(licenses) == 0 {
+			// neither do the grants without the disclaimer
+			licenses = internal.InvestigateLicenseGrant(text, db)
+		}
+	}
+	if len(licenses) == 0 && options.DetectAdditionalTerms {
+		// the appended terms may hide the license from the fuzzy matching
+		licenses = internal.InvestigateLicenseTextBeforeTerms(text, db)
+	}
+	var truncations map[string]internal.Truncation
+	if options.DetectTruncation {
+		truncations = internal.InvestigateTruncatedLicenseText(text, db)
+	}
+	var canonical string
+	if options.CanonicalText {
+		canonical = internal.CanonicalLicenseText(text)
+	}
+	if len(licenses) == 0 && len(truncations) > 0 {
+		for name, truncation := range truncations {
+			result.Matches = append(result.Matches, Match{
+				License: name, Confidence: truncation.Similarity * truncation.Completeness,
+				File: file, Plan: plan, Occurrences: occurrences,
+				Truncated: true, Completeness: truncation.Completeness, CanonicalText: canonical})
+		}
+		return
+	}
+	if len(licenses) == 0 {
+		if id := internal.REUSELicenseID(file, db); id != "" && plan == PlanLicenseFiles {
+			// the file name identifies the license even if the text is incomplete
+			result.Matches = append(result.Matches, Match{
+				License: id, Confidence: 1, File: file, Plan: plan})
+			return
+		}
+		if internal.IsCommercialLicenseFile(file) && plan == PlanLicenseFiles {
+			// the file name tells the commercial option of the dual licensing
+			result.Matches = append(result.Matches, Match{
+				License: internal.Commercial, Confidence: 1, File: file, Plan: plan})
+			return
+		}
+		addNotices(internal.RecognizeNonCommercialNotice(text))
+		addNotices(internal.RecognizePublicDomainDedication(text))
+		return
+	}
+	var rider string
+	if riders := internal.RecognizeRiders(text); len(riders) > 0 {
+		rider = riders[0]
+	}
+	fieldOfUse := internal.RecognizeFieldOfUseRestrictions(text)
+	var sections map[string]string
+	if options.ClassifySections {
+		names := make([]string, 0, len(licenses))
+		for name := range licenses {
+			names = append(names, name)
+		}
+		sections = internal.InvestigateLicenseSections(text, names, db)
+	}
+	var terms map[string]string
+	if options.DetectAdditionalTerms {
+		names := make([]string, 0, len(licenses))
+		for name := range licenses {
+			names = append(names, name)
+		}
+		terms = internal.InvestigateAdditionalTerms(text, names, db)
+	}
+	var explanation map[string]map[string]float32
+	if options.ExplainNormalization {
+		explanation = internal.ExplainNormalization(text, db, options.UnorderedMatching)
+	}
+	for name, confidence := range licenses {
+		match := Match{
+			License: name, Confidence: confidence, File: file, Plan: plan,
+			Occurrences: occurrences, Rider: rider, FieldOfUse: fieldOfUse,
+			Sections: sections[name], CanonicalText: canonical}
+		if explanation != nil {
+			for _, step := range internal.NormalizationSteps {
+				match.Normalization = append(match.Normalization, NormalizationStep{
+					Step: step, Before: explanation[step][name], After: confidence})
+			}
+		}
+		if additional, exists := terms[name]; exists {
+			match.HasAdditionalTerms = true
+			match.AdditionalTerms = additional
+		}
+		if truncation, exists := truncations[name]; exists {
+			match.Truncated = true
+			match.Completeness = truncation.Completeness
+		}
+		result.Matches = append(result.Matches, match)
+	}
+}
+
+// addHeader investigates the header comment shared by the banner files and appends
+// the matches attributed to the first file and its programming language.
+func (result *Result) addHeader(banner internal.HeaderBanner, options Options) {
+	before := len(result.Matches)
+	result.addText(banner.Files[0], PlanHeaders, len(banner.Files), banner.Text, options)
+	language := internal.SourceLanguage(banner.Files[0])
+	for i := before; i < len(result.Matches); i++ {
+		result.Matches[i].Language = language
+	}
+}
+
+// collapseVersions replaces the matches of several versions of the same license family
+// in the same file, which are equally likely, with the single match of the family, see
+// Match.VersionUncertain. A single version of a license family is more confident than
+// the others in the texts of the licenses themselves, so they are never collapsed.
+func (result *Result) collapseVersions() {
+	type family struct {
+		file, plan, name string
+	}
+	best := map[family]float32{}
+	for _, match := range result.Matches {
+		parts := licenseVersionRe.FindStringSubmatch(match.License)
+		if parts == nil || match.Exception != "" {
+			continue
+		}
+		key := family{match.File, match.Plan, parts[1]}
+		if best[key] < match.Confidence {
+			best[key] = match.Confidence
+		}
+	}
+	versions := map[family]map[string]bool{}
+	candidates := map[family][]string{}
+	for _, match := range result.Matches {
+		parts := licenseVersionRe.FindStringSubmatch(match.License)
+		if parts == nil || match.Exception != "" {
+			continue
+		}
+		key := family{match.File, match.Plan, parts[1]}
+		// the verbatim text of a version outweighs the close texts of the others,
+		// e.g. LPPL-1.1 and LPPL-1.2
+		if best[key]-match.Confidence > versionTieTolerance ||
+			(best[key] >= 1 && match.Confidence < 1) {
+			continue
+		}
+		if versions[key] == nil {
+			versions[key] = map[string]bool{}
+		}
+		versions[key][parts[2]] = true
+		candidates[key] = append(candidates[key], match.License)
+	}
+	matches := result.Matches[:0]
+	collapsed := map[family]bool{}
+	for _, match := range result.Matches {
+		parts := licenseVersionRe.FindStringSubmatch(match.License)
+		if parts == nil || match.Exception != "" {
+			matches = append(matches, match)
+			continue
+		}
+		key := family{match.File, match.Plan, parts[1]}
+		if len(versions[key]) < 2 {
+			matches = append(matches, match)
+			continue
+		}
+		if collapsed[key] {
+			continue
+		}
+		collapsed[key] = true
+		sort.Strings(candidates[key])
+		match.License = key.name
+		match.Confidence = best[key]
+		match.VersionUncertain = true
+		match.Versions = candidates[key]
+		matches = append(matches, match)
+	}
+	result.Matches = matches
+}
+
+// weightByProminence scales the confidences of the matches found by the plan, see prominence.
+func (result *Result) weightByProminence(plan string) {
+	for i, match := range result.Matches {
+		if match.Plan == plan {
+			result.Matches[i].Confidence *= prominence(match.File)
+		}
+	}
+}
+
+// warnConflictingLicenseFiles adds the warning if the generic license files in the root
+// directory, e.g. LICENSE and LICENSE.txt, match the different licenses, which is a red flag.
+// The files named after their licenses, e.g. LICENSE-MIT and LICENSE-APACHE, offer the choice
+// and do not conflict.
+func (result *Result) warnConflictingLicenseFiles() {
+	licenses := map[string]map[string]float32{}
+	var files []string
+	for _, match := range result.Matches {
+		if match.Plan != PlanLicenseFiles || strings.Contains(match.File, "/") ||
+			!genericLicenseFileRe.MatchString(match.File) {
+			continue
+		}
+		if licenses[match.File] == nil {
+			licenses[match.File] = map[string]float32{}
+			files = append(files, match.File)
+		}
+		licenses[match.File][match.License] = match.Confidence
+	}
+	sort.Strings(files)
+	var disagreeing []string
+	for i, file := range files {
+		for j, other := range files {
+			if i != j && disjointLicenses(licenses[file], licenses[other]) {
+				license, _ := mostConfidentLicense(licenses[file])
+				disagreeing = append(disagreeing, file+" ("+license+")")
+				break
+			}
+		}
+	}
+	if len(disagreeing) > 0 {
+		result.Warnings = append(result.Warnings,
+			"the license files disagree: "+strings.Join(disagreeing, ", "))
+	}
+}
+
+// disjointLicenses indicates whether the two sets of the licenses have nothing in common.
+func disjointLicenses(licenses, others map[string]float32) bool {
+	for name := range licenses {
+		if _, exists := others[name]; exists {
+			return false
+		}
+	}
+	return true
+}
+
+// mostConfidentLicense returns the license with the highest confidence, the first by name
+// if there are several.
+func mostConfidentLicense(licenses map[string]float32) (string, float32) {
+	names := make([]string, 0, len(licenses))
+	for name := range licenses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var best string
+	var confidence float32
+	for _, name := range names {
+		if licenses[name] > confidence {
+			best = name
+			confidence = licenses[name]
+		}
+	}
+	return best, confidence
+}
+
+// addApacheNotices appends the matches of the Apache NOTICE files and warns that the license
+// file is missing, see internal.RecognizeApacheNoticeFile.
+func (result *Result) addApacheNotices(notices map[string][]byte) {
+	for _, file := range sortedKeys(notices) {
+		for _, notice := range internal.RecognizeApacheNoticeFile(notices[file]) {
+			result.Matches = append(result.Matches, Match{
+				License: notice.License, Confidence: notice.Confidence, File: file,
+				Plan: PlanLicenseFiles})
+			result.Warnings = append(result.Warnings,
+				file+" refers to the Apache License but the LICENSE file is missing")
+		}
+	}
+}
+
+// addDeclaredChoices appends the matches of the declared expressions which offer the choice
+// of licenses, e.g. "MIT OR Apache-2.0", if the license files support any of the alternatives.
+// Otherwise, the license files do not need the manifests and they are not consulted.
+func (result *Result) addDeclaredChoices(declarations map[string][]string, db *internal.Database) {
+	supported := result.Licenses()
+	for _, file := range sortedDeclarationKeys(declarations) {
+		for _, expression := range declarations[file] {
+			if !spdxDisjunctionRe.MatchString(expression) {
+				continue
+			}
+			for name := range internal.InvestigateDeclaredLicense(expression, db) {
+				if _, exists := supported[name]; exists {
+					result.addDeclared(file, PlanManifests, expression, db)
+					break
+				}
+			}
+		}
+	}
+}
+
+// markMissingLicenseFiles sets MissingLicenseFile of the declared licenses which are not
+// matched in any license file, if the license files plan found anything.
+func (result *Result) markMissingLicenseFiles() {
+	supported := map[string]bool{}
+	for _, match := range result.Matches {
+		if match.Plan == PlanLicenseFiles {
+			supported[match.License] = true
+		}
+	}
+	if len(supported) == 0 {
+		return
+	}
+	for i, match := range result.Matches {
+		if match.Plan == PlanManifests {
+			result.Matches[i].MissingLicenseFile = !supported[match.License]
+		}
+	}
+}
+
+// foldDuplicates keeps the most confident match of each license and sets its Evidence.
+func (result *Result) foldDuplicates() {
+	result.sort()
+	kept := map[string]int{}
+	folded := result.Matches[:0]
+	for _, match := range result.Matches {
+		if i, exists := kept[match.License]; exists {
+			folded[i].Evidence++
+			continue
+		}
+		kept[match.License] = len(folded)
+		match.Evidence = 1
+		folded = append(folded, match)
+	}
+	result.Matches = folded
+}
+
+// annotateOtherPlans moves the matches which do not originate from the first plan
+// to Annotations. The matches are appended in the order of the plans.
+func (result *Result) annotateOtherPlans() {
+	if len(result.Matches) == 0 {
+		return
+	}
+	authoritative := result.Matches[0].Plan
+	var matches []Match
+	for _, match := range result.Matches {
+		if match.Plan == authoritative {
+			matches = append(matches, match)
+		} else {
+			result.Annotations = append(result.Annotations, match)
+		}
+	}
+	result.Matches = matches
+	annotations := &Result{Matches: result.Annotations}
+	annotations.sort()
+}
+
+// restrict removes the matches of the licenses which are not allowed, see Options.Allowlist.
+func (result *Result) restrict(allowed map[string]bool) {
+	if allowed == nil {
+		return
+	}
+	kept := result.Matches[:0]
+	for _, match := range result.Matches {
+		if allowed[strings.ToLower(strings.TrimPrefix(match.License, "deprecated_"))] {
+			kept = append(kept, match)
+		}
+	}
+	result.Matches = kept
+}
+
+// hasLicense indicates whether any of the matched licenses starts with the given prefix.
+func (result *Result) hasLicense(prefix string) bool {
+	for _, match := range result.Matches {
+		if strings.HasPrefix(match.License, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// markDualLicensing sets DualLicensed if the commercial license file is matched together
+// with any other license.
+func (result *Result) markDualLicensing() {
+	var commercial, other bool
+	for _, match := range result.Matches {
+		if match.License == internal.Commercial {
+			commercial = true
+		} else {
+			other = true
+		}
+	}
+	result.DualLicensed = commercial && other
+}
+
+// sort orders the matches by confidence, license name, file path, plan and exception, and
+// the warnings alphabetically, so that the order does not depend on the map iteration.
+func (result *Result) sort() {
+	sort.Slice(result.Matches, func(i, j int) bool {
+		return lessMatch(result.Matches[i], result.Matches[j])
+	})
+	sort.Strings(result.Warnings)
+}
+
+// RankMatches sets Rank of the matches which are sorted like Result.Matches, e.g. by Sorted.
+// The ranking is dense: the matches with the same confidence share the rank, which is then
+// ordered by the license name, the file path, the plan and the exception, and the ranks
+// have no gaps, e.g. 1, 1, 2, 3.
+func RankMatches(matches []Match) {
+	for i := range matches {
+		switch {
+		case i == 0:
+			matches[i].Rank = 1
+		case matches[i].Confidence == matches[i-1].Confidence:
+			matches[i].Rank = matches[i-1].Rank
+		default:
+			matches[i].Rank = matches[i-1].Rank + 1
+		}
+	}
+}
+
+// lessMatch indicates whether mi goes before mj, see Result.Matches.
+func lessMatch(mi, mj Match) bool {
+	if mi.Confidence != mj.Confidence {
+		return mi.Confidence > mj.Confidence
+	}
+	if mi.License != mj.License {
+		return mi.License < mj.License
+	}
+	if mi.File != mj.File {
+		return mi.File < mj.File
+	}
+	if mi.Plan != mj.Plan {
+		return mi.Plan < mj.Plan
+	}
+	return mi.Exception < mj.Exception
+}
+
+// Sorted returns the licenses returned by Detect as the matches in the same order as
+// Result.Matches: by confidence in descending order and then by name. Only License and
+// Confidence of the matches are set.
+func Sorted(licenses map[string]float32) []Match {
+	matches := make([]Match, 0, len(licenses))
+	for name, confidence := range licenses {
+		matches = append(matches, Match{License: name, Confidence: confidence})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return lessMatch(matches[i], matches[j])
+	})
+	return matches
+}
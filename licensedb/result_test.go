@@ -0,0 +1,104 @@
+package licensedb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelConfidence(t *testing.T) {
+	for confidence, label := range map[float32]string{
+		1: "Exact", 0.99: "Exact", 0.9899: "High", 0.9: "High", 0.8999: "Likely",
+		0.75: "Likely", 0.7499: "Uncertain", 0: "Uncertain",
+	} {
+		assert.Equal(t, label, LabelConfidence(confidence, DefaultConfidenceLabels), confidence)
+	}
+	custom := []ConfidenceLabel{{Label: "Low", Threshold: 0.5}, {Label: "Good", Threshold: 0.8}}
+	assert.Equal(t, "Good", LabelConfidence(0.8, custom))
+	assert.Equal(t, "Low", LabelConfidence(0.79, custom))
+	assert.Equal(t, "", LabelConfidence(0.49, custom))
+
+	fs := memoryFiler{"LICENSE": referenceText(t, "MIT")}
+	result, err := DetectDetailedWithOptions(fs, Options{LabelConfidence: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "Exact", result.Matches[0].ConfidenceLabel)
+	result, err = DetectDetailedWithOptions(fs, Options{LabelConfidence: true, ConfidenceLabels: custom})
+	assert.Nil(t, err)
+	assert.Equal(t, "Good", result.Matches[0].ConfidenceLabel)
+	result, err = DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Empty(t, result.Matches[0].ConfidenceLabel)
+}
+
+func TestRankMatches(t *testing.T) {
+	matches := Sorted(map[string]float32{"MIT": 1, "Apache-2.0": 1, "ISC": 0.9, "BSD-3-Clause": 0.8})
+	RankMatches(matches)
+	assert.Equal(t, []Match{
+		{License: "Apache-2.0", Confidence: 1, Rank: 1}, {License: "MIT", Confidence: 1, Rank: 1},
+		{License: "ISC", Confidence: 0.9, Rank: 2}, {License: "BSD-3-Clause", Confidence: 0.8, Rank: 3},
+	}, matches)
+
+	fs := memoryFiler{
+		"LICENSE-MIT":    referenceText(t, "MIT"),
+		"LICENSE-APACHE": referenceText(t, "Apache-2.0"),
+		"README.md":      "# Foo\n\n## License\n\nFoo is licensed under BSD-3-Clause.\n",
+	}
+	var expected []Match
+	for i := 0; i < 5; i++ {
+		result, err := DetectDetailedWithOptions(fs, Options{RankMatches: true, RunAllPlans: true})
+		assert.Nil(t, err)
+		assert.NotEmpty(t, result.Matches)
+		for j, match := range result.Matches {
+			if j == 0 {
+				assert.Equal(t, 1, match.Rank)
+			} else if match.Confidence == result.Matches[j-1].Confidence {
+				assert.Equal(t, result.Matches[j-1].Rank, match.Rank)
+			} else {
+				assert.Equal(t, result.Matches[j-1].Rank+1, match.Rank)
+			}
+		}
+		if expected == nil {
+			expected = result.Matches
+		}
+		assert.Equal(t, expected, result.Matches)
+	}
+	result, err := DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Zero(t, result.Matches[0].Rank)
+}
+
+func TestDetectStableKeys(t *testing.T) {
+	fs := memoryFiler{"LICENSE": referenceText(t, "MIT")}
+	result, err := DetectDetailedWithOptions(fs, Options{StableKeys: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", result.Matches[0].License)
+	// the key is derived from the reference text, so it is the same in every run
+	const mitKey = "2bd1eaeb-afeb-531f-ae7a-0a0511af4429"
+	assert.Equal(t, mitKey, result.Matches[0].Key)
+	key, err := LicenseKey("MIT")
+	assert.Nil(t, err)
+	assert.Equal(t, mitKey, key)
+	keys, err := result.LicensesByKey()
+	assert.Nil(t, err)
+	assert.Equal(t, float32(1), keys[mitKey])
+	assert.Len(t, keys, len(result.Licenses()))
+	again, err := DetectDetailedWithOptions(fs, Options{StableKeys: true})
+	assert.Nil(t, err)
+	assert.Equal(t, result.Matches, again.Matches)
+
+	// the renamed SPDX identifier keeps the key
+	licenseKey := func(license string) string {
+		key, err := LicenseKey(license)
+		assert.Nil(t, err)
+		return key
+	}
+	assert.Equal(t, licenseKey("GPL-2.0-only"), licenseKey("deprecated_GPL-2.0"))
+	assert.NotEqual(t, licenseKey("GPL-2.0-only"), licenseKey("GPL-2.0-or-later"))
+	assert.NotEqual(t, licenseKey("MPL-2.0"), licenseKey("MPL-2.0-no-copyleft-exception"))
+	assert.NotEqual(t, licenseKey("MIT"), licenseKey("Apache-2.0"))
+	assert.Equal(t, licenseKey("GPL"), licenseKey("GPL"))
+
+	result, err = DetectDetailed(fs)
+	assert.Nil(t, err)
+	assert.Empty(t, result.Matches[0].Key)
+}
@@ -0,0 +1,89 @@
+package licensedb
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+// RetryPolicy repeats the failed ReadFile and ReadDir calls of the Filer, e.g. the remote
+// ones which hit a network glitch, see Options.Retry. The zero value does not retry.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of the calls for each read, including the first one.
+	// 0 and 1 mean no retries.
+	MaxAttempts int
+	// Backoff is the delay before the second attempt. It doubles before each next attempt.
+	Backoff time.Duration
+	// Retryable decides whether the error is transient. IsRetryable is used if it is nil.
+	Retryable func(err error) bool
+}
+
+// IsRetryable returns true if the error, or its cause, reports itself as temporary or as
+// a timeout, like net.Error does. The missing files are never retried.
+func IsRetryable(err error) bool {
+	err = errors.Cause(err)
+	if temporary, ok := err.(interface{ Temporary() bool }); ok && temporary.Temporary() {
+		return true
+	}
+	if timeout, ok := err.(interface{ Timeout() bool }); ok && timeout.Timeout() {
+		return true
+	}
+	return false
+}
+
+// retryingFiler applies RetryPolicy to the original Filer.
+type retryingFiler struct {
+	filer.Filer
+	policy RetryPolicy
+}
+
+// wrap returns the Filer which retries the reads, or fs itself if the policy does not retry.
+func (policy RetryPolicy) wrap(fs filer.Filer) filer.Filer {
+	if policy.MaxAttempts <= 1 {
+		return fs
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = IsRetryable
+	}
+	return &retryingFiler{Filer: fs, policy: policy}
+}
+
+func (fs *retryingFiler) ReadFile(path string) ([]byte, error) {
+	var content []byte
+	err := fs.retry(func() (err error) {
+		content, err = fs.Filer.ReadFile(path)
+		return err
+	})
+	return content, err
+}
+
+func (fs *retryingFiler) ReadFilePrefix(path string, size int) ([]byte, error) {
+	var content []byte
+	err := fs.retry(func() (err error) {
+		content, err = filer.ReadFilePrefix(fs.Filer, path, size)
+		return err
+	})
+	return content, err
+}
+
+func (fs *retryingFiler) ReadDir(path string) ([]filer.File, error) {
+	var files []filer.File
+	err := fs.retry(func() (err error) {
+		files, err = fs.Filer.ReadDir(path)
+		return err
+	})
+	return files, err
+}
+
+// retry calls read until it succeeds, fails with a non-retryable error or runs out of attempts.
+func (fs *retryingFiler) retry(read func() error) error {
+	backoff := fs.policy.Backoff
+	err := read()
+	for attempt := 1; err != nil && attempt < fs.policy.MaxAttempts && fs.policy.Retryable(err); attempt++ {
+		time.Sleep(backoff)
+		backoff *= 2
+		err = read()
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package licensedb
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "connection reset by peer" }
+func (temporaryError) Temporary() bool { return true }
+
+// glitchyFiler fails the first reads of each path with the specified error.
+type glitchyFiler struct {
+	memoryFiler
+	err      error
+	failures int
+	attempts map[string]int
+}
+
+func (fs glitchyFiler) ReadFile(path string) ([]byte, error) {
+	fs.attempts[path]++
+	if fs.attempts[path] <= fs.failures {
+		return nil, fs.err
+	}
+	return fs.memoryFiler.ReadFile(path)
+}
+
+func (fs glitchyFiler) ReadDir(path string) ([]filer.File, error) {
+	fs.attempts[path+"/"]++
+	if fs.attempts[path+"/"] <= fs.failures {
+		return nil, fs.err
+	}
+	return fs.memoryFiler.ReadDir(path)
+}
+
+func TestDetectRetry(t *testing.T) {
+	newFiler := func(err error) glitchyFiler {
+		return glitchyFiler{
+			memoryFiler: memoryFiler{"LICENSE": referenceText(t, "MIT")},
+			err:         err, failures: 1, attempts: map[string]int{},
+		}
+	}
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	fs := newFiler(temporaryError{})
+	licenses, err := DetectWithOptions(fs, Options{Retry: policy, FailOnReadError: true})
+	assert.Nil(t, err)
+	assert.Contains(t, licenses, "MIT")
+	assert.Equal(t, 2, fs.attempts["/"])
+	assert.Equal(t, 2, fs.attempts["LICENSE"])
+	// no retries by default
+	_, err = DetectWithOptions(newFiler(temporaryError{}), Options{})
+	assert.NotNil(t, err)
+	// the non-retryable errors fail immediately
+	fs = newFiler(errors.New("permission denied"))
+	_, err = DetectWithOptions(fs, Options{Retry: policy})
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, fs.attempts["/"])
+	// the attempts are limited
+	fs = newFiler(temporaryError{})
+	fs.failures = 5
+	_, err = DetectWithOptions(fs, Options{Retry: policy})
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, fs.attempts["/"])
+	// the custom classification
+	fs = newFiler(errors.New("permission denied"))
+	policy.Retryable = func(err error) bool { return err.Error() == "permission denied" }
+	licenses, err = DetectWithOptions(fs, Options{Retry: policy})
+	assert.Nil(t, err)
+	assert.Contains(t, licenses, "MIT")
+	assert.True(t, IsRetryable(temporaryError{}))
+	assert.False(t, IsRetryable(os.ErrNotExist))
+}
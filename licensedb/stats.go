@@ -0,0 +1,103 @@
+package licensedb
+
+import (
+	"time"
+
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+)
+
+const (
+	// PlanOverride is the name of the plan which reads the machine-readable license override,
+	// e.g. .license.yml, which takes precedence over all the other plans.
+	PlanOverride = "override"
+	// PlanLicenseFiles is the name of the plan which matches the license files.
+	PlanLicenseFiles = "license files"
+	// PlanManifests is the name of the plan which reads the licenses declared in the manifests,
+	// e.g. the OCI labels in Dockerfile.
+	PlanManifests = "manifests"
+	// PlanReadme is the name of the plan which scans README files for license mentions.
+	PlanReadme = "readme"
+	// PlanHeaders is the name of the plan which matches the header comments of the source files.
+	PlanHeaders = "header comments"
+	// PlanBinaries is the name of the plan which matches the license texts embedded in
+	// the binary files, see Options.ScanBinaries.
+	PlanBinaries = "binary strings"
+)
+
+// PlanStats contains the timings of a single detection plan.
+type PlanStats struct {
+	// Name of the plan, e.g. PlanLicenseFiles.
+	Name string
+	// Candidates is the number of texts which were investigated, e.g. the license files
+	// or the distinct header comments.
+	Candidates int
+	// Extraction is the time spent listing and reading the candidate files.
+	Extraction time.Duration
+	// Investigation is the time spent matching the candidates against the license database.
+	Investigation time.Duration
+}
+
+// Stats contains the timings of each detection plan which was executed, in the order of
+// execution, and how much was read from the file tree.
+type Stats struct {
+	Plans []PlanStats
+	// FilesRead is the number of files which were successfully read.
+	FilesRead int
+	// BytesRead is the overall size of the files which were successfully read.
+	BytesRead int64
+}
+
+// Total returns the overall time spent in all the plans.
+func (stats *Stats) Total() time.Duration {
+	var total time.Duration
+	for _, plan := range stats.Plans {
+		total += plan.Extraction + plan.Investigation
+	}
+	return total
+}
+
+// add records the plan which started at `start`, finished the extraction at `extracted`
+// and finished the investigation of `candidates` texts now. It is a no-op on nil receivers.
+func (stats *Stats) add(name string, candidates int, start, extracted time.Time) {
+	if stats == nil {
+		return
+	}
+	stats.Plans = append(stats.Plans, PlanStats{
+		Name:          name,
+		Candidates:    candidates,
+		Extraction:    extracted.Sub(start),
+		Investigation: time.Since(extracted),
+	})
+}
+
+// countingFiler accumulates the number of read files and their sizes in Stats.
+type countingFiler struct {
+	filer.Filer
+	stats *Stats
+}
+
+// wrap wraps fs so that the reads are counted. It returns fs as is on nil receivers.
+func (stats *Stats) wrap(fs filer.Filer) filer.Filer {
+	if stats == nil {
+		return fs
+	}
+	return &countingFiler{Filer: fs, stats: stats}
+}
+
+func (fs *countingFiler) ReadFile(path string) ([]byte, error) {
+	content, err := fs.Filer.ReadFile(path)
+	if err == nil {
+		fs.stats.FilesRead++
+		fs.stats.BytesRead += int64(len(content))
+	}
+	return content, err
+}
+
+func (fs *countingFiler) ReadFilePrefix(path string, size int) ([]byte, error) {
+	content, err := filer.ReadFilePrefix(fs.Filer, path, size)
+	if err == nil {
+		fs.stats.FilesRead++
+		fs.stats.BytesRead += int64(len(content))
+	}
+	return content, err
+}
@@ -0,0 +1,66 @@
+package licensedb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectWithStats(t *testing.T) {
+	fs := memoryFiler{
+		"README.md": "# Foo\n\n## License\n\nFoo is released under the MIT license.\n",
+		"main.go":   "package main",
+	}
+	start := time.Now()
+	licenses, stats, err := DetectWithStats(fs)
+	elapsed := time.Since(start)
+	assert.Nil(t, err)
+	assert.Contains(t, licenses, "MIT")
+	assert.Len(t, stats.Plans, 3)
+	assert.Equal(t, PlanLicenseFiles, stats.Plans[0].Name)
+	assert.Equal(t, PlanManifests, stats.Plans[1].Name)
+	assert.Equal(t, PlanReadme, stats.Plans[2].Name)
+	var sum time.Duration
+	for _, plan := range stats.Plans {
+		// the coarse clocks, e.g. on Windows, may measure nothing
+		assert.True(t, plan.Extraction >= 0)
+		assert.True(t, plan.Investigation >= 0)
+		sum += plan.Extraction + plan.Investigation
+	}
+	assert.Equal(t, sum, stats.Total())
+	assert.True(t, stats.Total() <= elapsed)
+	assert.Equal(t, 0, stats.Plans[0].Candidates)
+	assert.Equal(t, 0, stats.Plans[1].Candidates)
+	assert.Equal(t, 1, stats.Plans[2].Candidates)
+	assert.Equal(t, 1, stats.FilesRead)
+	assert.Equal(t, int64(len(fs["README.md"])), stats.BytesRead)
+
+	fs["LICENSE"] = referenceText(t, "MIT")
+	_, stats, err = DetectWithStats(fs)
+	assert.Nil(t, err)
+	assert.Len(t, stats.Plans, 1)
+	assert.Equal(t, PlanLicenseFiles, stats.Plans[0].Name)
+	assert.Equal(t, 1, stats.Plans[0].Candidates)
+	assert.Equal(t, 1, stats.FilesRead)
+	assert.Equal(t, int64(len(fs["LICENSE"])), stats.BytesRead)
+
+	delete(fs, "LICENSE")
+	fs["README.md"] = "# Foo\n"
+	fs["util.go"] = "// Copyright 2018 Foo Authors\n\npackage main"
+	_, stats, err = DetectWithStats(fs)
+	assert.Equal(t, ErrNoLicenseFound, err)
+	assert.Len(t, stats.Plans, 3)
+	assert.Equal(t, PlanReadme, stats.Plans[2].Name)
+	assert.Equal(t, 1, stats.Plans[2].Candidates)
+	assert.Equal(t, 1, stats.FilesRead)
+	stats = &Stats{}
+	_, err = detect(fs, Options{ScanHeaders: true}, stats)
+	assert.Equal(t, ErrNoLicenseFound, err)
+	assert.Len(t, stats.Plans, 4)
+	assert.Equal(t, PlanHeaders, stats.Plans[3].Name)
+	assert.Equal(t, 1, stats.Plans[3].Candidates)
+	assert.Equal(t, 3, stats.FilesRead)
+	assert.Equal(t, int64(len(fs["README.md"])+len(fs["main.go"])+len(fs["util.go"])),
+		stats.BytesRead)
+}
@@ -0,0 +1,122 @@
+package licensedb
+
+import (
+	paths "path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/filer"
+	"gopkg.in/src-d/go-license-detector.v2/licensedb/internal"
+)
+
+// Subtrees is the result of DetectWithSubtrees.
+type Subtrees struct {
+	// Root contains the licenses of the whole tree as returned by Detect. It is nil if
+	// the root has no license.
+	Root map[string]float32
+	// Conflicts maps the paths to the subdirectories which have their own license files to
+	// the licenses detected there, if the most confident of them is different from the root.
+	Conflicts map[string]map[string]float32
+}
+
+// DetectWithSubtrees detects the license of the whole tree and additionally the licenses of
+// the nested directories which carry their own license files. Only the directories whose
+// licenses differ from the root are reported, since they are the potential license conflicts.
+func DetectWithSubtrees(fs filer.Filer) (*Subtrees, error) {
+	root, err := Detect(fs)
+	if err != nil && err != ErrNoLicenseFound {
+		return nil, err
+	}
+	result := &Subtrees{Root: root, Conflicts: map[string]map[string]float32{}}
+	for _, dir := range listLicensedDirectories(fs, "") {
+		licenses, err := Detect(filer.NestFiler(fs, dir))
+		if err != nil {
+			continue
+		}
+		if _, exists := root[bestLicense(licenses)]; !exists {
+			result.Conflicts[dir] = licenses
+		}
+	}
+	return result, nil
+}
+
+// DetectSubdir is the same as Detect but treats the subdirectory as the root of the project,
+// e.g. "packages/foo" in a monorepo. The files outside of it are never read, so neither
+// the license files nor the README in the real root are considered.
+func DetectSubdir(fs filer.Filer, subdir string) (map[string]float32, error) {
+	subdir = paths.Clean(strings.Trim(subdir, "/"))
+	if subdir == "." {
+		return Detect(fs)
+	}
+	if subdir == ".." || strings.HasPrefix(subdir, "../") {
+		return nil, errors.Errorf("%s is outside of the tree", subdir)
+	}
+	if _, err := fs.ReadDir(subdir); err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", subdir)
+	}
+	return Detect(filer.NestFiler(fs, subdir))
+}
+
+// EffectiveLicense detects the license which applies to the file, since the license files in
+// the subdirectory override those in the root for the files beneath. The directories are
+// checked from that of the file up to the root, and the nearest which has its own license files
+// is detected like DetectSubdir. If there is none, the whole tree is detected like Detect.
+func EffectiveLicense(fs filer.Filer, filePath string) (map[string]float32, error) {
+	filePath = paths.Clean(strings.Trim(filePath, "/"))
+	if filePath == ".." || strings.HasPrefix(filePath, "../") {
+		return nil, errors.Errorf("%s is outside of the tree", filePath)
+	}
+	for dir := paths.Dir(filePath); dir != "."; dir = paths.Dir(dir) {
+		if hasLicenseFiles(fs, dir) {
+			return Detect(filer.NestFiler(fs, dir))
+		}
+	}
+	return Detect(fs)
+}
+
+// hasLicenseFiles indicates whether the directory directly contains any license file.
+func hasLicenseFiles(fs filer.Filer, dir string) bool {
+	files, err := fs.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, file := range files {
+		if !file.IsDir && internal.IsLicenseFile(file.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// listLicensedDirectories recursively finds the subdirectories which contain license files.
+// The hidden and the license directories themselves are not visited.
+func listLicensedDirectories(fs filer.Filer, dir string) []string {
+	files, err := fs.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var result []string
+	for _, file := range files {
+		if !file.IsDir || strings.HasPrefix(file.Name, ".") || internal.IsLicenseDirectory(file.Name) {
+			continue
+		}
+		path := paths.Join(dir, file.Name)
+		if hasLicenseFiles(fs, path) {
+			result = append(result, path)
+		}
+		result = append(result, listLicensedDirectories(fs, path)...)
+	}
+	return result
+}
+
+// bestLicense returns the license with the highest confidence. The ties are resolved
+// in favor of the lexicographically smaller name.
+func bestLicense(licenses map[string]float32) string {
+	var best string
+	for name, confidence := range licenses {
+		if best == "" || confidence > licenses[best] || (confidence == licenses[best] && name < best) {
+			best = name
+		}
+	}
+	return best
+}
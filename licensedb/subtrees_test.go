@@ -0,0 +1,74 @@
+package licensedb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectWithSubtrees(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE":                 referenceText(t, "MIT"),
+		"main.go":                 "package main",
+		"gpl-module/LICENSE":      referenceText(t, "GPL-3.0-only"),
+		"gpl-module/module.go":    "package module",
+		"mit-module/LICENSE.txt":  referenceText(t, "MIT"),
+		"docs/index.md":           "# Docs",
+		"deep/nested/lib/COPYING": referenceText(t, "BSD-3-Clause"),
+	}
+	subtrees, err := DetectWithSubtrees(fs)
+	assert.Nil(t, err)
+	assert.InDelta(t, 1, subtrees.Root["MIT"], 0.05)
+	assert.Len(t, subtrees.Conflicts, 2)
+	assert.Contains(t, subtrees.Conflicts, "gpl-module")
+	assert.Equal(t, "GPL-3.0-only", bestLicense(subtrees.Conflicts["gpl-module"]))
+	assert.Contains(t, subtrees.Conflicts, "deep/nested/lib")
+	assert.NotContains(t, subtrees.Conflicts, "mit-module")
+}
+
+func TestDetectSubdir(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE":                  referenceText(t, "MIT"),
+		"README.md":                "# Monorepo\n\nAll the packages are released under the MIT license.\n",
+		"packages/foo/LICENSE":     referenceText(t, "Apache-2.0"),
+		"packages/foo/index.js":    "module.exports = {};\n",
+		"packages/bar/README.md":   "# Bar\n\n## License\n\nBar is released under the ISC license.\n",
+		"packages/bar/src/main.go": "package main\n",
+	}
+	licenses, err := DetectSubdir(fs, "packages/foo")
+	assert.Nil(t, err)
+	assert.Equal(t, "Apache-2.0", bestLicense(licenses))
+	assert.NotContains(t, licenses, "MIT")
+	licenses, err = DetectSubdir(fs, "/packages/bar/")
+	assert.Nil(t, err)
+	assert.Equal(t, "ISC", bestLicense(licenses))
+	licenses, err = DetectSubdir(fs, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", bestLicense(licenses))
+	_, err = DetectSubdir(fs, "packages/baz")
+	assert.NotNil(t, err)
+	_, err = DetectSubdir(fs, "packages/../../etc")
+	assert.NotNil(t, err)
+}
+
+func TestEffectiveLicense(t *testing.T) {
+	fs := memoryFiler{
+		"LICENSE":                        referenceText(t, "MIT"),
+		"main.go":                        "package main\n",
+		"vendor/foo/LICENSE":             referenceText(t, "Apache-2.0"),
+		"vendor/foo/internal/bar/bar.go": "package bar\n",
+		"docs/guide.md":                  "# Guide\n",
+	}
+	licenses, err := EffectiveLicense(fs, "vendor/foo/internal/bar/bar.go")
+	assert.Nil(t, err)
+	assert.Equal(t, "Apache-2.0", bestLicense(licenses))
+	assert.NotContains(t, licenses, "MIT")
+	licenses, err = EffectiveLicense(fs, "/docs/guide.md")
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", bestLicense(licenses))
+	licenses, err = EffectiveLicense(fs, "main.go")
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", bestLicense(licenses))
+	_, err = EffectiveLicense(fs, "../etc/passwd")
+	assert.NotNil(t, err)
+}